@@ -0,0 +1,81 @@
+// Package auth verifies the bearer JWTs sent by clients and carries the
+// authenticated user through the request context.
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// publicMethods can be called without a token.
+var publicMethods = map[string]bool{
+	"/RecordsService/Ping": true,
+}
+
+type userKey struct{}
+
+// WithUser returns a copy of ctx carrying the authenticated user.
+func WithUser(ctx context.Context, userId primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, userKey{}, userId)
+}
+
+// UserFromContext returns the user authenticated by the interceptors.
+func UserFromContext(ctx context.Context) (primitive.ObjectID, bool) {
+	userId, ok := ctx.Value(userKey{}).(primitive.ObjectID)
+	return userId, ok
+}
+
+func (v *Verifier) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := v.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (v *Verifier) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (v *Verifier) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "a single bearer token is required")
+	}
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "authorization should be a bearer token")
+	}
+	userId, err := v.Verify(token)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid token: %v", err)
+	}
+	return WithUser(ctx, userId), nil
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Config lists the keys tokens may be signed with, at least one is required.
+type Config struct {
+	HMACSecret []byte
+	// PEM encoded RSA or ECDSA public key or certificate
+	PublicKeyFile string
+	// JSON Web Key Set, keys are picked by the token "kid" header
+	JWKSFile string
+	// checked against the "iss" and "aud" claims when set
+	Issuer   string
+	Audience string
+}
+
+func ConfigFromEnv() Config {
+	return Config{
+		HMACSecret:    []byte(os.Getenv("JWT_HMAC_SECRET")),
+		PublicKeyFile: os.Getenv("JWT_PUBLIC_KEY_FILE"),
+		JWKSFile:      os.Getenv("JWT_JWKS_FILE"),
+		Issuer:        os.Getenv("JWT_ISSUER"),
+		Audience:      os.Getenv("JWT_AUDIENCE"),
+	}
+}
+
+// Verifier checks bearer tokens and extracts the user they were issued to
+// from the "sub" claim.
+type Verifier struct {
+	hmacSecret []byte
+	publicKey  crypto.PublicKey
+	keys       map[string]any
+	parser     *jwt.Parser
+}
+
+func NewVerifier(c Config) (*Verifier, error) {
+	v := &Verifier{hmacSecret: c.HMACSecret, keys: map[string]any{}}
+	if c.PublicKeyFile != "" {
+		key, err := loadPublicKey(c.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		v.publicKey = key
+	}
+	if c.JWKSFile != "" {
+		keys, err := loadJWKS(c.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	}
+	if len(v.hmacSecret) == 0 && v.publicKey == nil && len(v.keys) == 0 {
+		return nil, errors.New("no jwt verification key configured")
+	}
+
+	opts := []jwt.ParserOption{jwt.WithValidMethods(v.methods()), jwt.WithExpirationRequired()}
+	if c.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(c.Issuer))
+	}
+	if c.Audience != "" {
+		opts = append(opts, jwt.WithAudience(c.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+	return v, nil
+}
+
+// Verify checks the token signature and claims and returns its subject.
+func (v *Verifier) Verify(token string) (primitive.ObjectID, error) {
+	claims := jwt.RegisteredClaims{}
+	if _, err := v.parser.ParseWithClaims(token, &claims, v.keyFunc); err != nil {
+		return primitive.NilObjectID, err
+	}
+	userId, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return primitive.NilObjectID, fmt.Errorf("subject should be a user id")
+	}
+	return userId, nil
+}
+
+// methods lists the algorithms the configured keys can verify.
+func (v *Verifier) methods() []string {
+	hmac, rsa, ec := len(v.hmacSecret) > 0, false, false
+	for _, key := range append([]any{v.publicKey}, values(v.keys)...) {
+		switch key.(type) {
+		case []byte:
+			hmac = true
+		case *rsaPublicKey:
+			rsa = true
+		case *ecdsaPublicKey:
+			ec = true
+		}
+	}
+	methods := []string{}
+	if hmac {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if rsa {
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512")
+	}
+	if ec {
+		methods = append(methods, "ES256", "ES384", "ES512")
+	}
+	return methods
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (any, error) {
+	// a key id selects a single key from the set, otherwise the
+	// standalone secret and public key are used
+	var key any = v.publicKey
+	kid, _ := token.Header["kid"].(string)
+	fromSet := kid != "" && len(v.keys) > 0
+	if fromSet {
+		var ok bool
+		if key, ok = v.keys[kid]; !ok {
+			return nil, fmt.Errorf("unknown key id '%s'", kid)
+		}
+	}
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if secret, ok := key.([]byte); ok {
+			return secret, nil
+		}
+		if !fromSet && len(v.hmacSecret) > 0 {
+			return v.hmacSecret, nil
+		}
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if k, ok := key.(*rsaPublicKey); ok {
+			return (*rsa.PublicKey)(k), nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if k, ok := key.(*ecdsaPublicKey); ok {
+			return (*ecdsa.PublicKey)(k), nil
+		}
+	}
+	return nil, fmt.Errorf("no key for algorithm %s", token.Method.Alg())
+}
+
+// distinct types so a key of one kind is never used for another algorithm
+type (
+	rsaPublicKey   rsa.PublicKey
+	ecdsaPublicKey ecdsa.PublicKey
+)
+
+func wrapPublicKey(key any) (crypto.PublicKey, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return (*rsaPublicKey)(k), nil
+	case *ecdsa.PublicKey:
+		return (*ecdsaPublicKey)(k), nil
+	}
+	return nil, fmt.Errorf("unsupported public key type %T", key)
+}
+
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return wrapPublicKey(key)
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return wrapPublicKey(key)
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return wrapPublicKey(cert.PublicKey)
+	}
+	return nil, fmt.Errorf("unsupported PEM block '%s' in %s", block.Type, path)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// symmetric
+	K string `json:"k"`
+}
+
+func loadJWKS(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks file %s: %w", path, err)
+	}
+	keys := map[string]any{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("jwks key '%s': %w", k.Kid, err)
+		}
+		keys[k.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa exponent is too large")
+		}
+		return (*rsaPublicKey)(&rsa.PublicKey{N: n, E: int(e.Int64())}), nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return (*ecdsaPublicKey)(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}), nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid base64url integer")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func values(m map[string]any) []any {
+	list := []any{}
+	for _, v := range m {
+		list = append(list, v)
+	}
+	return list
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccountKinds are the kinds of accounts records can be booked on.
+var AccountKinds = []string{"CASH", "BANK", "CARD", "SAVINGS"}
+
+// Account is a wallet, bank account or card holding money in a single
+// currency.
+type Account struct {
+	ID       primitive.ObjectID `bson:"_id" json:"_id"`
+	UserId   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name     string             `bson:"name" json:"name"`
+	Kind     string             `bson:"kind" json:"kind"`
+	Currency string             `bson:"currency" json:"currency"`
+	// OpeningBalance is in minor units of Currency, negative for debts
+	OpeningBalance int64     `bson:"opening_balance" json:"opening_balance"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt      time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// AccountTotals sums the records booked on an account by type, the
+// records of transfers apart from the others.
+type AccountTotals struct {
+	Income       int64
+	Expense      int64
+	TransfersIn  int64
+	TransfersOut int64
+}
+
+// Balance is the balance of the account given the totals of its records.
+func (a Account) Balance(t AccountTotals) int64 {
+	return a.OpeningBalance + t.Income - t.Expense + t.TransfersIn - t.TransfersOut
+}
+
+// AccountStore persists the user's accounts.
+type AccountStore interface {
+	// CreateAccount inserts a and sets its ID.
+	CreateAccount(ctx context.Context, a *Account) error
+	// GetAccount returns the user's account with the given id or ErrNotFound.
+	GetAccount(ctx context.Context, userId, id primitive.ObjectID) (Account, error)
+	// UpdateAccount overwrites the given fields, every one of AccountFields
+	// when empty, of the account a.ID owned by a.UserId and reads the
+	// stored account back into a. The currency of an account with records
+	// cannot change.
+	UpdateAccount(ctx context.Context, a *Account, fields []string) error
+	// ListAccounts returns every account of the user ordered by name.
+	ListAccounts(ctx context.Context, userId primitive.ObjectID) ([]Account, error)
+	// DeleteAccount removes an account without records.
+	DeleteAccount(ctx context.Context, userId, id primitive.ObjectID) error
+	// AccountTotals sums the user's records dated before the given time,
+	// every record when it is zero, per account.
+	AccountTotals(ctx context.Context, userId primitive.ObjectID, before time.Time) (map[primitive.ObjectID]AccountTotals, error)
+}
+
+// ErrAccountNotFound is the ErrNotFound of account lookups.
+var ErrAccountNotFound = fmt.Errorf("%w: no such account", ErrNotFound)
+
+// ErrAccountInUse refuses changes to accounts records are booked on.
+var ErrAccountInUse = fmt.Errorf("%w: the account has records", ErrFailedPrecondition)
+
+// AccountFields are the fields of an account an update can change.
+var AccountFields = []string{"name", "kind", "currency_code", "opening_balance"}
+
+// Validate checks the fields of an account being created.
+func (a *Account) Validate() error {
+	return a.ValidateFields(AccountFields)
+}
+
+// ValidateFields is Validate restricted to the fields a partial update writes.
+func (a *Account) ValidateFields(fields []string) error {
+	v := Violations{}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			a.Name = strings.TrimSpace(a.Name)
+			if a.Name == "" {
+				v.Add("name", fmt.Errorf("name should not be empty"))
+			} else if utf8.RuneCountInString(a.Name) > maxCategoryName {
+				v.Add("name", fmt.Errorf("name should not be longer than %d characters", maxCategoryName))
+			}
+		case "kind":
+			if !containsString(AccountKinds, a.Kind) {
+				v.Add("kind", fmt.Errorf("kind should be one of %s", strings.Join(AccountKinds, ", ")))
+			}
+		case "currency_code":
+			v.Add("currency_code", Money{Currency: a.Currency}.Validate())
+		case "opening_balance":
+		default:
+			v.Add(field, fmt.Errorf("'%s' is not an updatable account field", field))
+		}
+	}
+	return v.Err()
+}
+
+// UpdateAccountFields defaults an empty field list of an update to every field.
+func UpdateAccountFields(fields []string) []string {
+	if len(fields) == 0 {
+		return AccountFields
+	}
+	return fields
+}
+
+// ApplyFields copies the given fields of src into a.
+func (a *Account) ApplyFields(src Account, fields []string) {
+	for _, field := range fields {
+		switch field {
+		case "name":
+			a.Name = src.Name
+		case "kind":
+			a.Kind = src.Kind
+		case "currency_code":
+			a.Currency = src.Currency
+		case "opening_balance":
+			a.OpeningBalance = src.OpeningBalance
+		}
+	}
+}
+
+// AddRecord adds the amount of a record to the totals of its type.
+func (t *AccountTotals) AddRecord(recordType string, transfer bool, units int64) {
+	switch {
+	case transfer && recordType == "INCOME":
+		t.TransfersIn += units
+	case transfer:
+		t.TransfersOut += units
+	case recordType == "INCOME":
+		t.Income += units
+	default:
+		t.Expense += units
+	}
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MaxBatchSize is the largest number of records a batch can write.
+const MaxBatchSize = 100
+
+// RecordUpdate is an item of UpdateMany, see RecordStore.Update.
+type RecordUpdate struct {
+	Record Record
+	Fields []string
+}
+
+// RecordDelete is an item of DeleteMany, see RecordStore.Delete.
+type RecordDelete struct {
+	ID      primitive.ObjectID
+	Version int64
+}
+
+// CheckBatchSize checks a batch has between one and MaxBatchSize items.
+func CheckBatchSize(n int) error {
+	if n == 0 {
+		return InvalidField("records", fmt.Errorf("a batch holds at least one record"))
+	}
+	if n > MaxBatchSize {
+		return InvalidField("records", fmt.Errorf("a batch holds at most %d records, not %d", MaxBatchSize, n))
+	}
+	return nil
+}
+
+// CheckBatch validates records written together and checks their
+// references, the fields of the invalid ones are named
+// "records[i].field".
+func CheckBatch(records []Record, refs RecordRefs) error {
+	v := Violations{}
+	for i := range records {
+		err := records[i].Validate()
+		if err == nil {
+			err = refs.Check(records[i])
+		}
+		var validation *ValidationError
+		if !errors.As(err, &validation) {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		v = append(v, batchViolations(i, validation)...)
+	}
+	return v.Err()
+}
+
+// BatchItemError is the error of the i-th item of a batch: the fields of
+// a ValidationError are named "records[i].field" and other errors are
+// wrapped with the position of the item.
+func BatchItemError(i int, err error) error {
+	var validation *ValidationError
+	if errors.As(err, &validation) {
+		return batchViolations(i, validation).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("records[%d]: %w", i, err)
+	}
+	return nil
+}
+
+func batchViolations(i int, err *ValidationError) Violations {
+	v := Violations{}
+	for _, violation := range err.Violations {
+		violation.Field = fmt.Sprintf("records[%d].%s", i, violation.Field)
+		v = append(v, violation)
+	}
+	return v
+}
+
+// BatchTargets are the records written by the items of a batch so far,
+// two items cannot write the same record, nor both sides of a transfer.
+type BatchTargets map[primitive.ObjectID]bool
+
+// Add marks the records written by an item, or fails when one of them is
+// written by a previous item.
+func (t BatchTargets) Add(ids ...primitive.ObjectID) error {
+	for _, id := range ids {
+		if t[id] {
+			return InvalidField("id", fmt.Errorf("record %s is already written by another item of the batch", id.Hex()))
+		}
+	}
+	for _, id := range ids {
+		if !id.IsZero() {
+			t[id] = true
+		}
+	}
+	return nil
+}
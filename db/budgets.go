@@ -0,0 +1,256 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Budget limits the expenses of a category, with its subcategories, or
+// of a tag over every period of SummaryPeriods.
+type Budget struct {
+	ID     primitive.ObjectID `bson:"_id" json:"_id"`
+	UserId primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Name   string             `bson:"name" json:"name"`
+	// a budget tracks either CategoryId or Tag
+	CategoryId primitive.ObjectID `bson:"category_id,omitempty" json:"category_id"`
+	Tag        string             `bson:"tag,omitempty" json:"tag"`
+	// Limit is the amount available per period, only expenses in its
+	// currency count against it
+	Limit  Money  `bson:"limit" json:"limit"`
+	Period string `bson:"period" json:"period"`
+	// Start is in the first period of the budget
+	Start time.Time `bson:"start" json:"start"`
+	// Rollover carries the amount left unspent at the end of a period over
+	// to the next one
+	Rollover  bool      `bson:"rollover" json:"rollover"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// BudgetStore persists the user's budgets.
+type BudgetStore interface {
+	// CreateBudget inserts b and sets its ID.
+	CreateBudget(ctx context.Context, b *Budget) error
+	// GetBudget returns the user's budget with the given id or ErrNotFound.
+	GetBudget(ctx context.Context, userId, id primitive.ObjectID) (Budget, error)
+	// UpdateBudget overwrites the given fields, every one of BudgetFields
+	// when empty, of the budget b.ID owned by b.UserId and reads the stored
+	// budget back into b.
+	UpdateBudget(ctx context.Context, b *Budget, fields []string) error
+	// ListBudgets returns every budget of the user ordered by name.
+	ListBudgets(ctx context.Context, userId primitive.ObjectID) ([]Budget, error)
+	// DeleteBudget removes the user's budget.
+	DeleteBudget(ctx context.Context, userId, id primitive.ObjectID) error
+}
+
+// ErrBudgetNotFound is the ErrNotFound of budget lookups.
+var ErrBudgetNotFound = fmt.Errorf("%w: no such budget", ErrNotFound)
+
+// BudgetFields are the fields of a budget an update can change.
+var BudgetFields = []string{"name", "category_id", "tag", "limit", "period", "start_date", "rollover"}
+
+// Validate checks the fields of a budget being created, it starts now
+// unless told otherwise.
+func (b *Budget) Validate() error {
+	if b.Start.IsZero() {
+		b.Start = Now()
+	}
+	return b.ValidateFields(BudgetFields)
+}
+
+// ValidateFields is Validate restricted to the fields a partial update writes.
+func (b *Budget) ValidateFields(fields []string) error {
+	v := Violations{}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			b.Name = strings.TrimSpace(b.Name)
+			if b.Name == "" {
+				v.Add("name", fmt.Errorf("name should not be empty"))
+			} else if utf8.RuneCountInString(b.Name) > maxCategoryName {
+				v.Add("name", fmt.Errorf("name should not be longer than %d characters", maxCategoryName))
+			}
+		case "category_id":
+		case "tag":
+			if b.Tag != "" {
+				tags, err := NormalizeTags([]string{b.Tag})
+				v.Add("tag", err)
+				if err == nil {
+					b.Tag = tags[0]
+				}
+			}
+		case "limit":
+			v.Add("limit.currency_code", b.Limit.Validate())
+			if b.Limit.Units <= 0 {
+				v.Add("limit.units", fmt.Errorf("limit should be positive"))
+			}
+		case "period":
+			if b.Period == "" {
+				b.Period = "MONTH"
+			}
+			if !containsString(SummaryPeriods, b.Period) {
+				v.Add("period", fmt.Errorf("period should be one of %s", strings.Join(SummaryPeriods, ", ")))
+			}
+		case "start_date":
+			b.Start = NormalizeTime(b.Start)
+			v.Add("start_date", checkDate(b.Start))
+		case "rollover":
+		default:
+			v.Add(field, fmt.Errorf("'%s' is not an updatable budget field", field))
+		}
+	}
+	return v.Err()
+}
+
+// Check verifies a complete budget tracks either a category accepting
+// expenses or a tag.
+func (b Budget) Check(refs RecordRefs) error {
+	switch {
+	case b.CategoryId.IsZero() && b.Tag == "":
+		return InvalidField("category_id", fmt.Errorf("a budget tracks either a category or a tag"))
+	case !b.CategoryId.IsZero() && b.Tag != "":
+		return InvalidField("tag", fmt.Errorf("a budget tracks either a category or a tag, not both"))
+	case b.Tag != "":
+		return nil
+	}
+	return refs.Check(Record{UserId: b.UserId, Type: "EXPENSE", CategoryId: b.CategoryId})
+}
+
+// UpdateBudgetFields defaults an empty field list of an update to every field.
+func UpdateBudgetFields(fields []string) []string {
+	if len(fields) == 0 {
+		return BudgetFields
+	}
+	return fields
+}
+
+// ApplyFields copies the given fields of src into b.
+func (b *Budget) ApplyFields(src Budget, fields []string) {
+	for _, field := range fields {
+		switch field {
+		case "name":
+			b.Name = src.Name
+		case "category_id":
+			b.CategoryId = src.CategoryId
+		case "tag":
+			b.Tag = src.Tag
+		case "limit":
+			b.Limit = src.Limit
+		case "period":
+			b.Period = src.Period
+		case "start_date":
+			b.Start = src.Start
+		case "rollover":
+			b.Rollover = src.Rollover
+		}
+	}
+}
+
+// BudgetStatus is the progress of a budget over the period of a given time.
+type BudgetStatus struct {
+	Budget Budget
+	// Start is the first instant of the period and End the first one of
+	// the next period
+	Start time.Time
+	End   time.Time
+	// Rollover is the amount left unspent by the previous periods
+	Rollover int64
+	Spent    int64
+	// Projected is the spend at the end of the period if it goes on at
+	// the same pace
+	Projected int64
+}
+
+// Available is the limit of the period with the rollover.
+func (s BudgetStatus) Available() int64 {
+	return s.Budget.Limit.Units + s.Rollover
+}
+
+// Remaining is negative once the budget is overspent.
+func (s BudgetStatus) Remaining() int64 {
+	return s.Available() - s.Spent
+}
+
+// Over reports whether more than available was spent.
+func (s BudgetStatus) Over() bool {
+	return s.Spent > s.Available()
+}
+
+// AtRisk reports whether the projected spend exceeds the budget.
+func (s BudgetStatus) AtRisk() bool {
+	return !s.Over() && s.Projected > s.Available()
+}
+
+// SummaryQuery selects the expenses the budget tracks up to the end of
+// the period of at, from its first period when they roll over. categories
+// are the category of the budget and its subcategories.
+func (b Budget) SummaryQuery(at time.Time, loc *time.Location, categories []primitive.ObjectID) SummaryQuery {
+	start := PeriodStart(at, b.Period, loc)
+	from := start
+	if first := PeriodStart(b.Start, b.Period, loc); b.Rollover && first.Before(start) {
+		from = first
+	}
+	filter := RecordFilter{Type: "EXPENSE", Currency: b.Limit.Currency, From: from, To: nextPeriod(start, b.Period)}
+	if b.Tag != "" {
+		filter.AnyTags = []string{b.Tag}
+	} else {
+		filter.Categories = categories
+	}
+	return SummaryQuery{UserId: b.UserId, Filter: filter, Period: b.Period, Location: loc}
+}
+
+// Status computes the status of the budget at the given time out of the
+// rows of its SummaryQuery.
+func (b Budget) Status(rows []SummaryRow, at time.Time, loc *time.Location) BudgetStatus {
+	spent := map[int64]int64{}
+	for _, row := range rows {
+		spent[row.Start.UnixMilli()] += row.Expense
+	}
+	s := BudgetStatus{Budget: b, Start: PeriodStart(at, b.Period, loc)}
+	s.End = nextPeriod(s.Start, b.Period)
+	if b.Rollover {
+		for p := PeriodStart(b.Start, b.Period, loc); p.Before(s.Start); p = nextPeriod(p, b.Period) {
+			s.Rollover = max64(0, s.Rollover+b.Limit.Units-spent[p.UnixMilli()])
+		}
+	}
+	s.Spent = spent[s.Start.UnixMilli()]
+
+	// the pace is taken over at least a day so the first hours of a period
+	// do not project wildly
+	length := s.End.Sub(s.Start)
+	elapsed := at.Sub(s.Start)
+	if elapsed < 24*time.Hour {
+		elapsed = 24 * time.Hour
+	}
+	if elapsed > length {
+		elapsed = length
+	}
+	s.Projected = int64(math.Round(float64(s.Spent) * float64(length) / float64(elapsed)))
+	return s
+}
+
+// nextPeriod is the start of the period after the one starting at start.
+func nextPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case "DAY":
+		return start.AddDate(0, 0, 1)
+	case "WEEK":
+		return start.AddDate(0, 0, 7)
+	case "YEAR":
+		return start.AddDate(1, 0, 0)
+	}
+	return start.AddDate(0, 1, 0)
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,238 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Category groups a user's records, categories nest under a parent to
+// form a tree per user.
+type Category struct {
+	ID     primitive.ObjectID `bson:"_id" json:"_id"`
+	UserId primitive.ObjectID `bson:"user_id" json:"user_id"`
+	// ParentId is the NilObjectID for top level categories
+	ParentId primitive.ObjectID `bson:"parent_id" json:"parent_id"`
+	Name     string             `bson:"name" json:"name"`
+	Icon     string             `bson:"icon" json:"icon"`
+	// Color is empty or a "#rrggbb" hex color
+	Color string `bson:"color" json:"color"`
+	// Type restricts the records of the category to EXPENSE or INCOME,
+	// empty accepts both
+	Type      string    `bson:"type" json:"type"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// CategoryStore persists categories next to the records they classify.
+type CategoryStore interface {
+	// CreateCategory inserts c and sets its ID.
+	CreateCategory(ctx context.Context, c *Category) error
+	// GetCategory returns the user's category with the given id or ErrNotFound.
+	GetCategory(ctx context.Context, userId, id primitive.ObjectID) (Category, error)
+	// UpdateCategory overwrites the given fields, every one of
+	// CategoryFields when empty, of the category c.ID owned by c.UserId
+	// and reads the stored category back into c.
+	UpdateCategory(ctx context.Context, c *Category, fields []string) error
+	// ListCategories returns every category of the user ordered by name.
+	ListCategories(ctx context.Context, userId primitive.ObjectID) ([]Category, error)
+	// DeleteCategory removes the user's category. Its records, budgets and
+	// subcategories move to moveTo, or when it is the NilObjectID the
+	// records become uncategorized, the budgets are deleted and the
+	// subcategories move up to the parent of the deleted category. Every
+	// record moved gets an UPDATED revision.
+	DeleteCategory(ctx context.Context, userId, id, moveTo primitive.ObjectID) error
+}
+
+// ErrCategoryNotFound is the ErrNotFound of category lookups.
+var ErrCategoryNotFound = fmt.Errorf("%w: no such category", ErrNotFound)
+
+// CategoryFields are the fields of a category an update can change.
+var CategoryFields = []string{"name", "parent_id", "icon", "color", "type"}
+
+const maxCategoryName = 64
+
+var colorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Validate checks the fields of a category being created.
+func (c *Category) Validate() error {
+	return c.ValidateFields(CategoryFields)
+}
+
+// ValidateFields is Validate restricted to the fields a partial update writes.
+func (c *Category) ValidateFields(fields []string) error {
+	v := Violations{}
+	for _, field := range fields {
+		switch field {
+		case "name":
+			c.Name = strings.TrimSpace(c.Name)
+			if c.Name == "" {
+				v.Add("name", fmt.Errorf("name should not be empty"))
+			} else if utf8.RuneCountInString(c.Name) > maxCategoryName {
+				v.Add("name", fmt.Errorf("name should not be longer than %d characters", maxCategoryName))
+			}
+		case "icon":
+			if utf8.RuneCountInString(c.Icon) > maxCategoryName {
+				v.Add("icon", fmt.Errorf("icon should not be longer than %d characters", maxCategoryName))
+			}
+		case "color":
+			if c.Color != "" && !colorPattern.MatchString(c.Color) {
+				v.Add("color", fmt.Errorf("color should be a hex color such as '#1e90ff'"))
+			}
+		case "type":
+			if c.Type != "" {
+				v.Add("type", TypeCheck(c.Type))
+			}
+		case "parent_id":
+			if c.ParentId == c.ID && !c.ID.IsZero() {
+				v.Add("parent_id", fmt.Errorf("a category cannot be its own parent"))
+			}
+		default:
+			v.Add(field, fmt.Errorf("'%s' is not an updatable category field", field))
+		}
+	}
+	return v.Err()
+}
+
+// UpdateCategoryFields defaults an empty field list of an update to every field.
+func UpdateCategoryFields(fields []string) []string {
+	if len(fields) == 0 {
+		return CategoryFields
+	}
+	return fields
+}
+
+// ApplyFields copies the given fields of src into c.
+func (c *Category) ApplyFields(src Category, fields []string) {
+	for _, field := range fields {
+		switch field {
+		case "name":
+			c.Name = src.Name
+		case "parent_id":
+			c.ParentId = src.ParentId
+		case "icon":
+			c.Icon = src.Icon
+		case "color":
+			c.Color = src.Color
+		case "type":
+			c.Type = src.Type
+		}
+	}
+}
+
+// Accepts reports whether records of the given type may use the category.
+func (c Category) Accepts(recordType string) bool {
+	return c.Type == "" || c.Type == recordType
+}
+
+// CategoryTree indexes the categories of a user for the checks needing
+// the whole tree.
+type CategoryTree map[primitive.ObjectID]Category
+
+func NewCategoryTree(categories []Category) CategoryTree {
+	tree := CategoryTree{}
+	for _, c := range categories {
+		tree[c.ID] = c
+	}
+	return tree
+}
+
+// Descendants returns id and the ids of every category below it.
+func (t CategoryTree) Descendants(id primitive.ObjectID) []primitive.ObjectID {
+	ids := []primitive.ObjectID{id}
+	for i := 0; i < len(ids); i++ {
+		for _, c := range t {
+			if c.ParentId == ids[i] {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+	return ids
+}
+
+// isBelow reports whether id is ancestor itself or one of its descendants.
+func (t CategoryTree) isBelow(id, ancestor primitive.ObjectID) bool {
+	for seen := 0; !id.IsZero() && seen <= len(t); seen++ {
+		if id == ancestor {
+			return true
+		}
+		id = t[id].ParentId
+	}
+	return false
+}
+
+// CheckPlacement verifies c can be stored in the tree: its parent exists,
+// it does not end up below itself and the type restrictions of its
+// parent and subcategories agree with its own. An empty type is
+// inherited from a restricted parent.
+func (t CategoryTree) CheckPlacement(c *Category) error {
+	if c.ParentId.IsZero() {
+		return t.checkChildren(*c)
+	}
+	parent, ok := t[c.ParentId]
+	if !ok {
+		return InvalidField("parent_id", fmt.Errorf("category '%s' does not exist", c.ParentId.Hex()))
+	}
+	if !c.ID.IsZero() && t.isBelow(c.ParentId, c.ID) {
+		return InvalidField("parent_id", fmt.Errorf("a category cannot be moved below itself"))
+	}
+	if c.Type == "" {
+		c.Type = parent.Type
+	}
+	if parent.Type != "" && c.Type != parent.Type {
+		return InvalidField("type", fmt.Errorf("parent category '%s' only accepts %s records", parent.Name, parent.Type))
+	}
+	return t.checkChildren(*c)
+}
+
+func (t CategoryTree) checkChildren(c Category) error {
+	if c.Type == "" || c.ID.IsZero() {
+		return nil
+	}
+	for _, child := range t {
+		if child.ParentId == c.ID && child.Type != c.Type {
+			return fmt.Errorf("%w: subcategory '%s' accepts other than %s records", ErrFailedPrecondition, child.Name, c.Type)
+		}
+	}
+	return nil
+}
+
+// MergeCategoryUpdate applies the given fields of c to the stored category and
+// checks the result still fits in the tree of the user's categories.
+func MergeCategoryUpdate(stored, c Category, fields []string, categories []Category) (Category, error) {
+	merged := stored
+	merged.ApplyFields(c, fields)
+	if err := NewCategoryTree(categories).CheckPlacement(&merged); err != nil {
+		return merged, err
+	}
+	return merged, nil
+}
+
+// CheckMove verifies the records and subcategories of the category id
+// can be moved to moveTo when deleting it and returns the deleted
+// category.
+func (t CategoryTree) CheckMove(id, moveTo primitive.ObjectID) (Category, error) {
+	c, ok := t[id]
+	if !ok {
+		return c, ErrCategoryNotFound
+	}
+	if moveTo.IsZero() {
+		return c, nil
+	}
+	target, ok := t[moveTo]
+	if !ok {
+		return c, InvalidField("move_to", fmt.Errorf("category '%s' does not exist", moveTo.Hex()))
+	}
+	if t.isBelow(moveTo, id) {
+		return c, InvalidField("move_to", fmt.Errorf("records cannot be moved to the deleted category or below it"))
+	}
+	if target.Type != "" && target.Type != c.Type {
+		return c, InvalidField("move_to", fmt.Errorf("category '%s' only accepts %s records", target.Name, target.Type))
+	}
+	return c, nil
+}
@@ -9,10 +9,22 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var (
-	DB          *mongo.Database
-	RecordsColl *mongo.Collection
-)
+var DB *mongo.Database
+
+// Store is everything the services need from a storage backend.
+type Store interface {
+	RecordStore
+	CategoryStore
+	TagStore
+	AccountStore
+	TransferStore
+	ScheduleStore
+	SummaryStore
+	BudgetStore
+	IdempotencyStore
+	TrashStore
+	HistoryStore
+}
 
 func ConnectDB() *mongo.Client {
 	uri := os.Getenv("MONGODB_URI")
@@ -24,7 +36,6 @@ func ConnectDB() *mongo.Client {
 		log.Fatalln(err.Error())
 	}
 	DB = client.Database("finetrack")
-	RecordsColl = DB.Collection("records")
 
 	return client
 }
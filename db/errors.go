@@ -0,0 +1,65 @@
+package db
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrNotFound      = errors.New("record not found")
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrFailedPrecondition wraps writes refused because of the current
+	// state of the stored data rather than the request itself.
+	ErrFailedPrecondition = errors.New("failed precondition")
+	// ErrVersionMismatch is returned by conditional writes when the record
+	// was changed since the version the client read.
+	ErrVersionMismatch = errors.New("record was modified concurrently")
+	// ErrUnavailable wraps backend errors worth retrying, such as lost
+	// connections or a locked database.
+	ErrUnavailable = errors.New("storage unavailable")
+)
+
+// FieldViolation describes an invalid field, named by its path in the
+// API messages such as "amount.currency_code".
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// ValidationError lists every invalid field of a write or query.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := []string{}
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Field+": "+v.Description)
+	}
+	return "invalid argument: " + strings.Join(msgs, "; ")
+}
+
+// Violations collects field violations while validating.
+type Violations []FieldViolation
+
+// Add records err against field, nil errors are ignored.
+func (v *Violations) Add(field string, err error) {
+	if err != nil {
+		*v = append(*v, FieldViolation{Field: field, Description: err.Error()})
+	}
+}
+
+// Err returns a *ValidationError when any violation was added.
+func (v Violations) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v}
+}
+
+// InvalidField is a ValidationError for a single field.
+func InvalidField(field string, err error) error {
+	v := Violations{}
+	v.Add(field, err)
+	return v.Err()
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The actions of the revisions of a record.
+const (
+	RevisionCreated  = "CREATED"
+	RevisionUpdated  = "UPDATED"
+	RevisionDeleted  = "DELETED"
+	RevisionRestored = "RESTORED"
+)
+
+// Revision is an entry of the history of a record, appended by the stores
+// along with every write of the record and never changed afterwards.
+type Revision struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	UserId   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RecordId primitive.ObjectID `bson:"record_id" json:"record_id"`
+	Action   string             `bson:"action" json:"action"`
+	Actor    Actor              `bson:"actor" json:"actor"`
+	// Changes lists the fields of RecordFields the write changed, empty
+	// for a delete
+	Changes []FieldChange `bson:"changes" json:"changes"`
+	// Record is the record as the write left it, as it was deleted for a
+	// delete
+	Record    Record    `bson:"record" json:"record"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// FieldChange is the old and new value of a field, formatted as
+// RecordValue does.
+type FieldChange struct {
+	Field    string `bson:"field" json:"field"`
+	OldValue string `bson:"old_value" json:"old_value"`
+	NewValue string `bson:"new_value" json:"new_value"`
+}
+
+// Actor is who made a write and from which client, the zero Actor for the
+// writes of the server itself such as the records of schedules.
+type Actor struct {
+	UserId    primitive.ObjectID `bson:"user_id,omitempty" json:"user_id"`
+	UserAgent string             `bson:"user_agent,omitempty" json:"user_agent"`
+	Address   string             `bson:"address,omitempty" json:"address"`
+}
+
+// HistoryStore reads the revisions the record stores append.
+type HistoryStore interface {
+	// GetRecordHistory returns the revisions of the user's record, oldest
+	// first, which outlive the record itself.
+	GetRecordHistory(ctx context.Context, userId, recordId primitive.ObjectID) ([]Revision, error)
+}
+
+type actorKey struct{}
+
+// WithActor returns ctx carrying the actor of the writes made with it.
+func WithActor(ctx context.Context, a Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, a)
+}
+
+// ActorOf is the actor ctx carries, the zero Actor when it carries none.
+func ActorOf(ctx context.Context) Actor {
+	a, _ := ctx.Value(actorKey{}).(Actor)
+	return a
+}
+
+// NewRevision is the revision of a write by the actor of ctx, from the
+// record before to the record after it; a delete has no after record.
+func NewRevision(ctx context.Context, action string, before, after Record, now time.Time) Revision {
+	rev := Revision{
+		ID:        primitive.NewObjectID(),
+		UserId:    before.UserId,
+		RecordId:  before.ID,
+		Action:    action,
+		Actor:     ActorOf(ctx),
+		Changes:   []FieldChange{},
+		Record:    before,
+		CreatedAt: now,
+	}
+	if action == RevisionDeleted {
+		return rev
+	}
+	rev.UserId, rev.RecordId, rev.Record = after.UserId, after.ID, after
+	for _, field := range RecordFields {
+		oldValue, newValue := RecordValue(before, field), RecordValue(after, field)
+		if oldValue != newValue {
+			rev.Changes = append(rev.Changes, FieldChange{Field: field, OldValue: oldValue, NewValue: newValue})
+		}
+	}
+	return rev
+}
+
+// RecordValue formats a field of RecordFields, empty when unset.
+func RecordValue(r Record, field string) string {
+	switch field {
+	case "type":
+		return r.Type
+	case "date":
+		if r.Date.IsZero() {
+			return ""
+		}
+		return r.Date.UTC().Format(time.RFC3339Nano)
+	case "title":
+		return r.Title
+	case "description":
+		return r.Description
+	case "amount":
+		if r.Amount.Currency == "" {
+			return ""
+		}
+		return r.Amount.String()
+	case "category_id":
+		return hexOrEmpty(r.CategoryId)
+	case "account_id":
+		return hexOrEmpty(r.AccountId)
+	case "tags":
+		return strings.Join(r.Tags, ",")
+	}
+	return ""
+}
+
+// RevertFields lists the fields of RecordFields an update writes to bring
+// r back to how the revision left it.
+func RevertFields(r Record, rev Revision) []string {
+	fields := []string{}
+	for _, field := range RecordFields {
+		if RecordValue(r, field) != RecordValue(rev.Record, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
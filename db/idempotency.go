@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyKey is a call a user made with an idempotency key, kept to
+// answer a retry of the call with its response.
+type IdempotencyKey struct {
+	UserId primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Key    string             `bson:"key" json:"key"`
+	// RequestHash identifies the method and payload of the call
+	RequestHash string `bson:"request_hash" json:"request_hash"`
+	// Response is the encoded response, nil until the call succeeded
+	Response  []byte    `bson:"response,omitempty" json:"response,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	// ExpiresAt ends the window in which the key answers retries
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	// LockedUntil is when a call that never completed gives up the key
+	LockedUntil time.Time `bson:"locked_until" json:"locked_until"`
+}
+
+// Free reports whether the key can be reserved again at now: it expired,
+// or its call never completed.
+func (k IdempotencyKey) Free(now time.Time) bool {
+	return !now.Before(k.ExpiresAt) || (k.Response == nil && !now.Before(k.LockedUntil))
+}
+
+// IdempotencyStore keeps the idempotency keys of the users.
+type IdempotencyStore interface {
+	// ReserveKey stores k, without a response, unless the user already
+	// has the key and it is not Free: the stored key is then returned
+	// with ErrAlreadyExists.
+	ReserveKey(ctx context.Context, k IdempotencyKey) (IdempotencyKey, error)
+	// CompleteKey stores the response and request hash of k on the user's
+	// key, provided it is still the reservation k made, which CreatedAt
+	// tells apart, or returns ErrNotFound.
+	CompleteKey(ctx context.Context, k IdempotencyKey) error
+	// RenewKey moves the lock of the reservation k to k.LockedUntil while
+	// its call runs, or returns ErrNotFound when the key was completed or
+	// reserved again since.
+	RenewKey(ctx context.Context, k IdempotencyKey) error
+	// ReleaseKey removes the reservation k so the call can be made again,
+	// leaving alone a later reservation of the key.
+	ReleaseKey(ctx context.Context, k IdempotencyKey) error
+	// PurgeKeys removes the keys expired at now and returns how many.
+	PurgeKeys(ctx context.Context, now time.Time) (int64, error)
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// maxOccurrencesPerPass bounds the records a schedule creates in a single
+// pass, a schedule far behind catches up over the following passes.
+const maxOccurrencesPerPass = 500
+
+// Materialize creates the records of every occurrence due at now and
+// returns how many were created. It is idempotent: the occurrences
+// recorded by a pass that failed halfway are not recorded twice.
+func Materialize(ctx context.Context, store Store, now time.Time) (int, error) {
+	due, err := store.DueSchedules(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	errs := []error{}
+	for _, s := range due {
+		n, err := MaterializeSchedule(ctx, store, s, now)
+		created += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", s.ID.Hex(), err))
+		}
+	}
+	return created, errors.Join(errs...)
+}
+
+// MaterializeSchedule creates the records of the occurrences of s due at
+// now. Occurrences whose record became invalid, for instance because its
+// account changed currency, are skipped.
+func MaterializeSchedule(ctx context.Context, store Store, s Schedule, now time.Time) (int, error) {
+	created := 0
+	n := s.Materialized
+	for ; n < s.Materialized+maxOccurrencesPerPass; n++ {
+		date, ok := s.Occurrence(n)
+		if !ok || date.After(now) {
+			break
+		}
+		r, ok := s.Record(n, date)
+		if !ok {
+			continue
+		}
+		var validation *ValidationError
+		err := store.Create(ctx, &r)
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrAlreadyExists):
+		case errors.As(err, &validation):
+			log.Printf("skipping occurrence %s of schedule %s: %v\n", date.Format(time.DateOnly), s.ID.Hex(), err)
+		default:
+			return created, advance(ctx, store, s, n, err)
+		}
+	}
+	return created, advance(ctx, store, s, n, nil)
+}
+
+// advance stores the progress of s. Losing the race against an update
+// of the schedule is fine, the next pass starts from the stored progress
+// and finds the records already created.
+func advance(ctx context.Context, store Store, s Schedule, materialized int, err error) error {
+	if materialized == s.Materialized {
+		return err
+	}
+	advanceErr := store.AdvanceSchedule(ctx, s, materialized)
+	if errors.Is(advanceErr, ErrVersionMismatch) {
+		advanceErr = nil
+	}
+	return errors.Join(err, advanceErr)
+}
+
+// AdvanceTo returns s with its progress moved to materialized.
+func (s Schedule) AdvanceTo(materialized int) Schedule {
+	s.Materialized = materialized
+	s.NextDate, _ = s.Occurrence(materialized)
+	return s
+}
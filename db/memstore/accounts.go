@@ -0,0 +1,128 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) CreateAccount(ctx context.Context, a *db.Account) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	now := db.Now()
+	a.ID = primitive.NewObjectID()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts = append(s.accounts, *a)
+	return nil
+}
+
+func (s *Store) GetAccount(ctx context.Context, userId, id primitive.ObjectID) (db.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.account(userId, id)
+}
+
+func (s *Store) UpdateAccount(ctx context.Context, a *db.Account, fields []string) error {
+	fields = db.UpdateAccountFields(fields)
+	if err := a.ValidateFields(fields); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.accountIndex(a.UserId, a.ID)
+	if i < 0 {
+		return db.ErrAccountNotFound
+	}
+	updated := s.accounts[i]
+	updated.ApplyFields(*a, fields)
+	if updated.Currency != s.accounts[i].Currency && s.accountUsed(a.UserId, a.ID) {
+		return db.ErrAccountInUse
+	}
+	updated.UpdatedAt = db.Now()
+	s.accounts[i] = updated
+	*a = updated
+	return nil
+}
+
+func (s *Store) ListAccounts(ctx context.Context, userId primitive.ObjectID) ([]db.Account, error) {
+	accounts := []db.Account{}
+	s.mu.RLock()
+	for _, a := range s.accounts {
+		if a.UserId == userId {
+			accounts = append(accounts, a)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Name != accounts[j].Name {
+			return accounts[i].Name < accounts[j].Name
+		}
+		return accounts[i].ID.Hex() < accounts[j].ID.Hex()
+	})
+	return accounts, nil
+}
+
+func (s *Store) DeleteAccount(ctx context.Context, userId, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.accountIndex(userId, id)
+	if i < 0 {
+		return db.ErrAccountNotFound
+	}
+	if s.accountUsed(userId, id) {
+		return db.ErrAccountInUse
+	}
+	s.accounts = append(s.accounts[:i], s.accounts[i+1:]...)
+	return nil
+}
+
+func (s *Store) AccountTotals(ctx context.Context, userId primitive.ObjectID, before time.Time) (map[primitive.ObjectID]db.AccountTotals, error) {
+	totals := map[primitive.ObjectID]db.AccountTotals{}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.records {
+		if r.UserId != userId || r.AccountId.IsZero() || (!before.IsZero() && !r.Date.Before(before)) {
+			continue
+		}
+		t := totals[r.AccountId]
+		t.AddRecord(r.Type, r.IsTransfer(), r.Amount.Units)
+		totals[r.AccountId] = t
+	}
+	return totals, nil
+}
+
+// account finds the user's account, it must be called with s.mu held.
+func (s *Store) account(userId, id primitive.ObjectID) (db.Account, error) {
+	if i := s.accountIndex(userId, id); i >= 0 {
+		return s.accounts[i], nil
+	}
+	return db.Account{}, db.ErrAccountNotFound
+}
+
+func (s *Store) accountIndex(userId, id primitive.ObjectID) int {
+	for i := range s.accounts {
+		if s.accounts[i].ID == id && s.accounts[i].UserId == userId {
+			return i
+		}
+	}
+	return -1
+}
+
+// accountUsed reports whether records are booked on the account, it must
+// be called with s.mu held.
+func (s *Store) accountUsed(userId, id primitive.ObjectID) bool {
+	for _, r := range s.records {
+		if r.UserId == userId && r.AccountId == id {
+			return true
+		}
+	}
+	return false
+}
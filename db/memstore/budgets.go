@@ -0,0 +1,96 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) CreateBudget(ctx context.Context, b *db.Budget) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := b.Check(s.refs()); err != nil {
+		return err
+	}
+	now := db.Now()
+	b.ID = primitive.NewObjectID()
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	s.budgets = append(s.budgets, *b)
+	return nil
+}
+
+func (s *Store) GetBudget(ctx context.Context, userId, id primitive.ObjectID) (db.Budget, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := s.budgetIndex(userId, id)
+	if i < 0 {
+		return db.Budget{}, db.ErrBudgetNotFound
+	}
+	return s.budgets[i], nil
+}
+
+func (s *Store) UpdateBudget(ctx context.Context, b *db.Budget, fields []string) error {
+	fields = db.UpdateBudgetFields(fields)
+	if err := b.ValidateFields(fields); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.budgetIndex(b.UserId, b.ID)
+	if i < 0 {
+		return db.ErrBudgetNotFound
+	}
+	updated := s.budgets[i]
+	updated.ApplyFields(*b, fields)
+	if err := updated.Check(s.refs()); err != nil {
+		return err
+	}
+	updated.UpdatedAt = db.Now()
+	s.budgets[i] = updated
+	*b = updated
+	return nil
+}
+
+func (s *Store) ListBudgets(ctx context.Context, userId primitive.ObjectID) ([]db.Budget, error) {
+	budgets := []db.Budget{}
+	s.mu.RLock()
+	for _, b := range s.budgets {
+		if b.UserId == userId {
+			budgets = append(budgets, b)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(budgets, func(i, j int) bool {
+		if budgets[i].Name != budgets[j].Name {
+			return budgets[i].Name < budgets[j].Name
+		}
+		return budgets[i].ID.Hex() < budgets[j].ID.Hex()
+	})
+	return budgets, nil
+}
+
+func (s *Store) DeleteBudget(ctx context.Context, userId, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.budgetIndex(userId, id)
+	if i < 0 {
+		return db.ErrBudgetNotFound
+	}
+	s.budgets = append(s.budgets[:i], s.budgets[i+1:]...)
+	return nil
+}
+
+func (s *Store) budgetIndex(userId, id primitive.ObjectID) int {
+	for i, b := range s.budgets {
+		if b.UserId == userId && b.ID == id {
+			return i
+		}
+	}
+	return -1
+}
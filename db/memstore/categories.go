@@ -0,0 +1,152 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) CreateCategory(ctx context.Context, c *db.Category) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.ID = primitive.NilObjectID
+	if err := db.NewCategoryTree(s.userCategories(c.UserId)).CheckPlacement(c); err != nil {
+		return err
+	}
+	now := db.Now()
+	c.ID = primitive.NewObjectID()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	s.categories = append(s.categories, *c)
+	return nil
+}
+
+func (s *Store) GetCategory(ctx context.Context, userId, id primitive.ObjectID) (db.Category, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.category(userId, id)
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, c *db.Category, fields []string) error {
+	fields = db.UpdateCategoryFields(fields)
+	if err := c.ValidateFields(fields); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.categoryIndex(c.UserId, c.ID)
+	if i < 0 {
+		return db.ErrCategoryNotFound
+	}
+	stored := s.categories[i]
+	updated, err := db.MergeCategoryUpdate(stored, *c, fields, s.userCategories(c.UserId))
+	if err != nil {
+		return err
+	}
+	if updated.Type != "" && updated.Type != stored.Type {
+		for _, r := range s.records {
+			if r.UserId == c.UserId && r.CategoryId == c.ID && r.Type != updated.Type {
+				return fmt.Errorf("%w: category has records other than %s", db.ErrFailedPrecondition, updated.Type)
+			}
+		}
+	}
+	updated.UpdatedAt = db.Now()
+	s.categories[i] = updated
+	*c = updated
+	return nil
+}
+
+func (s *Store) ListCategories(ctx context.Context, userId primitive.ObjectID) ([]db.Category, error) {
+	s.mu.RLock()
+	categories := s.userCategories(userId)
+	s.mu.RUnlock()
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Name != categories[j].Name {
+			return categories[i].Name < categories[j].Name
+		}
+		return categories[i].ID.Hex() < categories[j].ID.Hex()
+	})
+	return categories, nil
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, userId, id, moveTo primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted, err := db.NewCategoryTree(s.userCategories(userId)).CheckMove(id, moveTo)
+	if err != nil {
+		return err
+	}
+	parent := moveTo
+	if parent.IsZero() {
+		parent = deleted.ParentId
+	}
+	now := db.Now()
+	for i := range s.records {
+		if r := &s.records[i]; r.UserId == userId && r.CategoryId == id {
+			before := *r
+			r.CategoryId = moveTo
+			r.UpdatedAt = now
+			r.Version++
+			s.revise(ctx, db.RevisionUpdated, before, *r, now)
+		}
+	}
+	budgets := s.budgets[:0]
+	for _, b := range s.budgets {
+		if b.UserId == userId && b.CategoryId == id {
+			if moveTo.IsZero() {
+				continue
+			}
+			b.CategoryId = moveTo
+			b.UpdatedAt = now
+		}
+		budgets = append(budgets, b)
+	}
+	s.budgets = budgets
+	kept := s.categories[:0]
+	for _, c := range s.categories {
+		if c.UserId == userId && c.ID == id {
+			continue
+		}
+		if c.UserId == userId && c.ParentId == id {
+			c.ParentId = parent
+			c.UpdatedAt = now
+		}
+		kept = append(kept, c)
+	}
+	s.categories = kept
+	return nil
+}
+
+// category finds the user's category, it must be called with s.mu held.
+func (s *Store) category(userId, id primitive.ObjectID) (db.Category, error) {
+	if i := s.categoryIndex(userId, id); i >= 0 {
+		return s.categories[i], nil
+	}
+	return db.Category{}, db.ErrCategoryNotFound
+}
+
+func (s *Store) categoryIndex(userId, id primitive.ObjectID) int {
+	for i := range s.categories {
+		if s.categories[i].ID == id && s.categories[i].UserId == userId {
+			return i
+		}
+	}
+	return -1
+}
+
+// userCategories copies the user's categories, it must be called with s.mu held.
+func (s *Store) userCategories(userId primitive.ObjectID) []db.Category {
+	categories := []db.Category{}
+	for _, c := range s.categories {
+		if c.UserId == userId {
+			categories = append(categories, c)
+		}
+	}
+	return categories
+}
@@ -0,0 +1,29 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) GetRecordHistory(ctx context.Context, userId, recordId primitive.ObjectID) ([]db.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	revisions := []db.Revision{}
+	for _, rev := range s.history {
+		if rev.UserId == userId && rev.RecordId == recordId {
+			rev.Record = clone(rev.Record)
+			revisions = append(revisions, rev)
+		}
+	}
+	return revisions, nil
+}
+
+// revise appends the revision of a write to the history. It must be
+// called with s.mu held.
+func (s *Store) revise(ctx context.Context, action string, before, after db.Record, now time.Time) {
+	rev := db.NewRevision(ctx, action, clone(before), clone(after), now)
+	s.history = append(s.history, rev)
+}
@@ -0,0 +1,76 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// idempotencyKey identifies the key of a user.
+type idempotencyKey struct {
+	userId primitive.ObjectID
+	key    string
+}
+
+func (s *Store) ReserveKey(ctx context.Context, k db.IdempotencyKey) (db.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := idempotencyKey{k.UserId, k.Key}
+	if stored, ok := s.keys[id]; ok && !stored.Free(k.CreatedAt) {
+		return stored, db.ErrAlreadyExists
+	}
+	s.keys[id] = k
+	return k, nil
+}
+
+func (s *Store) CompleteKey(ctx context.Context, k db.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := idempotencyKey{k.UserId, k.Key}
+	stored, ok := s.keys[id]
+	if !ok || !stored.CreatedAt.Equal(k.CreatedAt) {
+		return db.ErrNotFound
+	}
+	stored.RequestHash = k.RequestHash
+	stored.Response = append([]byte{}, k.Response...)
+	s.keys[id] = stored
+	return nil
+}
+
+func (s *Store) RenewKey(ctx context.Context, k db.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := idempotencyKey{k.UserId, k.Key}
+	stored, ok := s.keys[id]
+	if !ok || !stored.CreatedAt.Equal(k.CreatedAt) || stored.Response != nil {
+		return db.ErrNotFound
+	}
+	stored.LockedUntil = k.LockedUntil
+	s.keys[id] = stored
+	return nil
+}
+
+func (s *Store) ReleaseKey(ctx context.Context, k db.IdempotencyKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := idempotencyKey{k.UserId, k.Key}
+	if stored, ok := s.keys[id]; ok && stored.CreatedAt.Equal(k.CreatedAt) {
+		delete(s.keys, id)
+	}
+	return nil
+}
+
+func (s *Store) PurgeKeys(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := int64(0)
+	for id, k := range s.keys {
+		if !now.Before(k.ExpiresAt) {
+			delete(s.keys, id)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,305 @@
+// Package memstore is an in-memory implementation of the db store interfaces,
+// used by the tests and for local development without MongoDB.
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Store struct {
+	mu         sync.RWMutex
+	records    []db.Record
+	categories []db.Category
+	accounts   []db.Account
+	schedules  []db.Schedule
+	budgets    []db.Budget
+	keys       map[idempotencyKey]db.IdempotencyKey
+	// trash holds the deleted records
+	trash   []db.Record
+	history []db.Revision
+}
+
+func New() *Store {
+	return &Store{keys: map[idempotencyKey]db.IdempotencyKey{}}
+}
+
+func (s *Store) Create(ctx context.Context, r *db.Record) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	now := db.Now()
+	r.ID = primitive.NewObjectID()
+	r.CreatedAt = now
+	r.UpdatedAt = now
+	r.Version = 1
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refs().Check(*r); err != nil {
+		return err
+	}
+	if err := s.taken(*r, nil); err != nil {
+		return err
+	}
+	s.records = append(s.records, clone(*r))
+	s.revise(ctx, db.RevisionCreated, db.Record{}, *r, now)
+	return nil
+}
+
+func (s *Store) CreateMany(ctx context.Context, records []db.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := db.CheckBatch(records, s.refs()); err != nil {
+		return err
+	}
+	for i, r := range records {
+		if err := s.taken(r, records[:i]); err != nil {
+			return err
+		}
+	}
+	now := db.Now()
+	for i := range records {
+		r := &records[i]
+		r.ID = primitive.NewObjectID()
+		r.CreatedAt = now
+		r.UpdatedAt = now
+		r.Version = 1
+		s.records = append(s.records, clone(*r))
+		s.revise(ctx, db.RevisionCreated, db.Record{}, *r, now)
+	}
+	return nil
+}
+
+func (s *Store) ImportedIds(ctx context.Context, userId, accountId primitive.ObjectID, importIds []string) (map[string]bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	taken := map[string]bool{}
+	for _, id := range importIds {
+		if s.imported(userId, accountId, id, nil) {
+			taken[id] = true
+		}
+	}
+	return taken, nil
+}
+
+// taken returns ErrAlreadyExists when a unique key of r is used by a
+// stored record or one of the pending ones, it must be called with s.mu held.
+func (s *Store) taken(r db.Record, pending []db.Record) error {
+	if !r.ScheduleId.IsZero() && s.recorded(r.ScheduleId, r.Occurrence) {
+		return fmt.Errorf("%w: occurrence %s of schedule %s", db.ErrAlreadyExists, r.Occurrence, r.ScheduleId.Hex())
+	}
+	if r.ImportId != "" && s.imported(r.UserId, r.AccountId, r.ImportId, pending) {
+		return fmt.Errorf("%w: record imported as '%s'", db.ErrAlreadyExists, r.ImportId)
+	}
+	return nil
+}
+
+// imported reports whether a record of the account has the import id, it
+// must be called with s.mu held.
+func (s *Store) imported(userId, accountId primitive.ObjectID, importId string, pending []db.Record) bool {
+	for _, records := range [][]db.Record{s.records, pending} {
+		for _, r := range records {
+			if r.ImportId == importId && r.UserId == userId && r.AccountId == accountId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Store) Get(ctx context.Context, userId, id primitive.ObjectID) (db.Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.indexOf(userId, id); i >= 0 {
+		return s.records[i], nil
+	}
+	return db.Record{}, db.ErrNotFound
+}
+
+func (s *Store) Update(ctx context.Context, r *db.Record, fields []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.update(ctx, r, fields, nil)
+}
+
+func (s *Store) UpdateMany(ctx context.Context, updates []db.RecordUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batch(len(updates), func(i int, targets db.BatchTargets) error {
+		return s.update(ctx, &updates[i].Record, updates[i].Fields, targets)
+	})
+}
+
+// update is Update, it must be called with s.mu held. The written
+// records are added to the targets of a batch unless they are nil.
+func (s *Store) update(ctx context.Context, r *db.Record, fields []string, targets db.BatchTargets) error {
+	fields = db.UpdateFields(fields)
+	if err := r.ValidateFields(fields); err != nil {
+		return err
+	}
+	i := s.indexOf(r.UserId, r.ID)
+	if i < 0 {
+		return db.ErrNotFound
+	}
+	if r.Version != 0 && r.Version != s.records[i].Version {
+		return db.ErrVersionMismatch
+	}
+	updated := s.records[i]
+	updated.ApplyFields(*r, fields)
+	if err := s.refs().Check(updated); err != nil {
+		return err
+	}
+	now := db.Now()
+	j := s.otherSide(updated)
+	if targets != nil {
+		ids := []primitive.ObjectID{updated.ID}
+		if j >= 0 {
+			ids = append(ids, s.records[j].ID)
+		}
+		if err := targets.Add(ids...); err != nil {
+			return err
+		}
+	}
+	if j >= 0 {
+		shared, err := db.CheckTransferSide(s.records[i], updated, s.records[j], fields, s.refs())
+		if err != nil {
+			return err
+		}
+		other := s.records[j]
+		other.ApplyFields(updated, shared)
+		other.UpdatedAt = now
+		other.Version++
+		s.revise(ctx, db.RevisionUpdated, s.records[j], other, now)
+		s.records[j] = clone(other)
+	}
+	updated.UpdatedAt = now
+	updated.Version++
+	s.revise(ctx, db.RevisionUpdated, s.records[i], updated, now)
+	s.records[i] = clone(updated)
+	*r = updated
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, userId, id primitive.ObjectID, version int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(ctx, userId, db.RecordDelete{ID: id, Version: version}, nil)
+}
+
+func (s *Store) DeleteMany(ctx context.Context, userId primitive.ObjectID, deletes []db.RecordDelete) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batch(len(deletes), func(i int, targets db.BatchTargets) error {
+		return s.delete(ctx, userId, deletes[i], targets)
+	})
+}
+
+// delete is Delete, it must be called with s.mu held. The deleted
+// records are added to the targets of a batch unless they are nil.
+func (s *Store) delete(ctx context.Context, userId primitive.ObjectID, d db.RecordDelete, targets db.BatchTargets) error {
+	i := s.indexOf(userId, d.ID)
+	if i < 0 {
+		return db.ErrNotFound
+	}
+	if d.Version != 0 && d.Version != s.records[i].Version {
+		return db.ErrVersionMismatch
+	}
+	r := s.records[i]
+	j := s.otherSide(r)
+	if targets != nil {
+		ids := []primitive.ObjectID{r.ID}
+		if j >= 0 {
+			ids = append(ids, s.records[j].ID)
+		}
+		if err := targets.Add(ids...); err != nil {
+			return err
+		}
+	}
+	now := db.Now()
+	kept := []db.Record{}
+	for _, stored := range s.records {
+		if stored.ID == r.ID || (r.IsTransfer() && stored.UserId == r.UserId && stored.TransferId == r.TransferId) {
+			stored.DeletedAt = now
+			s.trash = append(s.trash, stored)
+			s.revise(ctx, db.RevisionDeleted, stored, db.Record{}, now)
+			continue
+		}
+		kept = append(kept, stored)
+	}
+	s.records = kept
+	return nil
+}
+
+// batch runs the n items of a batch in order, restoring the records as
+// they were when one of them fails. It must be called with s.mu held.
+func (s *Store) batch(n int, item func(i int, targets db.BatchTargets) error) error {
+	saved := append([]db.Record{}, s.records...)
+	savedTrash := append([]db.Record{}, s.trash...)
+	revisions := len(s.history)
+	targets := db.BatchTargets{}
+	for i := 0; i < n; i++ {
+		if err := item(i, targets); err != nil {
+			s.records, s.trash, s.history = saved, savedTrash, s.history[:revisions]
+			return db.BatchItemError(i, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) GetUserRecords(ctx context.Context, q db.RecordQuery) (db.RecordPage, error) {
+	if err := q.Validate(); err != nil {
+		return db.RecordPage{}, err
+	}
+
+	s.mu.RLock()
+	matching := []db.Record{}
+	total := int64(0)
+	for _, r := range s.records {
+		if r.UserId != q.UserId || !q.Filter.Match(r) {
+			continue
+		}
+		total++
+		if q.After == nil || q.Sort.After(r, *q.After) {
+			matching = append(matching, r)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matching, func(i, j int) bool { return q.Sort.Compare(matching[i], matching[j]) < 0 })
+	if len(matching) > q.PageSize+1 {
+		matching = matching[:q.PageSize+1]
+	}
+	page := db.NewRecordPage(q, matching)
+	if q.CountTotal {
+		page.Total = total
+	}
+	return page, nil
+}
+
+// refs looks up the references of records, it must be called with s.mu held.
+func (s *Store) refs() db.RecordRefs {
+	return db.RecordRefs{Category: s.category, Account: s.account}
+}
+
+// clone copies the slices of r so records kept by the store never share
+// them with the caller; the store replaces slices instead of changing them.
+func clone(r db.Record) db.Record {
+	r.Tags = append([]string{}, r.Tags...)
+	return r
+}
+
+// indexOf finds the user's record, it must be called with s.mu held.
+func (s *Store) indexOf(userId, id primitive.ObjectID) int {
+	for i := range s.records {
+		if s.records[i].ID == id && s.records[i].UserId == userId {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,142 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) CreateSchedule(ctx context.Context, sc *db.Schedule) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := sc.Check(s.refs()); err != nil {
+		return err
+	}
+	now := db.Now()
+	sc.ID = primitive.NewObjectID()
+	sc.Begin()
+	sc.Version = 1
+	sc.CreatedAt = now
+	sc.UpdatedAt = now
+	s.schedules = append(s.schedules, cloneSchedule(*sc))
+	return nil
+}
+
+func (s *Store) GetSchedule(ctx context.Context, userId, id primitive.ObjectID) (db.Schedule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.scheduleIndex(userId, id); i >= 0 {
+		return s.schedules[i], nil
+	}
+	return db.Schedule{}, db.ErrScheduleNotFound
+}
+
+func (s *Store) UpdateSchedule(ctx context.Context, sc *db.Schedule, fields []string) error {
+	fields = db.UpdateScheduleFields(fields)
+	if err := sc.ValidateFields(fields); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.scheduleIndex(sc.UserId, sc.ID)
+	if i < 0 {
+		return db.ErrScheduleNotFound
+	}
+	updated, err := db.MergeScheduleUpdate(s.schedules[i], *sc, fields, s.refs())
+	if err != nil {
+		return err
+	}
+	s.schedules[i] = cloneSchedule(updated)
+	*sc = updated
+	return nil
+}
+
+func (s *Store) ListSchedules(ctx context.Context, userId primitive.ObjectID) ([]db.Schedule, error) {
+	schedules := []db.Schedule{}
+	s.mu.RLock()
+	for _, sc := range s.schedules {
+		if sc.UserId == userId {
+			schedules = append(schedules, sc)
+		}
+	}
+	s.mu.RUnlock()
+	sort.Slice(schedules, func(i, j int) bool {
+		if schedules[i].Title != schedules[j].Title {
+			return schedules[i].Title < schedules[j].Title
+		}
+		return schedules[i].ID.Hex() < schedules[j].ID.Hex()
+	})
+	return schedules, nil
+}
+
+func (s *Store) DeleteSchedule(ctx context.Context, userId, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.scheduleIndex(userId, id)
+	if i < 0 {
+		return db.ErrScheduleNotFound
+	}
+	s.schedules = append(s.schedules[:i], s.schedules[i+1:]...)
+	return nil
+}
+
+func (s *Store) DueSchedules(ctx context.Context, now time.Time) ([]db.Schedule, error) {
+	due := []db.Schedule{}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, sc := range s.schedules {
+		if !sc.NextDate.IsZero() && !sc.NextDate.After(now) {
+			due = append(due, sc)
+		}
+	}
+	return due, nil
+}
+
+func (s *Store) AdvanceSchedule(ctx context.Context, sc db.Schedule, materialized int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.scheduleIndex(sc.UserId, sc.ID)
+	if i < 0 {
+		return db.ErrScheduleNotFound
+	}
+	if s.schedules[i].Version != sc.Version {
+		return db.ErrVersionMismatch
+	}
+	advanced := s.schedules[i].AdvanceTo(materialized)
+	advanced.Version++
+	s.schedules[i] = advanced
+	return nil
+}
+
+func (s *Store) scheduleIndex(userId, id primitive.ObjectID) int {
+	for i := range s.schedules {
+		if s.schedules[i].ID == id && s.schedules[i].UserId == userId {
+			return i
+		}
+	}
+	return -1
+}
+
+// recorded reports whether a record of the occurrence exists, it must be
+// called with s.mu held.
+func (s *Store) recorded(scheduleId primitive.ObjectID, occurrence time.Time) bool {
+	for _, r := range s.records {
+		if r.ScheduleId == scheduleId && r.Occurrence.Equal(occurrence) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneSchedule is clone for schedules.
+func cloneSchedule(sc db.Schedule) db.Schedule {
+	sc.Tags = append([]string{}, sc.Tags...)
+	sc.Exceptions = append([]db.OccurrenceException{}, sc.Exceptions...)
+	return sc
+}
@@ -0,0 +1,22 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+func (s *Store) Summarize(ctx context.Context, q db.SummaryQuery) ([]db.SummaryRow, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	summary := db.NewSummarizer(q)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.records {
+		if r.UserId == q.UserId && q.Filter.Match(r) {
+			summary.AddRecord(r)
+		}
+	}
+	return summary.Rows(), nil
+}
@@ -0,0 +1,73 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) ListTags(ctx context.Context, userId primitive.ObjectID) ([]db.TagCount, error) {
+	counts := map[string]int64{}
+	s.mu.RLock()
+	for _, r := range s.records {
+		if r.UserId == userId {
+			for _, tag := range r.Tags {
+				counts[tag]++
+			}
+		}
+	}
+	s.mu.RUnlock()
+
+	tags := []db.TagCount{}
+	for tag, n := range counts {
+		tags = append(tags, db.TagCount{Tag: tag, Records: n})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Records != tags[j].Records {
+			return tags[i].Records > tags[j].Records
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags, nil
+}
+
+func (s *Store) RenameTag(ctx context.Context, userId primitive.ObjectID, from, to string) (int64, error) {
+	from, to, err := db.CheckRename(from, to)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	changed := int64(0)
+	now := db.Now()
+	for i := range s.records {
+		r := &s.records[i]
+		if r.UserId != userId || from == to || !contains(r.Tags, from) {
+			continue
+		}
+		before := *r
+		r.Tags = db.RenameTagIn(r.Tags, from, to)
+		r.UpdatedAt = now
+		r.Version++
+		s.revise(ctx, db.RevisionUpdated, before, *r, now)
+		changed++
+	}
+	for i := range s.budgets {
+		if b := &s.budgets[i]; b.UserId == userId && b.Tag == from {
+			b.Tag = to
+			b.UpdatedAt = now
+		}
+	}
+	return changed, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) CreateTransfer(ctx context.Context, t *db.Transfer) (db.Record, db.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := t.Validate(s.refs()); err != nil {
+		return db.Record{}, db.Record{}, err
+	}
+	t.ID = primitive.NewObjectID()
+	debit, credit := t.Records()
+	now := db.Now()
+	for _, r := range []*db.Record{&debit, &credit} {
+		r.ID = primitive.NewObjectID()
+		r.CreatedAt = now
+		r.UpdatedAt = now
+		r.Version = 1
+		s.records = append(s.records, clone(*r))
+		s.revise(ctx, db.RevisionCreated, db.Record{}, *r, now)
+	}
+	return debit, credit, nil
+}
+
+// otherSide finds the other record of the transfer r belongs to, -1 when
+// r is no transfer. It must be called with s.mu held.
+func (s *Store) otherSide(r db.Record) int {
+	if !r.IsTransfer() {
+		return -1
+	}
+	for i := range s.records {
+		if s.records[i].TransferId == r.TransferId && s.records[i].UserId == r.UserId && s.records[i].ID != r.ID {
+			return i
+		}
+	}
+	return -1
+}
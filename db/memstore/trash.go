@@ -0,0 +1,121 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) ListTrash(ctx context.Context, q db.RecordQuery) (db.RecordPage, error) {
+	q.Filter, q.Sort = db.RecordFilter{}, db.TrashSort
+	if err := q.Validate(); err != nil {
+		return db.RecordPage{}, err
+	}
+
+	s.mu.RLock()
+	matching := []db.Record{}
+	total := int64(0)
+	for _, r := range s.trash {
+		if r.UserId != q.UserId {
+			continue
+		}
+		total++
+		if q.After == nil || q.Sort.After(r, *q.After) {
+			matching = append(matching, clone(r))
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(matching, func(i, j int) bool { return q.Sort.Compare(matching[i], matching[j]) < 0 })
+	if len(matching) > q.PageSize+1 {
+		matching = matching[:q.PageSize+1]
+	}
+	page := db.NewRecordPage(q, matching)
+	if q.CountTotal {
+		page.Total = total
+	}
+	return page, nil
+}
+
+func (s *Store) RestoreRecord(ctx context.Context, userId, id primitive.ObjectID) (db.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sides, kept := s.trashed(userId, id)
+	if len(sides) == 0 {
+		return db.Record{}, db.ErrNotFound
+	}
+	now := db.Now()
+	restored := []db.Record{}
+	for _, side := range sides {
+		r, err := db.CheckRestore(side, s.refs(), now)
+		if err != nil {
+			return db.Record{}, err
+		}
+		if err := s.taken(r, nil); err != nil {
+			return db.Record{}, err
+		}
+		restored = append(restored, r)
+	}
+	for i, r := range restored {
+		s.revise(ctx, db.RevisionRestored, sides[i], r, now)
+	}
+	s.records = append(s.records, restored...)
+	s.trash = kept
+	return clone(restored[0]), nil
+}
+
+func (s *Store) PurgeRecord(ctx context.Context, userId, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sides, kept := s.trashed(userId, id)
+	if len(sides) == 0 {
+		return db.ErrNotFound
+	}
+	s.trash = kept
+	return nil
+}
+
+func (s *Store) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := []db.Record{}
+	for _, r := range s.trash {
+		if r.DeletedAt.Before(before) {
+			continue
+		}
+		kept = append(kept, r)
+	}
+	n := int64(len(s.trash) - len(kept))
+	s.trash = kept
+	return n, nil
+}
+
+// trashed splits the trash into the user's deleted record, first and
+// along with the other side of its transfer, and the other records. It
+// must be called with s.mu held.
+func (s *Store) trashed(userId, id primitive.ObjectID) (sides, kept []db.Record) {
+	i := -1
+	for j, r := range s.trash {
+		if r.ID == id && r.UserId == userId {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil, s.trash
+	}
+	r := s.trash[i]
+	sides = []db.Record{r}
+	for j, other := range s.trash {
+		switch {
+		case j == i:
+		case r.IsTransfer() && other.UserId == userId && other.TransferId == r.TransferId:
+			sides = append(sides, other)
+		default:
+			kept = append(kept, other)
+		}
+	}
+	return sides, kept
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount in the minor units of an ISO-4217 currency,
+// e.g. {Units: 1234, Currency: "USD"} is 12.34 USD.
+type Money struct {
+	Units    int64  `bson:"units" json:"units"`
+	Currency string `bson:"currency" json:"currency"`
+}
+
+// DefaultCurrency is assigned to amounts stored before currencies existed.
+var DefaultCurrency = "USD"
+
+// currencyExponents maps ISO-4217 codes to their number of minor unit digits.
+var currencyExponents = map[string]int{}
+
+func init() {
+	for exp, codes := range map[int]string{
+		0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+		2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BRL BSD BTN BWP BYN BZD " +
+			"CAD CDF CHF CNY COP CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD " +
+			"GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL MAD " +
+			"MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK PHP " +
+			"PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB " +
+			"TJS TMT TOP TRY TTD TWD TZS UAH USD UYU UZS VED VES WST XCD YER ZAR ZMW ZWL",
+		3: "BHD IQD JOD KWD LYD OMR TND",
+		4: "CLF UYW",
+	} {
+		for _, code := range strings.Fields(codes) {
+			currencyExponents[code] = exp
+		}
+	}
+}
+
+// CurrencyExponent returns the number of decimal digits of the currency's minor unit.
+func CurrencyExponent(currency string) (int, bool) {
+	exp, ok := currencyExponents[currency]
+	return exp, ok
+}
+
+func (m Money) Validate() error {
+	if _, ok := currencyExponents[m.Currency]; !ok {
+		return fmt.Errorf("'%s' is not a supported ISO-4217 currency code", m.Currency)
+	}
+	return nil
+}
+
+// String formats the amount with the currency's precision, e.g. "-12.34 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal formats the amount with the currency's precision and without
+// the currency, e.g. "-12.34", as ParseMoney reads it.
+func (m Money) Decimal() string {
+	exp := currencyExponents[m.Currency]
+	units := m.Units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+	}
+	digits := strconv.FormatUint(absUnits(units), 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// ParseMoney parses a decimal amount such as "-1234.5" in the given currency.
+// Amounts with more decimals than the currency allows are rejected rather than rounded.
+func ParseMoney(amount string, currency string) (Money, error) {
+	m := Money{Currency: currency}
+	exp, ok := currencyExponents[currency]
+	if !ok {
+		return m, m.Validate()
+	}
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return m, fmt.Errorf("'%s' is not a valid amount", amount)
+	}
+	if len(frac) > exp {
+		return m, fmt.Errorf("'%s' has more than %d decimals allowed for %s", amount, exp, currency)
+	}
+	digits := whole + frac + strings.Repeat("0", exp-len(frac))
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return m, fmt.Errorf("'%s' is not a valid amount", amount)
+		}
+	}
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return m, fmt.Errorf("'%s' is out of range", amount)
+	}
+	if negative {
+		units = -units
+	}
+	m.Units = units
+	return m, nil
+}
+
+func absUnits(u int64) uint64 {
+	if u == math.MinInt64 {
+		return uint64(math.MaxInt64) + 1
+	}
+	if u < 0 {
+		return uint64(-u)
+	}
+	return uint64(u)
+}
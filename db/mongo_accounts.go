@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *MongoStore) CreateAccount(ctx context.Context, a *Account) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	now := Now()
+	a.ID = primitive.NewObjectID()
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	if _, err := s.accounts.InsertOne(ctx, a); err != nil {
+		return mongoError(err)
+	}
+	return nil
+}
+
+func (s *MongoStore) GetAccount(ctx context.Context, userId, id primitive.ObjectID) (Account, error) {
+	a := Account{}
+	err := s.accounts.FindOne(ctx, bson.M{"_id": id, "user_id": userId}).Decode(&a)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return a, ErrAccountNotFound
+	}
+	return a, mongoError(err)
+}
+
+func (s *MongoStore) UpdateAccount(ctx context.Context, a *Account, fields []string) error {
+	fields = UpdateAccountFields(fields)
+	if err := a.ValidateFields(fields); err != nil {
+		return err
+	}
+	stored, err := s.GetAccount(ctx, a.UserId, a.ID)
+	if err != nil {
+		return err
+	}
+	updated := stored
+	updated.ApplyFields(*a, fields)
+	if updated.Currency != stored.Currency {
+		if err := s.checkAccountUnused(ctx, a.UserId, a.ID); err != nil {
+			return err
+		}
+	}
+	updated.UpdatedAt = Now()
+	result, err := s.accounts.ReplaceOne(ctx, bson.M{"_id": a.ID, "user_id": a.UserId}, updated)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrAccountNotFound
+	}
+	*a = updated
+	return nil
+}
+
+func (s *MongoStore) ListAccounts(ctx context.Context, userId primitive.ObjectID) ([]Account, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.accounts.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	accounts := []Account{}
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, mongoError(err)
+	}
+	return accounts, nil
+}
+
+func (s *MongoStore) DeleteAccount(ctx context.Context, userId, id primitive.ObjectID) error {
+	if err := s.checkAccountUnused(ctx, userId, id); err != nil {
+		return err
+	}
+	result, err := s.accounts.DeleteOne(ctx, bson.M{"_id": id, "user_id": userId})
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) AccountTotals(ctx context.Context, userId primitive.ObjectID, before time.Time) (map[primitive.ObjectID]AccountTotals, error) {
+	match := bson.M{"user_id": userId, "account_id": bson.M{"$exists": true}}
+	if !before.IsZero() {
+		match["date"] = bson.M{"$lt": before}
+	}
+	cursor, err := s.records.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"account_id": "$account_id",
+				"type":       "$type",
+				"transfer":   bson.M{"$ne": bson.A{bson.M{"$type": "$transfer_id"}, "missing"}},
+			},
+			"total": bson.M{"$sum": "$amount.units"},
+		}}},
+	})
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	var groups []struct {
+		ID struct {
+			AccountId primitive.ObjectID `bson:"account_id"`
+			Type      string             `bson:"type"`
+			Transfer  bool               `bson:"transfer"`
+		} `bson:"_id"`
+		Total int64 `bson:"total"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, mongoError(err)
+	}
+	totals := map[primitive.ObjectID]AccountTotals{}
+	for _, g := range groups {
+		t := totals[g.ID.AccountId]
+		t.AddRecord(g.ID.Type, g.ID.Transfer, g.Total)
+		totals[g.ID.AccountId] = t
+	}
+	return totals, nil
+}
+
+func (s *MongoStore) checkAccountUnused(ctx context.Context, userId, id primitive.ObjectID) error {
+	n, err := s.records.CountDocuments(ctx, bson.M{"user_id": userId, "account_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return mongoError(err)
+	}
+	if n > 0 {
+		return ErrAccountInUse
+	}
+	return nil
+}
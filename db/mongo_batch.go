@@ -0,0 +1,171 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateMany checks every update against the stored records first, then
+// writes them with a single BulkWrite in a transaction. Each write is
+// conditioned on the version read, so a record changed in between fails
+// the batch with ErrVersionMismatch.
+func (s *MongoStore) UpdateMany(ctx context.Context, updates []RecordUpdate) error {
+	now := Now()
+	targets := BatchTargets{}
+	models := []mongo.WriteModel{}
+	before := []Record{}
+	for i := range updates {
+		writes, read, err := s.updateWrites(ctx, updates[i], targets, now)
+		if err != nil {
+			return BatchItemError(i, err)
+		}
+		models = append(models, writes...)
+		before = append(before, read...)
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	stored := map[primitive.ObjectID]Record{}
+	err := s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		result, err := s.records.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+		if err != nil {
+			return mongoError(err)
+		}
+		if result.MatchedCount < int64(len(models)) {
+			return ErrVersionMismatch
+		}
+		ids := []primitive.ObjectID{}
+		for _, r := range before {
+			ids = append(ids, r.ID)
+		}
+		cursor, err := s.records.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return mongoError(err)
+		}
+		defer cursor.Close(ctx)
+		for cursor.Next(ctx) {
+			var r Record
+			if err := cursor.Decode(&r); err != nil {
+				return err
+			}
+			stored[r.ID] = r
+		}
+		if err := cursor.Err(); err != nil {
+			return mongoError(err)
+		}
+		revisions := []Revision{}
+		for _, r := range before {
+			revisions = append(revisions, NewRevision(ctx, RevisionUpdated, r, stored[r.ID], now))
+		}
+		return s.insertRevisions(ctx, revisions...)
+	})
+	if err != nil {
+		return err
+	}
+	for i := range updates {
+		updates[i].Record = stored[updates[i].Record.ID]
+	}
+	return nil
+}
+
+// updateWrites checks an update of UpdateMany as Update does and returns
+// its writes, along with the one of the other side of a transfer, and the
+// records they write as read.
+func (s *MongoStore) updateWrites(ctx context.Context, u RecordUpdate, targets BatchTargets, now time.Time) ([]mongo.WriteModel, []Record, error) {
+	r := u.Record
+	fields := UpdateFields(u.Fields)
+	if err := r.ValidateFields(fields); err != nil {
+		return nil, nil, err
+	}
+	stored, err := s.Get(ctx, r.UserId, r.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if r.Version != 0 && r.Version != stored.Version {
+		return nil, nil, ErrVersionMismatch
+	}
+	updated := stored
+	updated.ApplyFields(r, fields)
+	if RefsChanged(fields) {
+		if err := s.recordRefs(ctx).Check(updated); err != nil {
+			return nil, nil, err
+		}
+	}
+	models := []mongo.WriteModel{
+		mongo.NewUpdateOneModel().
+			SetFilter(mongoRecordVersion(r.UserId, r.ID, stored.Version)).
+			SetUpdate(mongoRecordUpdate(r, fields, now)),
+	}
+	if !stored.IsTransfer() {
+		return models, []Record{stored}, targets.Add(stored.ID)
+	}
+	other, err := s.otherSide(ctx, stored)
+	if err != nil {
+		return nil, nil, err
+	}
+	shared, err := CheckTransferSide(stored, updated, other, fields, s.recordRefs(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := targets.Add(stored.ID, other.ID); err != nil {
+		return nil, nil, err
+	}
+	if len(shared) == 0 {
+		return models, []Record{stored}, nil
+	}
+	models = append(models, mongo.NewUpdateOneModel().
+		SetFilter(mongoRecordVersion(other.UserId, other.ID, other.Version)).
+		SetUpdate(mongoRecordUpdate(updated, shared, now)))
+	return models, []Record{stored, other}, nil
+}
+
+// DeleteMany checks every record exists at the requested version first,
+// then moves them, along with the other sides of their transfers, to the
+// trash in a single transaction.
+func (s *MongoStore) DeleteMany(ctx context.Context, userId primitive.ObjectID, deletes []RecordDelete) error {
+	targets := BatchTargets{}
+	all := []Record{}
+	for i, d := range deletes {
+		sides, err := s.deleteSides(ctx, userId, d)
+		if err != nil {
+			return BatchItemError(i, err)
+		}
+		ids := []primitive.ObjectID{}
+		for _, r := range sides {
+			ids = append(ids, r.ID)
+		}
+		if err := targets.Add(ids...); err != nil {
+			return BatchItemError(i, err)
+		}
+		all = append(all, sides...)
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return s.moveToTrash(ctx, all)
+}
+
+// deleteSides reads the records Delete or an item of DeleteMany removes,
+// the other side of a transfer included.
+func (s *MongoStore) deleteSides(ctx context.Context, userId primitive.ObjectID, d RecordDelete) ([]Record, error) {
+	stored, err := s.Get(ctx, userId, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	if d.Version != 0 && d.Version != stored.Version {
+		return nil, ErrVersionMismatch
+	}
+	if !stored.IsTransfer() {
+		return []Record{stored}, nil
+	}
+	other, err := s.otherSide(ctx, stored)
+	if err != nil {
+		return nil, err
+	}
+	return []Record{stored, other}, nil
+}
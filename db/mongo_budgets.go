@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *MongoStore) CreateBudget(ctx context.Context, b *Budget) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if err := b.Check(s.recordRefs(ctx)); err != nil {
+		return err
+	}
+	now := Now()
+	b.ID = primitive.NewObjectID()
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	if _, err := s.budgets.InsertOne(ctx, b); err != nil {
+		return mongoError(err)
+	}
+	return nil
+}
+
+func (s *MongoStore) GetBudget(ctx context.Context, userId, id primitive.ObjectID) (Budget, error) {
+	b := Budget{}
+	err := s.budgets.FindOne(ctx, bson.M{"_id": id, "user_id": userId}).Decode(&b)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return b, ErrBudgetNotFound
+	}
+	return b, mongoError(err)
+}
+
+func (s *MongoStore) UpdateBudget(ctx context.Context, b *Budget, fields []string) error {
+	fields = UpdateBudgetFields(fields)
+	if err := b.ValidateFields(fields); err != nil {
+		return err
+	}
+	stored, err := s.GetBudget(ctx, b.UserId, b.ID)
+	if err != nil {
+		return err
+	}
+	updated := stored
+	updated.ApplyFields(*b, fields)
+	if err := updated.Check(s.recordRefs(ctx)); err != nil {
+		return err
+	}
+	updated.UpdatedAt = Now()
+	result, err := s.budgets.ReplaceOne(ctx, bson.M{"_id": b.ID, "user_id": b.UserId}, updated)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrBudgetNotFound
+	}
+	*b = updated
+	return nil
+}
+
+func (s *MongoStore) ListBudgets(ctx context.Context, userId primitive.ObjectID) ([]Budget, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.budgets.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	budgets := []Budget{}
+	if err := cursor.All(ctx, &budgets); err != nil {
+		return nil, mongoError(err)
+	}
+	return budgets, nil
+}
+
+func (s *MongoStore) DeleteBudget(ctx context.Context, userId, id primitive.ObjectID) error {
+	result, err := s.budgets.DeleteOne(ctx, bson.M{"_id": id, "user_id": userId})
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *MongoStore) CreateCategory(ctx context.Context, c *Category) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	categories, err := s.ListCategories(ctx, c.UserId)
+	if err != nil {
+		return err
+	}
+	c.ID = primitive.NilObjectID
+	if err := NewCategoryTree(categories).CheckPlacement(c); err != nil {
+		return err
+	}
+	now := Now()
+	c.ID = primitive.NewObjectID()
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	if _, err := s.categories.InsertOne(ctx, c); err != nil {
+		return mongoError(err)
+	}
+	return nil
+}
+
+func (s *MongoStore) GetCategory(ctx context.Context, userId, id primitive.ObjectID) (Category, error) {
+	c := Category{}
+	err := s.categories.FindOne(ctx, bson.M{"_id": id, "user_id": userId}).Decode(&c)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return c, ErrCategoryNotFound
+	}
+	return c, mongoError(err)
+}
+
+func (s *MongoStore) UpdateCategory(ctx context.Context, c *Category, fields []string) error {
+	fields = UpdateCategoryFields(fields)
+	if err := c.ValidateFields(fields); err != nil {
+		return err
+	}
+	stored, err := s.GetCategory(ctx, c.UserId, c.ID)
+	if err != nil {
+		return err
+	}
+	categories, err := s.ListCategories(ctx, c.UserId)
+	if err != nil {
+		return err
+	}
+	updated, err := MergeCategoryUpdate(stored, *c, fields, categories)
+	if err != nil {
+		return err
+	}
+	if updated.Type != "" && updated.Type != stored.Type {
+		n, err := s.records.CountDocuments(ctx, bson.M{"user_id": c.UserId, "category_id": c.ID, "type": bson.M{"$ne": updated.Type}})
+		if err != nil {
+			return mongoError(err)
+		}
+		if n > 0 {
+			return fmt.Errorf("%w: category has records other than %s", ErrFailedPrecondition, updated.Type)
+		}
+	}
+	updated.UpdatedAt = Now()
+	result, err := s.categories.ReplaceOne(ctx, bson.M{"_id": c.ID, "user_id": c.UserId}, updated)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	*c = updated
+	return nil
+}
+
+func (s *MongoStore) ListCategories(ctx context.Context, userId primitive.ObjectID) ([]Category, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.categories.Find(ctx, bson.M{"user_id": userId}, opts)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	categories := []Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, mongoError(err)
+	}
+	return categories, nil
+}
+
+// DeleteCategory moves the records, budgets and subcategories and deletes
+// the category in one transaction.
+func (s *MongoStore) DeleteCategory(ctx context.Context, userId, id, moveTo primitive.ObjectID) error {
+	categories, err := s.ListCategories(ctx, userId)
+	if err != nil {
+		return err
+	}
+	deleted, err := NewCategoryTree(categories).CheckMove(id, moveTo)
+	if err != nil {
+		return err
+	}
+	parent := moveTo
+	if parent.IsZero() {
+		parent = deleted.ParentId
+	}
+	now := Now()
+	move := bson.M{"$set": bson.M{"category_id": moveTo, "updated_at": now}, "$inc": bson.M{"version": 1}}
+	if moveTo.IsZero() {
+		move = bson.M{"$unset": bson.M{"category_id": ""}, "$set": bson.M{"updated_at": now}, "$inc": bson.M{"version": 1}}
+	}
+	return s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		cursor, err := s.records.Find(ctx, bson.M{"user_id": userId, "category_id": id})
+		if err != nil {
+			return mongoError(err)
+		}
+		moved := []Record{}
+		if err := cursor.All(ctx, &moved); err != nil {
+			return mongoError(err)
+		}
+		if _, err := s.records.UpdateMany(ctx, bson.M{"user_id": userId, "category_id": id}, move); err != nil {
+			return mongoError(err)
+		}
+		revisions := []Revision{}
+		for _, r := range moved {
+			updated := r
+			updated.CategoryId = moveTo
+			updated.UpdatedAt = now
+			updated.Version++
+			revisions = append(revisions, NewRevision(ctx, RevisionUpdated, r, updated, now))
+		}
+		if err := s.insertRevisions(ctx, revisions...); err != nil {
+			return err
+		}
+		// budgets follow the records, there is nothing left to track without
+		if moveTo.IsZero() {
+			_, err = s.budgets.DeleteMany(ctx, bson.M{"user_id": userId, "category_id": id})
+		} else {
+			_, err = s.budgets.UpdateMany(ctx, bson.M{"user_id": userId, "category_id": id},
+				bson.M{"$set": bson.M{"category_id": moveTo, "updated_at": now}})
+		}
+		if err != nil {
+			return mongoError(err)
+		}
+		if _, err := s.categories.UpdateMany(ctx, bson.M{"user_id": userId, "parent_id": id},
+			bson.M{"$set": bson.M{"parent_id": parent, "updated_at": now}}); err != nil {
+			return mongoError(err)
+		}
+		result, err := s.categories.DeleteOne(ctx, bson.M{"_id": id, "user_id": userId})
+		if err != nil {
+			return mongoError(err)
+		}
+		if result.DeletedCount == 0 {
+			return ErrCategoryNotFound
+		}
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *MongoStore) GetRecordHistory(ctx context.Context, userId, recordId primitive.ObjectID) ([]Revision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.history.Find(ctx, bson.M{"user_id": userId, "record_id": recordId}, opts)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+	revisions := []Revision{}
+	if err := cursor.All(ctx, &revisions); err != nil {
+		return nil, mongoError(err)
+	}
+	return revisions, nil
+}
+
+// insertRevisions appends revisions to the history, in the transaction
+// of their write.
+func (s *MongoStore) insertRevisions(ctx context.Context, revisions ...Revision) error {
+	if len(revisions) == 0 {
+		return nil
+	}
+	docs := []any{}
+	for _, rev := range revisions {
+		docs = append(docs, rev)
+	}
+	_, err := s.history.InsertMany(ctx, docs)
+	return mongoError(err)
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (s *MongoStore) ReserveKey(ctx context.Context, k IdempotencyKey) (IdempotencyKey, error) {
+	// a key that is Free is replaced, the unique index keeps a single
+	// reservation when two calls race for it
+	_, err := s.keys.DeleteOne(ctx, bson.M{"user_id": k.UserId, "key": k.Key, "$or": bson.A{
+		bson.M{"expires_at": bson.M{"$lte": k.CreatedAt}},
+		bson.M{"response": bson.M{"$exists": false}, "locked_until": bson.M{"$lte": k.CreatedAt}},
+	}})
+	if err != nil {
+		return IdempotencyKey{}, mongoError(err)
+	}
+	_, err = s.keys.InsertOne(ctx, k)
+	if err = mongoError(err); !errors.Is(err, ErrAlreadyExists) {
+		return k, err
+	}
+	stored := IdempotencyKey{}
+	if err := s.keys.FindOne(ctx, bson.M{"user_id": k.UserId, "key": k.Key}).Decode(&stored); err != nil {
+		return stored, mongoError(err)
+	}
+	return stored, ErrAlreadyExists
+}
+
+func (s *MongoStore) CompleteKey(ctx context.Context, k IdempotencyKey) error {
+	result, err := s.keys.UpdateOne(ctx, bson.M{"user_id": k.UserId, "key": k.Key, "created_at": k.CreatedAt},
+		bson.M{"$set": bson.M{"request_hash": k.RequestHash, "response": k.Response}})
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) RenewKey(ctx context.Context, k IdempotencyKey) error {
+	result, err := s.keys.UpdateOne(ctx, bson.M{"user_id": k.UserId, "key": k.Key, "created_at": k.CreatedAt, "response": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"locked_until": k.LockedUntil}})
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) ReleaseKey(ctx context.Context, k IdempotencyKey) error {
+	_, err := s.keys.DeleteOne(ctx, bson.M{"user_id": k.UserId, "key": k.Key, "created_at": k.CreatedAt})
+	return mongoError(err)
+}
+
+// PurgeKeys removes the expired keys the TTL index did not remove yet,
+// MongoDB only runs it every minute.
+func (s *MongoStore) PurgeKeys(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.keys.DeleteMany(ctx, bson.M{"expires_at": bson.M{"$lte": now}})
+	if err != nil {
+		return 0, mongoError(err)
+	}
+	return result.DeletedCount, nil
+}
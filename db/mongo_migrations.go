@@ -0,0 +1,252 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongoMigration struct {
+	version int
+	up      func(ctx context.Context, database *mongo.Database) error
+}
+
+// mongoMigrations are applied in order and never edited once released,
+// add a new version instead.
+var mongoMigrations = []mongoMigration{
+	{
+		// amounts used to be plain int32 numbers without a currency
+		version: 1,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("records").UpdateMany(ctx,
+				bson.M{"amount": bson.M{"$type": "number"}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{
+					"amount": bson.M{"units": bson.M{"$toLong": "$amount"}, "currency": DefaultCurrency},
+				}}}},
+			)
+			return err
+		},
+	},
+	{
+		// dates and timestamps used to be strings, and created_at was
+		// written under the misspelled create_at key
+		version: 2,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			records := database.Collection("records")
+			cursor, err := records.Find(ctx, bson.M{"$or": bson.A{
+				bson.M{"date": bson.M{"$not": bson.M{"$type": "date"}}},
+				bson.M{"created_at": bson.M{"$not": bson.M{"$type": "date"}}},
+				bson.M{"updated_at": bson.M{"$not": bson.M{"$type": "date"}}},
+				bson.M{"create_at": bson.M{"$exists": true}},
+			}})
+			if err != nil {
+				return err
+			}
+			defer cursor.Close(ctx)
+
+			for cursor.Next(ctx) {
+				var doc bson.M
+				if err := cursor.Decode(&doc); err != nil {
+					return err
+				}
+				id, _ := doc["_id"].(primitive.ObjectID)
+				created := doc["created_at"]
+				if created == nil {
+					created = doc["create_at"]
+				}
+				createdAt := LegacyTime(created, id.Timestamp())
+				update := bson.M{
+					"$set": bson.M{
+						"date":       LegacyTime(doc["date"], createdAt),
+						"created_at": createdAt,
+						"updated_at": LegacyTime(doc["updated_at"], createdAt),
+					},
+					"$unset": bson.M{"create_at": ""},
+				}
+				if _, err := records.UpdateByID(ctx, id, update); err != nil {
+					return err
+				}
+			}
+			return cursor.Err()
+		},
+	},
+	{
+		// indexes backing the GetRecords filters and sort orders
+		version: 3,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("records").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}, {Key: "_id", Value: 1}}},
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "amount.units", Value: 1}, {Key: "_id", Value: 1}}},
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "type", Value: 1}, {Key: "date", Value: 1}}},
+			})
+			return err
+		},
+	},
+	{
+		// optimistic concurrency, existing records start at version 1
+		version: 4,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("records").UpdateMany(ctx,
+				bson.M{"version": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"version": int64(1)}})
+			return err
+		},
+	},
+	{
+		// category trees and the records of a category
+		version: 5,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("categories").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "parent_id", Value: 1}},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = database.Collection("records").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "category_id", Value: 1}},
+			})
+			return err
+		},
+	},
+	{
+		// multikey index for the tag filters and counts
+		version: 6,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("records").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "tags", Value: 1}},
+			})
+			return err
+		},
+	},
+	{
+		// accounts and the records booked on them
+		version: 7,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("accounts").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = database.Collection("records").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "account_id", Value: 1}, {Key: "date", Value: 1}},
+			})
+			return err
+		},
+	},
+	{
+		// the other side of a transfer is looked up by its transfer_id
+		version: 8,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("records").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "transfer_id", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			})
+			return err
+		},
+	},
+	{
+		// due schedules, and a single record per occurrence of a schedule
+		version: 9,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("schedules").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "title", Value: 1}}},
+				{Keys: bson.D{{Key: "next_date", Value: 1}}, Options: options.Index().SetSparse(true)},
+			})
+			if err != nil {
+				return err
+			}
+			_, err = database.Collection("records").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "schedule_id", Value: 1}, {Key: "occurrence", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"schedule_id": bson.M{"$exists": true}}),
+			})
+			return err
+		},
+	},
+	{
+		// budgets listed by name
+		version: 10,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("budgets").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "name", Value: 1}},
+			})
+			return err
+		},
+	},
+	{
+		// a single record per bank transaction and account, records outside
+		// of any account index a null account_id
+		version: 11,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("records").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "account_id", Value: 1}, {Key: "import_id", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"import_id": bson.M{"$exists": true}}),
+			})
+			return err
+		},
+	},
+	{
+		// a key per user, removed by MongoDB once its window is over
+		version: 12,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("idempotency_keys").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+				{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+			})
+			return err
+		},
+	},
+	{
+		// the deleted records, listed by user and purged by deletion time
+		version: 13,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("trash").Indexes().CreateMany(ctx, []mongo.IndexModel{
+				{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "deleted_at", Value: 1}, {Key: "_id", Value: 1}}},
+				{Keys: bson.D{{Key: "deleted_at", Value: 1}}},
+			})
+			return err
+		},
+	},
+	{
+		// the revisions of a record in order
+		version: 14,
+		up: func(ctx context.Context, database *mongo.Database) error {
+			_, err := database.Collection("record_history").Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "record_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}},
+			})
+			return err
+		},
+	},
+}
+
+// Migrate brings existing documents up to date with the current Record layout.
+func (s *MongoStore) Migrate(ctx context.Context) error {
+	applied := s.database.Collection("migrations")
+	var last struct {
+		Version int `bson:"_id"`
+	}
+	err := applied.FindOne(ctx, bson.M{}, options.FindOne().SetSort(bson.M{"_id": -1})).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+	for _, m := range mongoMigrations {
+		if m.version <= last.Version {
+			continue
+		}
+		if err := m.up(ctx, s.database); err != nil {
+			return fmt.Errorf("migration %d: %w", m.version, err)
+		}
+		if _, err := applied.InsertOne(ctx, bson.M{"_id": m.version, "applied_at": time.Now().UTC()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
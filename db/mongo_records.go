@@ -0,0 +1,431 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore is the MongoDB backed Store.
+type MongoStore struct {
+	database   *mongo.Database
+	records    *mongo.Collection
+	categories *mongo.Collection
+	accounts   *mongo.Collection
+	schedules  *mongo.Collection
+	budgets    *mongo.Collection
+	keys       *mongo.Collection
+	trash      *mongo.Collection
+	history    *mongo.Collection
+}
+
+func NewMongoStore(database *mongo.Database) *MongoStore {
+	return &MongoStore{
+		database:   database,
+		records:    database.Collection("records"),
+		categories: database.Collection("categories"),
+		accounts:   database.Collection("accounts"),
+		schedules:  database.Collection("schedules"),
+		budgets:    database.Collection("budgets"),
+		keys:       database.Collection("idempotency_keys"),
+		trash:      database.Collection("trash"),
+		history:    database.Collection("record_history"),
+	}
+}
+
+// inTransaction runs fn in a transaction committed when it returns nil.
+// Every write of more than one document goes through it, so the
+// MongoStore needs MongoDB to run as a replica set or sharded cluster; a
+// standalone server is not supported.
+func (s *MongoStore) inTransaction(ctx context.Context, fn func(ctx mongo.SessionContext) error) error {
+	session, err := s.database.Client().StartSession()
+	if err != nil {
+		return mongoError(err)
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(ctx mongo.SessionContext) (any, error) {
+		return nil, fn(ctx)
+	})
+	return err
+}
+
+func (s *MongoStore) Create(ctx context.Context, r *Record) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if err := s.recordRefs(ctx).Check(*r); err != nil {
+		return err
+	}
+	now := Now()
+	created := *r
+	err := s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		result, err := s.records.InsertOne(ctx, mongoRecordDocument(*r, now))
+		if err != nil {
+			return mongoError(err)
+		}
+		created.ID = result.InsertedID.(primitive.ObjectID)
+		created.CreatedAt = now
+		created.UpdatedAt = now
+		created.Version = 1
+		return s.insertRevisions(ctx, NewRevision(ctx, RevisionCreated, Record{}, created, now))
+	})
+	if err != nil {
+		return err
+	}
+	*r = created
+	return nil
+}
+
+func (s *MongoStore) CreateMany(ctx context.Context, records []Record) error {
+	if err := CheckBatch(records, s.recordRefs(ctx)); err != nil || len(records) == 0 {
+		return err
+	}
+	now := Now()
+	docs := []any{}
+	for _, r := range records {
+		docs = append(docs, mongoRecordDocument(r, now))
+	}
+	var ids []any
+	err := s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		result, err := s.records.InsertMany(ctx, docs)
+		if err != nil {
+			return mongoError(err)
+		}
+		ids = result.InsertedIDs
+		revisions := []Revision{}
+		for i, r := range records {
+			r.ID = ids[i].(primitive.ObjectID)
+			r.CreatedAt = now
+			r.UpdatedAt = now
+			r.Version = 1
+			revisions = append(revisions, NewRevision(ctx, RevisionCreated, Record{}, r, now))
+		}
+		return s.insertRevisions(ctx, revisions...)
+	})
+	if err != nil {
+		return err
+	}
+	for i := range records {
+		records[i].ID = ids[i].(primitive.ObjectID)
+		records[i].CreatedAt = now
+		records[i].UpdatedAt = now
+		records[i].Version = 1
+	}
+	return nil
+}
+
+// mongoRecordDocument is the document inserted for a new record, the
+// NilObjectID references are left out.
+func mongoRecordDocument(r Record, now time.Time) bson.M {
+	doc := bson.M{
+		"user_id":     r.UserId,
+		"type":        r.Type,
+		"date":        r.Date,
+		"title":       r.Title,
+		"description": r.Description,
+		"amount":      r.Amount,
+		"created_at":  now,
+		"updated_at":  now,
+		"version":     int64(1),
+		"tags":        r.Tags,
+	}
+	if !r.CategoryId.IsZero() {
+		doc["category_id"] = r.CategoryId
+	}
+	if !r.AccountId.IsZero() {
+		doc["account_id"] = r.AccountId
+	}
+	if !r.TransferId.IsZero() {
+		doc["transfer_id"] = r.TransferId
+	}
+	if !r.ScheduleId.IsZero() {
+		doc["schedule_id"] = r.ScheduleId
+		doc["occurrence"] = r.Occurrence
+	}
+	if r.ImportId != "" {
+		doc["import_id"] = r.ImportId
+	}
+	return doc
+}
+
+func (s *MongoStore) ImportedIds(ctx context.Context, userId, accountId primitive.ObjectID, importIds []string) (map[string]bool, error) {
+	taken := map[string]bool{}
+	if len(importIds) == 0 {
+		return taken, nil
+	}
+	filter := bson.M{"user_id": userId, "account_id": bson.M{"$exists": false}, "import_id": bson.M{"$in": importIds}}
+	if !accountId.IsZero() {
+		filter["account_id"] = accountId
+	}
+	cursor, err := s.records.Find(ctx, filter, options.Find().SetProjection(bson.M{"import_id": 1}))
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var r Record
+		if err := cursor.Decode(&r); err != nil {
+			return nil, err
+		}
+		taken[r.ImportId] = true
+	}
+	return taken, mongoError(cursor.Err())
+}
+
+func (s *MongoStore) Get(ctx context.Context, userId, id primitive.ObjectID) (Record, error) {
+	r := Record{}
+	err := s.records.FindOne(ctx, bson.M{"_id": id, "user_id": userId}).Decode(&r)
+	return r, mongoError(err)
+}
+
+func (s *MongoStore) Update(ctx context.Context, r *Record, fields []string) error {
+	fields = UpdateFields(fields)
+	if err := r.ValidateFields(fields); err != nil {
+		return err
+	}
+	stored, err := s.Get(ctx, r.UserId, r.ID)
+	if err != nil {
+		return err
+	}
+	updated := stored
+	updated.ApplyFields(*r, fields)
+	if RefsChanged(fields) {
+		if err := s.recordRefs(ctx).Check(updated); err != nil {
+			return err
+		}
+	}
+	if stored.IsTransfer() {
+		return s.updateTransferSide(ctx, r, stored, updated, fields)
+	}
+	now := Now()
+	written := Record{}
+	err = s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := s.records.FindOneAndUpdate(ctx, mongoRecordVersion(r.UserId, r.ID, r.Version), mongoRecordUpdate(*r, fields, now), opts).Decode(&written)
+		if err == mongo.ErrNoDocuments {
+			return s.missingRecord(ctx, r.UserId, r.ID)
+		}
+		if err != nil {
+			return mongoError(err)
+		}
+		return s.insertRevisions(ctx, NewRevision(ctx, RevisionUpdated, stored, written, now))
+	})
+	if err != nil {
+		return err
+	}
+	*r = written
+	return nil
+}
+
+// mongoRecordUpdate writes the given fields of r and a new version.
+func mongoRecordUpdate(r Record, fields []string, now time.Time) bson.M {
+	values := bson.M{
+		"title":       r.Title,
+		"amount":      r.Amount,
+		"description": r.Description,
+		"date":        r.Date,
+		"type":        r.Type,
+		"category_id": r.CategoryId,
+		"tags":        r.Tags,
+		"account_id":  r.AccountId,
+	}
+	set := bson.M{"updated_at": now}
+	unset := bson.M{}
+	for _, field := range fields {
+		if id, ok := values[field].(primitive.ObjectID); ok && id.IsZero() {
+			unset[field] = ""
+			continue
+		}
+		set[field] = values[field]
+	}
+	payload := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+	if len(unset) > 0 {
+		payload["$unset"] = unset
+	}
+	return payload
+}
+
+func (s *MongoStore) Delete(ctx context.Context, userId, id primitive.ObjectID, version int64) error {
+	sides, err := s.deleteSides(ctx, userId, RecordDelete{ID: id, Version: version})
+	if err != nil {
+		return err
+	}
+	return s.moveToTrash(ctx, sides)
+}
+
+// mongoRecordVersion selects the user's record, only at the given version
+// unless it is zero.
+func mongoRecordVersion(userId, id primitive.ObjectID, version int64) bson.M {
+	filter := bson.M{"_id": id, "user_id": userId}
+	if version != 0 {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// missingRecord tells why a conditional write matched nothing.
+func (s *MongoStore) missingRecord(ctx context.Context, userId, id primitive.ObjectID) error {
+	n, err := s.records.CountDocuments(ctx, bson.M{"_id": id, "user_id": userId})
+	if err != nil {
+		return mongoError(err)
+	}
+	if n > 0 {
+		return ErrVersionMismatch
+	}
+	return ErrNotFound
+}
+
+func (s *MongoStore) GetUserRecords(ctx context.Context, q RecordQuery) (RecordPage, error) {
+	if err := q.Validate(); err != nil {
+		return RecordPage{}, err
+	}
+
+	filter := mongoRecordFilter(q.UserId, q.Filter)
+	total := int64(0)
+	if q.CountTotal {
+		var err error
+		if total, err = s.records.CountDocuments(ctx, filter); err != nil {
+			return RecordPage{}, mongoError(err)
+		}
+	}
+	if q.After != nil {
+		filter = bson.M{"$and": bson.A{filter, mongoAfterCursor(q.Sort, *q.After)}}
+	}
+
+	opts := options.Find().
+		SetSort(mongoRecordSort(q.Sort)).
+		SetLimit(int64(q.PageSize) + 1)
+	cursor, err := s.records.Find(ctx, filter, opts)
+	if err != nil {
+		return RecordPage{}, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	rl := []Record{}
+	if err = cursor.All(ctx, &rl); err != nil {
+		return RecordPage{}, mongoError(err)
+	}
+	page := NewRecordPage(q, rl)
+	page.Total = total
+	return page, nil
+}
+
+// recordRefs looks up the references of records for RecordRefs.Check.
+func (s *MongoStore) recordRefs(ctx context.Context) RecordRefs {
+	return RecordRefs{
+		Category: func(userId, id primitive.ObjectID) (Category, error) {
+			return s.GetCategory(ctx, userId, id)
+		},
+		Account: func(userId, id primitive.ObjectID) (Account, error) {
+			return s.GetAccount(ctx, userId, id)
+		},
+	}
+}
+
+// mongoError translates driver errors into the db errors every store shares.
+func mongoError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return ErrNotFound
+	case mongo.IsDuplicateKeyError(err):
+		return fmt.Errorf("%w: %w", ErrAlreadyExists, err)
+	case mongo.IsNetworkError(err), mongo.IsTimeout(err), errors.Is(err, mongo.ErrClientDisconnected):
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
+
+func mongoRecordFilter(userId primitive.ObjectID, f RecordFilter) bson.M {
+	filter := bson.M{"user_id": userId}
+	if f.Type != "" {
+		filter["type"] = f.Type
+	}
+	date := bson.M{}
+	if !f.From.IsZero() {
+		date["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		date["$lt"] = f.To
+	}
+	if len(date) > 0 {
+		filter["date"] = date
+	}
+	amount := bson.M{}
+	if f.MinAmount != nil {
+		amount["$gte"] = *f.MinAmount
+	}
+	if f.MaxAmount != nil {
+		amount["$lte"] = *f.MaxAmount
+	}
+	if len(amount) > 0 {
+		filter["amount.units"] = amount
+	}
+	if f.Currency != "" {
+		filter["amount.currency"] = f.Currency
+	}
+	if len(f.Categories) > 0 {
+		filter["category_id"] = bson.M{"$in": f.Categories}
+	}
+	if !f.AccountId.IsZero() {
+		filter["account_id"] = f.AccountId
+	}
+	if f.ExcludeTransfers {
+		filter["transfer_id"] = bson.M{"$exists": false}
+	}
+	tags := bson.M{}
+	if len(f.AnyTags) > 0 {
+		tags["$in"] = f.AnyTags
+	}
+	if len(f.AllTags) > 0 {
+		tags["$all"] = f.AllTags
+	}
+	if len(tags) > 0 {
+		filter["tags"] = tags
+	}
+	if f.Text != "" {
+		text := primitive.Regex{Pattern: regexp.QuoteMeta(f.Text), Options: "i"}
+		filter["$or"] = bson.A{bson.M{"title": text}, bson.M{"description": text}}
+	}
+	return filter
+}
+
+var mongoSortKeys = map[SortField]string{
+	SortByDate:      "date",
+	SortByAmount:    "amount.units",
+	SortByCreatedAt: "created_at",
+	SortByDeletedAt: "deleted_at",
+}
+
+// mongoAfterCursor matches the records after c in the sort order.
+func mongoAfterCursor(s RecordSort, c RecordCursor) bson.M {
+	op := "$lt"
+	if s.Ascending {
+		op = "$gt"
+	}
+	var value any = c.Value
+	if s.Field != SortByAmount {
+		value = time.UnixMilli(c.Value).UTC()
+	}
+	key := mongoSortKeys[s.Field]
+	return bson.M{"$or": bson.A{
+		bson.M{key: bson.M{op: value}},
+		bson.M{key: value, "_id": bson.M{op: c.ID}},
+	}}
+}
+
+func mongoRecordSort(s RecordSort) bson.D {
+	dir := -1
+	if s.Ascending {
+		dir = 1
+	}
+	return bson.D{{Key: mongoSortKeys[s.Field], Value: dir}, {Key: "_id", Value: dir}}
+}
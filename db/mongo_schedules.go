@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *MongoStore) CreateSchedule(ctx context.Context, sc *Schedule) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	if err := sc.Check(s.recordRefs(ctx)); err != nil {
+		return err
+	}
+	created := *sc
+	now := Now()
+	created.ID = primitive.NewObjectID()
+	created.Begin()
+	created.Version = 1
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	if _, err := s.schedules.InsertOne(ctx, created); err != nil {
+		return mongoError(err)
+	}
+	*sc = created
+	return nil
+}
+
+func (s *MongoStore) GetSchedule(ctx context.Context, userId, id primitive.ObjectID) (Schedule, error) {
+	sc := Schedule{}
+	err := s.schedules.FindOne(ctx, bson.M{"_id": id, "user_id": userId}).Decode(&sc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return sc, ErrScheduleNotFound
+	}
+	return sc, mongoError(err)
+}
+
+// UpdateSchedule retries when the materializer advanced the schedule
+// between reading and writing it.
+func (s *MongoStore) UpdateSchedule(ctx context.Context, sc *Schedule, fields []string) error {
+	fields = UpdateScheduleFields(fields)
+	if err := sc.ValidateFields(fields); err != nil {
+		return err
+	}
+	for attempt := 0; attempt < ScheduleUpdateAttempts; attempt++ {
+		stored, err := s.GetSchedule(ctx, sc.UserId, sc.ID)
+		if err != nil {
+			return err
+		}
+		updated, err := MergeScheduleUpdate(stored, *sc, fields, s.recordRefs(ctx))
+		if err != nil {
+			return err
+		}
+		result, err := s.schedules.ReplaceOne(ctx, bson.M{"_id": sc.ID, "user_id": sc.UserId, "version": stored.Version}, updated)
+		if err != nil {
+			return mongoError(err)
+		}
+		if result.MatchedCount > 0 {
+			*sc = updated
+			return nil
+		}
+	}
+	return ErrVersionMismatch
+}
+
+func (s *MongoStore) ListSchedules(ctx context.Context, userId primitive.ObjectID) ([]Schedule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "title", Value: 1}, {Key: "_id", Value: 1}})
+	return s.findSchedules(ctx, bson.M{"user_id": userId}, opts)
+}
+
+func (s *MongoStore) DeleteSchedule(ctx context.Context, userId, id primitive.ObjectID) error {
+	result, err := s.schedules.DeleteOne(ctx, bson.M{"_id": id, "user_id": userId})
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+func (s *MongoStore) DueSchedules(ctx context.Context, now time.Time) ([]Schedule, error) {
+	return s.findSchedules(ctx, bson.M{"next_date": bson.M{"$lte": now}})
+}
+
+func (s *MongoStore) AdvanceSchedule(ctx context.Context, sc Schedule, materialized int) error {
+	advanced := sc.AdvanceTo(materialized)
+	update := bson.M{
+		"$set": bson.M{"materialized": advanced.Materialized},
+		"$inc": bson.M{"version": 1},
+	}
+	if advanced.NextDate.IsZero() {
+		update["$unset"] = bson.M{"next_date": ""}
+	} else {
+		update["$set"].(bson.M)["next_date"] = advanced.NextDate
+	}
+	result, err := s.schedules.UpdateOne(ctx, bson.M{"_id": sc.ID, "user_id": sc.UserId, "version": sc.Version}, update)
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+func (s *MongoStore) findSchedules(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]Schedule, error) {
+	cursor, err := s.schedules.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	schedules := []Schedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, mongoError(err)
+	}
+	return schedules, nil
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Summarize groups the records by period with $dateTrunc, which needs
+// MongoDB 5.0 or later.
+func (s *MongoStore) Summarize(ctx context.Context, q SummaryQuery) ([]SummaryRow, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: mongoRecordFilter(q.UserId, q.Filter)}}}
+	var group any
+	switch q.GroupBy {
+	case "CATEGORY":
+		group = bson.M{"$toString": "$category_id"}
+	case "ACCOUNT":
+		group = bson.M{"$toString": "$account_id"}
+	case "TAG":
+		pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: bson.M{"path": "$tags", "preserveNullAndEmptyArrays": true}}})
+		group = "$tags"
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.M{
+		"_id": bson.M{
+			"start": bson.M{"$dateTrunc": bson.M{
+				"date":        "$date",
+				"unit":        strings.ToLower(q.Period),
+				"timezone":    q.Location.String(),
+				"startOfWeek": "monday",
+			}},
+			"group":    group,
+			"currency": "$amount.currency",
+			"type":     "$type",
+		},
+		"total": bson.M{"$sum": "$amount.units"},
+		"count": bson.M{"$sum": 1},
+	}}})
+	cursor, err := s.records.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	var groups []struct {
+		ID struct {
+			Start    time.Time `bson:"start"`
+			Group    string    `bson:"group"`
+			Currency string    `bson:"currency"`
+			Type     string    `bson:"type"`
+		} `bson:"_id"`
+		Total int64 `bson:"total"`
+		Count int64 `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, mongoError(err)
+	}
+	summary := NewSummarizer(q)
+	for _, g := range groups {
+		summary.Add(g.ID.Start, g.ID.Type, g.ID.Group, Money{Units: g.Total, Currency: g.ID.Currency}, g.Count)
+	}
+	return summary.Rows(), nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (s *MongoStore) ListTags(ctx context.Context, userId primitive.ObjectID) ([]TagCount, error) {
+	cursor, err := s.records.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"user_id": userId}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "records": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "records", Value: -1}, {Key: "_id", Value: 1}}}},
+	})
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	var counts []struct {
+		Tag     string `bson:"_id"`
+		Records int64  `bson:"records"`
+	}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, mongoError(err)
+	}
+	tags := []TagCount{}
+	for _, c := range counts {
+		tags = append(tags, TagCount{Tag: c.Tag, Records: c.Records})
+	}
+	return tags, nil
+}
+
+// RenameTag rewrites the tags of each record on its own so they stay
+// sorted, all in one transaction with the budgets of the tag.
+func (s *MongoStore) RenameTag(ctx context.Context, userId primitive.ObjectID, from, to string) (int64, error) {
+	from, to, err := CheckRename(from, to)
+	if err != nil || from == to {
+		return 0, err
+	}
+	now := Now()
+	changed := int64(0)
+	err = s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		_, err := s.budgets.UpdateMany(ctx, bson.M{"user_id": userId, "tag": from}, bson.M{"$set": bson.M{"tag": to, "updated_at": now}})
+		if err != nil {
+			return mongoError(err)
+		}
+		cursor, err := s.records.Find(ctx, bson.M{"user_id": userId, "tags": from})
+		if err != nil {
+			return mongoError(err)
+		}
+		tagged := []Record{}
+		if err := cursor.All(ctx, &tagged); err != nil {
+			return mongoError(err)
+		}
+		changed = int64(len(tagged))
+		if len(tagged) == 0 {
+			return nil
+		}
+		models := []mongo.WriteModel{}
+		revisions := []Revision{}
+		for _, r := range tagged {
+			renamed := r
+			renamed.Tags = RenameTagIn(r.Tags, from, to)
+			renamed.UpdatedAt = now
+			renamed.Version++
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": r.ID, "user_id": userId}).
+				SetUpdate(bson.M{"$set": bson.M{"tags": renamed.Tags, "updated_at": now}, "$inc": bson.M{"version": 1}}))
+			revisions = append(revisions, NewRevision(ctx, RevisionUpdated, r, renamed, now))
+		}
+		if _, err := s.records.BulkWrite(ctx, models); err != nil {
+			return mongoError(err)
+		}
+		return s.insertRevisions(ctx, revisions...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (s *MongoStore) CreateTransfer(ctx context.Context, t *Transfer) (Record, Record, error) {
+	if err := t.Validate(s.recordRefs(ctx)); err != nil {
+		return Record{}, Record{}, err
+	}
+	transfer := *t
+	transfer.ID = primitive.NewObjectID()
+	debit, credit := transfer.Records()
+	now := Now()
+	err := s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		result, err := s.records.InsertMany(ctx, []any{mongoRecordDocument(debit, now), mongoRecordDocument(credit, now)})
+		if err != nil {
+			return mongoError(err)
+		}
+		revisions := []Revision{}
+		for i, r := range []*Record{&debit, &credit} {
+			r.ID = result.InsertedIDs[i].(primitive.ObjectID)
+			r.CreatedAt = now
+			r.UpdatedAt = now
+			r.Version = 1
+			revisions = append(revisions, NewRevision(ctx, RevisionCreated, Record{}, *r, now))
+		}
+		return s.insertRevisions(ctx, revisions...)
+	})
+	if err != nil {
+		return Record{}, Record{}, err
+	}
+	*t = transfer
+	return debit, credit, nil
+}
+
+// updateTransferSide is Update for a record of a transfer, the shared
+// fields are written to the other side in the same transaction.
+func (s *MongoStore) updateTransferSide(ctx context.Context, r *Record, stored, updated Record, fields []string) error {
+	other, err := s.otherSide(ctx, stored)
+	if err != nil {
+		return err
+	}
+	shared, err := CheckTransferSide(stored, updated, other, fields, s.recordRefs(ctx))
+	if err != nil {
+		return err
+	}
+	now := Now()
+	written := Record{}
+	err = s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := s.records.FindOneAndUpdate(ctx, mongoRecordVersion(r.UserId, r.ID, r.Version), mongoRecordUpdate(*r, fields, now), opts).Decode(&written)
+		if err == mongo.ErrNoDocuments {
+			return s.missingRecord(ctx, r.UserId, r.ID)
+		}
+		if err != nil {
+			return mongoError(err)
+		}
+		revisions := []Revision{NewRevision(ctx, RevisionUpdated, stored, written, now)}
+		if len(shared) > 0 {
+			otherWritten := Record{}
+			err = s.records.FindOneAndUpdate(ctx, bson.M{"_id": other.ID, "user_id": other.UserId}, mongoRecordUpdate(updated, shared, now), opts).Decode(&otherWritten)
+			if err != nil {
+				return mongoError(err)
+			}
+			revisions = append(revisions, NewRevision(ctx, RevisionUpdated, other, otherWritten, now))
+		}
+		return s.insertRevisions(ctx, revisions...)
+	})
+	if err != nil {
+		return err
+	}
+	*r = written
+	return nil
+}
+
+// otherSide reads the other record of the transfer r belongs to.
+func (s *MongoStore) otherSide(ctx context.Context, r Record) (Record, error) {
+	other := Record{}
+	err := s.records.FindOne(ctx, bson.M{"user_id": r.UserId, "transfer_id": r.TransferId, "_id": bson.M{"$ne": r.ID}}).Decode(&other)
+	return other, mongoError(err)
+}
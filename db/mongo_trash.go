@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errSideChanged aborts the transaction of a delete when a record changed
+// since it was read.
+var errSideChanged = errors.New("the record changed since it was read")
+
+// moveToTrash deletes the records read by deleteSides, at the version they
+// were read, and inserts them into the trash in a transaction.
+func (s *MongoStore) moveToTrash(ctx context.Context, records []Record) error {
+	now := Now()
+	models := []mongo.WriteModel{}
+	docs := []any{}
+	revisions := []Revision{}
+	for _, r := range records {
+		models = append(models, mongo.NewDeleteOneModel().SetFilter(mongoRecordVersion(r.UserId, r.ID, r.Version)))
+		r.DeletedAt = now
+		docs = append(docs, r)
+		revisions = append(revisions, NewRevision(ctx, RevisionDeleted, r, Record{}, now))
+	}
+	err := s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		result, err := s.records.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+		if err != nil {
+			return mongoError(err)
+		}
+		if result.DeletedCount < int64(len(models)) {
+			return errSideChanged
+		}
+		if _, err = s.trash.InsertMany(ctx, docs); err != nil {
+			return mongoError(err)
+		}
+		return s.insertRevisions(ctx, revisions...)
+	})
+	if errors.Is(err, errSideChanged) {
+		return s.missingRecord(ctx, records[0].UserId, records[0].ID)
+	}
+	return err
+}
+
+func (s *MongoStore) ListTrash(ctx context.Context, q RecordQuery) (RecordPage, error) {
+	q.Filter, q.Sort = RecordFilter{}, TrashSort
+	if err := q.Validate(); err != nil {
+		return RecordPage{}, err
+	}
+
+	filter := bson.M{"user_id": q.UserId}
+	total := int64(0)
+	if q.CountTotal {
+		var err error
+		if total, err = s.trash.CountDocuments(ctx, filter); err != nil {
+			return RecordPage{}, mongoError(err)
+		}
+	}
+	if q.After != nil {
+		filter = bson.M{"$and": bson.A{filter, mongoAfterCursor(q.Sort, *q.After)}}
+	}
+
+	opts := options.Find().
+		SetSort(mongoRecordSort(q.Sort)).
+		SetLimit(int64(q.PageSize) + 1)
+	cursor, err := s.trash.Find(ctx, filter, opts)
+	if err != nil {
+		return RecordPage{}, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	rl := []Record{}
+	if err = cursor.All(ctx, &rl); err != nil {
+		return RecordPage{}, mongoError(err)
+	}
+	page := NewRecordPage(q, rl)
+	page.Total = total
+	return page, nil
+}
+
+func (s *MongoStore) RestoreRecord(ctx context.Context, userId, id primitive.ObjectID) (Record, error) {
+	sides, err := s.trashed(ctx, userId, id)
+	if err != nil {
+		return Record{}, err
+	}
+	now := Now()
+	restored := []Record{}
+	docs := []any{}
+	revisions := []Revision{}
+	for _, side := range sides {
+		r, err := CheckRestore(side, s.recordRefs(ctx), now)
+		if err != nil {
+			return Record{}, err
+		}
+		restored = append(restored, r)
+		docs = append(docs, r)
+		revisions = append(revisions, NewRevision(ctx, RevisionRestored, side, r, now))
+	}
+	err = s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		if err := s.purgeSides(ctx, sides); err != nil {
+			return err
+		}
+		if _, err := s.records.InsertMany(ctx, docs); err != nil {
+			return mongoError(err)
+		}
+		return s.insertRevisions(ctx, revisions...)
+	})
+	if err != nil {
+		return Record{}, err
+	}
+	return restored[0], nil
+}
+
+func (s *MongoStore) PurgeRecord(ctx context.Context, userId, id primitive.ObjectID) error {
+	sides, err := s.trashed(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	return s.inTransaction(ctx, func(ctx mongo.SessionContext) error {
+		return s.purgeSides(ctx, sides)
+	})
+}
+
+func (s *MongoStore) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.trash.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, mongoError(err)
+	}
+	return result.DeletedCount, nil
+}
+
+// trashed reads the user's deleted record, followed by the other side of
+// its transfer.
+func (s *MongoStore) trashed(ctx context.Context, userId, id primitive.ObjectID) ([]Record, error) {
+	r := Record{}
+	if err := s.trash.FindOne(ctx, bson.M{"_id": id, "user_id": userId}).Decode(&r); err != nil {
+		return nil, mongoError(err)
+	}
+	if !r.IsTransfer() {
+		return []Record{r}, nil
+	}
+	cursor, err := s.trash.Find(ctx, bson.M{"user_id": userId, "transfer_id": r.TransferId, "_id": bson.M{"$ne": id}})
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+	others := []Record{}
+	if err := cursor.All(ctx, &others); err != nil {
+		return nil, mongoError(err)
+	}
+	return append([]Record{r}, others...), nil
+}
+
+// purgeSides removes the records read by trashed from the trash, unless
+// they were restored or purged since.
+func (s *MongoStore) purgeSides(ctx context.Context, sides []Record) error {
+	ids := []primitive.ObjectID{}
+	for _, r := range sides {
+		ids = append(ids, r.ID)
+	}
+	result, err := s.trash.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.DeletedCount < int64(len(ids)) {
+		return ErrNotFound
+	}
+	return nil
+}
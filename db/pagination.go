@@ -0,0 +1,132 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultPageSize = 10
+	MaxPageSize     = 100
+)
+
+var ErrInvalidPageToken = InvalidField("page_token", errors.New("page token is invalid or does not match the query"))
+
+// RecordCursor is the position of the last record of a page in the sort
+// order, the next page starts strictly after it. Keying on the sort value
+// and id keeps pages stable while records are inserted concurrently.
+type RecordCursor struct {
+	Value int64
+	ID    primitive.ObjectID
+}
+
+// RecordPage is one page of a RecordQuery.
+type RecordPage struct {
+	Records []Record
+	// Next is nil on the last page
+	Next *RecordCursor
+	// Total counts every matching record when the query asked for it
+	Total int64
+}
+
+// SortValue is the value of the sort field of r a cursor keys on.
+func (s RecordSort) SortValue(r Record) int64 {
+	switch s.Field {
+	case SortByAmount:
+		return r.Amount.Units
+	case SortByCreatedAt:
+		return r.CreatedAt.UnixMilli()
+	case SortByDeletedAt:
+		return r.DeletedAt.UnixMilli()
+	default:
+		return r.Date.UnixMilli()
+	}
+}
+
+// After reports whether r comes after the cursor in the sort order.
+func (s RecordSort) After(r Record, c RecordCursor) bool {
+	cmp := compareInt64(s.SortValue(r), c.Value)
+	if cmp == 0 {
+		cmp = compareObjectIDs(r.ID, c.ID)
+	}
+	if !s.Ascending {
+		cmp = -cmp
+	}
+	return cmp > 0
+}
+
+// NewRecordPage builds the page from up to PageSize+1 records fetched in
+// sort order, the extra record only tells there is a next page.
+func NewRecordPage(q RecordQuery, fetched []Record) RecordPage {
+	page := RecordPage{Records: fetched}
+	if len(fetched) > q.PageSize {
+		page.Records = fetched[:q.PageSize]
+		last := page.Records[len(page.Records)-1]
+		page.Next = &RecordCursor{Value: q.Sort.SortValue(last), ID: last.ID}
+	}
+	return page
+}
+
+type pageToken struct {
+	Value       int64  `json:"v"`
+	ID          string `json:"i"`
+	Fingerprint string `json:"f"`
+}
+
+// EncodePageToken turns the cursor into an opaque token bound to the query.
+func EncodePageToken(q RecordQuery, c *RecordCursor) string {
+	if c == nil {
+		return ""
+	}
+	b, _ := json.Marshal(pageToken{Value: c.Value, ID: c.ID.Hex(), Fingerprint: q.fingerprint()})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodePageToken parses a token from EncodePageToken, it must come from
+// a query with the same user, filter and sort.
+func DecodePageToken(q RecordQuery, token string) (*RecordCursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var t pageToken
+	if err := json.Unmarshal(b, &t); err != nil || t.Fingerprint != q.fingerprint() {
+		return nil, ErrInvalidPageToken
+	}
+	id, err := primitive.ObjectIDFromHex(t.ID)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	return &RecordCursor{Value: t.Value, ID: id}, nil
+}
+
+func (q RecordQuery) fingerprint() string {
+	b, _ := json.Marshal(struct {
+		UserId primitive.ObjectID
+		Filter RecordFilter
+		Sort   RecordSort
+	}{q.UserId, q.Filter, q.Sort})
+	h := fnv.New64a()
+	h.Write(b)
+	return strconv.FormatUint(h.Sum64(), 36)
+}
+
+func compareObjectIDs(a, b primitive.ObjectID) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RecordFilter narrows down a user's records, zero fields match everything.
+type RecordFilter struct {
+	Type string
+	// From is inclusive and To exclusive
+	From time.Time
+	To   time.Time
+	// MinAmount and MaxAmount are inclusive bounds in minor units
+	MinAmount *int64
+	MaxAmount *int64
+	Currency  string
+	// Text is a case-insensitive substring of the title or description
+	Text string
+	// Categories matches records in any of the categories, see
+	// CategoryTree.Descendants to include subcategories
+	Categories []primitive.ObjectID
+	// AnyTags matches records with at least one of the tags and AllTags
+	// records with every one of them
+	AnyTags []string
+	AllTags []string
+	// AccountId matches the records booked on the account
+	AccountId primitive.ObjectID
+	// ExcludeTransfers leaves out both records of every transfer
+	ExcludeTransfers bool
+}
+
+type SortField string
+
+const (
+	SortByDate      SortField = "date"
+	SortByAmount    SortField = "amount"
+	SortByCreatedAt SortField = "created_at"
+	// SortByDeletedAt only sorts the trash
+	SortByDeletedAt SortField = "deleted_at"
+)
+
+type RecordSort struct {
+	Field     SortField
+	Ascending bool
+}
+
+// RecordQuery selects a page of a user's records.
+type RecordQuery struct {
+	UserId primitive.ObjectID
+	Filter RecordFilter
+	Sort   RecordSort
+	// PageSize defaults to DefaultPageSize and is capped at MaxPageSize
+	PageSize int
+	// After is the cursor of the previous page, nil for the first one
+	After      *RecordCursor
+	CountTotal bool
+}
+
+func (q *RecordQuery) Validate() error {
+	v := Violations{}
+	q.Filter.validate(&v)
+	switch q.Sort.Field {
+	case "":
+		q.Sort.Field = SortByDate
+	case SortByDate, SortByAmount, SortByCreatedAt, SortByDeletedAt:
+	default:
+		v.Add("sort.field", fmt.Errorf("cannot sort records by '%s'", q.Sort.Field))
+	}
+	switch {
+	case q.PageSize < 0:
+		v.Add("page_size", fmt.Errorf("page size should not be negative"))
+	case q.PageSize == 0:
+		q.PageSize = DefaultPageSize
+	case q.PageSize > MaxPageSize:
+		q.PageSize = MaxPageSize
+	}
+	return v.Err()
+}
+
+// validate adds the violations of the filter to v and normalizes its tags.
+func (f *RecordFilter) validate(v *Violations) {
+	if f.Type != "" {
+		v.Add("filter.type", TypeCheck(f.Type))
+	}
+	if !f.From.IsZero() && !f.To.IsZero() && !f.From.Before(f.To) {
+		v.Add("filter.to_date", fmt.Errorf("from date should be before to date"))
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		v.Add("filter.max_amount", fmt.Errorf("min amount should not be greater than max amount"))
+	}
+	if f.Currency != "" {
+		v.Add("filter.currency_code", Money{Currency: f.Currency}.Validate())
+	}
+	var err error
+	if f.AnyTags, err = normalizeFilterTags(f.AnyTags); err != nil {
+		v.Add("filter.any_tags", err)
+	}
+	if f.AllTags, err = normalizeFilterTags(f.AllTags); err != nil {
+		v.Add("filter.all_tags", err)
+	}
+}
+
+// Match reports whether r passes the filter, for stores filtering in memory.
+func (f RecordFilter) Match(r Record) bool {
+	if f.Type != "" && r.Type != f.Type {
+		return false
+	}
+	if !f.From.IsZero() && r.Date.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !r.Date.Before(f.To) {
+		return false
+	}
+	if f.MinAmount != nil && r.Amount.Units < *f.MinAmount {
+		return false
+	}
+	if f.MaxAmount != nil && r.Amount.Units > *f.MaxAmount {
+		return false
+	}
+	if f.Currency != "" && r.Amount.Currency != f.Currency {
+		return false
+	}
+	if len(f.Categories) > 0 && !containsId(f.Categories, r.CategoryId) {
+		return false
+	}
+	if !f.AccountId.IsZero() && r.AccountId != f.AccountId {
+		return false
+	}
+	if f.ExcludeTransfers && r.IsTransfer() {
+		return false
+	}
+	if len(f.AnyTags) > 0 && !hasTags(r.Tags, f.AnyTags, false) {
+		return false
+	}
+	if len(f.AllTags) > 0 && !hasTags(r.Tags, f.AllTags, true) {
+		return false
+	}
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		if !strings.Contains(strings.ToLower(r.Title), text) && !strings.Contains(strings.ToLower(r.Description), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare orders a before b (-1), after b (1) or equal (0) by the sort
+// field, ties are broken by id so the order is stable.
+func (s RecordSort) Compare(a, b Record) int {
+	c := 0
+	switch s.Field {
+	case SortByAmount:
+		c = compareInt64(a.Amount.Units, b.Amount.Units)
+	case SortByCreatedAt:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	case SortByDeletedAt:
+		c = a.DeletedAt.Compare(b.DeletedAt)
+	default:
+		c = a.Date.Compare(b.Date)
+	}
+	if c == 0 {
+		c = compareObjectIDs(a.ID, b.ID)
+	}
+	if !s.Ascending {
+		c = -c
+	}
+	return c
+}
+
+// normalizeFilterTags normalizes the tags of a filter, nil when empty.
+func normalizeFilterTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return NormalizeTags(tags)
+}
+
+func containsId(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -2,114 +2,204 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type Record struct {
 	ID          primitive.ObjectID `bson:"_id" json:"_id"`
 	UserId      primitive.ObjectID `bson:"user_id" json:"user_id"`
 	Type        string             `bson:"type" json:"type"`
-	Date        string             `bson:"date" json:"date"`
+	Date        time.Time          `bson:"date" json:"date"`
 	Title       string             `bson:"title" json:"title"`
 	Description string             `bson:"description" json:"description"`
-	Amount      int32              `bson:"amount" json:"amount"`
-	CreatedAt   string             `bson:"created_at" json:"created_at"`
-	UpdatedAt   string             `bson:"updated_at" json:"updated_at"`
+	Amount      Money              `bson:"amount" json:"amount"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	// Version starts at 1 and is incremented by every update.
+	Version int64 `bson:"version" json:"version"`
+	// CategoryId is the NilObjectID for uncategorized records
+	CategoryId primitive.ObjectID `bson:"category_id,omitempty" json:"category_id"`
+	// Tags are normalized with NormalizeTags
+	Tags []string `bson:"tags" json:"tags"`
+	// AccountId is the NilObjectID for records outside of any account
+	AccountId primitive.ObjectID `bson:"account_id,omitempty" json:"account_id"`
+	// TransferId links both records of a Transfer, the NilObjectID otherwise
+	TransferId primitive.ObjectID `bson:"transfer_id,omitempty" json:"transfer_id"`
+	// ScheduleId and Occurrence identify the occurrence of the Schedule
+	// that created the record, a schedule creates a single record for each
+	ScheduleId primitive.ObjectID `bson:"schedule_id,omitempty" json:"schedule_id"`
+	Occurrence time.Time          `bson:"occurrence,omitempty" json:"occurrence"`
+	// ImportId identifies the bank transaction the record was imported
+	// from, such as the FITID of an OFX statement. It is unique among the
+	// user's records of an account, or outside of any account.
+	ImportId string `bson:"import_id,omitempty" json:"import_id"`
+	// DeletedAt is when the record was moved to the trash, zero for the
+	// records out of it
+	DeletedAt time.Time `bson:"deleted_at,omitempty" json:"deleted_at"`
 }
 
-const RECORDS_PER_PAGE int64 = 10
+// RecordStore is the persistence layer used by the records service.
+// Implementations must behave identically so the service can run on any of them.
+// Every write appends a Revision per record it writes, by the actor of its
+// context, along with the write.
+type RecordStore interface {
+	// Create inserts r and sets its ID and first version. It returns
+	// ErrAlreadyExists when the occurrence of its schedule was recorded or
+	// its import id is taken.
+	Create(ctx context.Context, r *Record) error
+	// CreateMany inserts every record or none of them, setting their IDs
+	// and first versions, see CheckBatch.
+	CreateMany(ctx context.Context, records []Record) error
+	// ImportedIds returns which of the import ids are taken by the user's
+	// records on the account, or outside of any account when accountId is
+	// the NilObjectID.
+	ImportedIds(ctx context.Context, userId, accountId primitive.ObjectID, importIds []string) (map[string]bool, error)
+	// Get returns the user's record with the given id or ErrNotFound.
+	Get(ctx context.Context, userId, id primitive.ObjectID) (Record, error)
+	// Update overwrites the given fields, every one of RecordFields when
+	// empty, of the record r.ID owned by r.UserId and reads the stored
+	// record back into r. It returns ErrNotFound when the user has no such
+	// record and ErrVersionMismatch when r.Version is set and the stored
+	// record has another version. The TransferFields of a transfer record
+	// are written to the other side of the transfer too.
+	Update(ctx context.Context, r *Record, fields []string) error
+	// Delete moves the user's record to the trash, along with the other
+	// side of a transfer, or returns ErrNotFound. A non-zero version must
+	// match the stored one or ErrVersionMismatch is returned.
+	Delete(ctx context.Context, userId, id primitive.ObjectID, version int64) error
+	// UpdateMany applies every update as Update does, or none of them, and
+	// reads the stored records back into the updates. The error of an item
+	// is a BatchItemError, two updates cannot write the same record.
+	UpdateMany(ctx context.Context, updates []RecordUpdate) error
+	// DeleteMany deletes the user's records as Delete does, every one of
+	// them or none. The error of an item is a BatchItemError.
+	DeleteMany(ctx context.Context, userId primitive.ObjectID, deletes []RecordDelete) error
+	// GetUserRecords returns the page of the user's records selected by q.
+	GetUserRecords(ctx context.Context, q RecordQuery) (RecordPage, error)
+}
 
-func (r *Record) New() error {
-	if err := typeCheck(r.Type); err != nil {
-		return err
-	}
-	payload := bson.M{
-		"user_id":     r.UserId,
-		"type":        r.Type,
-		"date":        r.Date,
-		"title":       r.Title,
-		"description": r.Description,
-		"amount":      r.Amount,
-		"create_at":   time.Now().UTC().String(),
-		"updated_at":  time.Now().UTC().String(),
-	}
-	if result, err := RecordsColl.InsertOne(context.TODO(), payload); err != nil {
-		return err
-	} else {
-		r.ID = result.InsertedID.(primitive.ObjectID)
-		return nil
-	}
+// RecordFields are the fields of a record an update can change, named as
+// in the API and the stores.
+var RecordFields = []string{"type", "date", "title", "description", "amount", "category_id", "tags", "account_id"}
 
+// Validate checks the fields every store requires before writing a record
+// and normalizes its date to the precision stores keep.
+func (r *Record) Validate() error {
+	return r.ValidateFields(RecordFields)
 }
 
-func (r *Record) Get(id string) error {
-	if Id, err := primitive.ObjectIDFromHex(id); err != nil {
-		return err
-	} else {
-		err = RecordsColl.FindOne(context.TODO(), bson.M{"_id": Id}).Decode(r)
-		return err
+// ValidateFields is Validate restricted to the fields a partial update writes.
+func (r *Record) ValidateFields(fields []string) error {
+	v := Violations{}
+	for _, field := range fields {
+		switch field {
+		case "type":
+			v.Add("type", TypeCheck(r.Type))
+		case "date":
+			r.Date = NormalizeTime(r.Date)
+			v.Add("date", checkDate(r.Date))
+		case "amount":
+			v.Add("amount.currency_code", r.Amount.Validate())
+			if r.Amount.Units < 0 {
+				v.Add("amount.units", fmt.Errorf("amount should not be negative, use the record type instead"))
+			}
+		case "tags":
+			tags, err := NormalizeTags(r.Tags)
+			v.Add("tags", err)
+			r.Tags = tags
+		case "title", "description", "category_id", "account_id":
+		default:
+			v.Add(field, fmt.Errorf("'%s' is not an updatable record field", field))
+		}
 	}
+	return v.Err()
 }
 
-func (r *Record) Update() error {
-	if err := typeCheck(r.Type); err != nil {
-		return err
-	}
-	payload := bson.M{
-		"$set": bson.M{
-			"title":       r.Title,
-			"amount":      r.Amount,
-			"description": r.Description,
-			"date":        r.Date,
-			"type":        r.Type,
-			"updated_at":  time.Now().UTC().String(),
-		},
+// ApplyFields copies the given fields of src into r.
+func (r *Record) ApplyFields(src Record, fields []string) {
+	for _, field := range fields {
+		switch field {
+		case "title":
+			r.Title = src.Title
+		case "amount":
+			r.Amount = src.Amount
+		case "description":
+			r.Description = src.Description
+		case "date":
+			r.Date = src.Date
+		case "type":
+			r.Type = src.Type
+		case "category_id":
+			r.CategoryId = src.CategoryId
+		case "tags":
+			r.Tags = src.Tags
+		case "account_id":
+			r.AccountId = src.AccountId
+		}
 	}
-	_, err := RecordsColl.UpdateByID(context.TODO(), r.ID, payload)
-	return err
 }
 
-func (r *Record) Delete(id string) error {
-	if ID, err := primitive.ObjectIDFromHex(id); err != nil {
-		return err
-	} else {
-		_, err = RecordsColl.DeleteOne(context.TODO(), bson.M{"_id": ID})
-		return err
-	}
+// RecordRefs looks up the categories and accounts records refer to.
+type RecordRefs struct {
+	Category func(userId, id primitive.ObjectID) (Category, error)
+	Account  func(userId, id primitive.ObjectID) (Account, error)
 }
 
-func GetUserRecords(userId string, recordType string, pageIdx int32) ([]Record, error) {
-	rl := []Record{}
-
-	objUserId, err := primitive.ObjectIDFromHex(userId)
-	if err != nil {
-		return rl, err
+// Check verifies the category and account of r belong to its owner, the
+// category accepts its type and the account holds its currency.
+func (refs RecordRefs) Check(r Record) error {
+	v := Violations{}
+	if !r.CategoryId.IsZero() {
+		c, err := refs.Category(r.UserId, r.CategoryId)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			v.Add("category_id", fmt.Errorf("category '%s' does not exist", r.CategoryId.Hex()))
+		case err != nil:
+			return err
+		case !c.Accepts(r.Type):
+			v.Add("category_id", fmt.Errorf("category '%s' only accepts %s records", c.Name, c.Type))
+		}
 	}
-
-	if err := typeCheck(recordType); err != nil {
-		return rl, err
+	if !r.AccountId.IsZero() {
+		a, err := refs.Account(r.UserId, r.AccountId)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			v.Add("account_id", fmt.Errorf("account '%s' does not exist", r.AccountId.Hex()))
+		case err != nil:
+			return err
+		case a.Currency != r.Amount.Currency:
+			v.Add("account_id", fmt.Errorf("account '%s' holds %s, not %s", a.Name, a.Currency, r.Amount.Currency))
+		}
 	}
+	return v.Err()
+}
 
-	filter := bson.M{"user_id": objUserId}
-	opts := options.Find().SetLimit(RECORDS_PER_PAGE).SetSkip(RECORDS_PER_PAGE * int64(pageIdx))
-	cursor, err := RecordsColl.Find(context.TODO(), filter, opts)
-	if err != nil {
-		return nil, err
+// RefsChanged reports whether an update of the given record fields needs
+// its references checked again.
+func RefsChanged(fields []string) bool {
+	for _, field := range fields {
+		switch field {
+		case "type", "category_id", "amount", "account_id":
+			return true
+		}
 	}
-	defer cursor.Close(context.TODO())
+	return false
+}
 
-	if err = cursor.All(context.TODO(), &rl); err != nil {
-		return nil, err
+// UpdateFields defaults an empty field list of an update to every field.
+func UpdateFields(fields []string) []string {
+	if len(fields) == 0 {
+		return RecordFields
 	}
-	return rl, nil
+	return fields
 }
 
-func typeCheck(t string) error {
+// TypeCheck validates a record type; shared by every RecordStore implementation.
+func TypeCheck(t string) error {
 	if t != "EXPENSE" && t != "INCOME" {
 		return fmt.Errorf("type should be either 'EXPENSE' or 'INCOME'")
 	}
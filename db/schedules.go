@@ -0,0 +1,377 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Frequencies are the units a schedule repeats in.
+var Frequencies = []string{"DAILY", "WEEKLY", "MONTHLY", "YEARLY"}
+
+const maxScheduleInterval = 1000
+
+// Schedule creates a record on every occurrence of an RRULE-like rule:
+// every Interval days, weeks, months or years from Start, until the
+// Until date or Count occurrences. Monthly and yearly occurrences falling
+// on a day the month does not have move to its last day.
+type Schedule struct {
+	ID     primitive.ObjectID `bson:"_id" json:"_id"`
+	UserId primitive.ObjectID `bson:"user_id" json:"user_id"`
+	// the fields of the records created, as in Record
+	Type        string             `bson:"type" json:"type"`
+	Title       string             `bson:"title" json:"title"`
+	Description string             `bson:"description" json:"description"`
+	Amount      Money              `bson:"amount" json:"amount"`
+	CategoryId  primitive.ObjectID `bson:"category_id,omitempty" json:"category_id"`
+	Tags        []string           `bson:"tags" json:"tags"`
+	AccountId   primitive.ObjectID `bson:"account_id,omitempty" json:"account_id"`
+
+	Frequency string `bson:"frequency" json:"frequency"`
+	Interval  int    `bson:"interval" json:"interval"`
+	// Start is the first occurrence
+	Start time.Time `bson:"start" json:"start"`
+	// Until is the last date an occurrence can fall on, zero for none
+	Until time.Time `bson:"until,omitempty" json:"until"`
+	// Count caps the number of occurrences, zero for none
+	Count      int                   `bson:"count" json:"count"`
+	Exceptions []OccurrenceException `bson:"exceptions" json:"exceptions"`
+
+	// Materialized is the number of occurrences handled by Materialize
+	// and NextDate the date of the next one, zero once the schedule ended.
+	Materialized int       `bson:"materialized" json:"materialized"`
+	NextDate     time.Time `bson:"next_date,omitempty" json:"next_date"`
+	// Version is incremented by every write so the materializer and
+	// updates never overwrite each other.
+	Version   int64     `bson:"version" json:"version"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// OccurrenceException skips or changes the record of a single occurrence.
+type OccurrenceException struct {
+	Date time.Time `bson:"date" json:"date"`
+	Skip bool      `bson:"skip" json:"skip"`
+	// Amount, Title and Description replace the ones of the schedule when set
+	Amount      *Money `bson:"amount,omitempty" json:"amount,omitempty"`
+	Title       string `bson:"title,omitempty" json:"title,omitempty"`
+	Description string `bson:"description,omitempty" json:"description,omitempty"`
+}
+
+// ScheduleStore persists recurring schedules, Materialize turns their
+// occurrences into records.
+type ScheduleStore interface {
+	// CreateSchedule inserts s, setting its ID and progress.
+	CreateSchedule(ctx context.Context, s *Schedule) error
+	// GetSchedule returns the user's schedule with the given id or ErrNotFound.
+	GetSchedule(ctx context.Context, userId, id primitive.ObjectID) (Schedule, error)
+	// UpdateSchedule overwrites the given fields, every one of
+	// ScheduleFields when empty, of the schedule s.ID owned by s.UserId and
+	// reads the stored schedule back into s.
+	UpdateSchedule(ctx context.Context, s *Schedule, fields []string) error
+	// ListSchedules returns every schedule of the user ordered by title.
+	ListSchedules(ctx context.Context, userId primitive.ObjectID) ([]Schedule, error)
+	// DeleteSchedule removes the user's schedule, the records it created
+	// are kept.
+	DeleteSchedule(ctx context.Context, userId, id primitive.ObjectID) error
+	// DueSchedules returns the schedules of every user with an occurrence
+	// due at or before now.
+	DueSchedules(ctx context.Context, now time.Time) ([]Schedule, error)
+	// AdvanceSchedule stores the progress of s once its occurrences before
+	// materialized were handled. It returns ErrVersionMismatch when the
+	// schedule changed since s was read.
+	AdvanceSchedule(ctx context.Context, s Schedule, materialized int) error
+}
+
+// ErrScheduleNotFound is the ErrNotFound of schedule lookups.
+var ErrScheduleNotFound = fmt.Errorf("%w: no such schedule", ErrNotFound)
+
+// ScheduleFields are the fields of a schedule an update can change.
+var ScheduleFields = []string{
+	"type", "title", "description", "amount", "category_id", "tags", "account_id",
+	"frequency", "interval", "start_date", "until_date", "count",
+}
+
+// scheduleRuleFields are the fields changing when the occurrences fall.
+var scheduleRuleFields = []string{"frequency", "interval", "start_date", "until_date", "count"}
+
+// Validate checks the fields of a schedule being created.
+func (s *Schedule) Validate() error {
+	return s.ValidateFields(ScheduleFields)
+}
+
+// ValidateFields is Validate restricted to the fields a partial update
+// writes, the exceptions only change through SetException.
+func (s *Schedule) ValidateFields(fields []string) error {
+	v := Violations{}
+	recordFields := []string{}
+	for _, field := range fields {
+		switch field {
+		case "type", "title", "description", "amount", "category_id", "tags", "account_id":
+			recordFields = append(recordFields, field)
+		case "frequency":
+			if !containsString(Frequencies, s.Frequency) {
+				v.Add("frequency", fmt.Errorf("frequency should be one of %s", strings.Join(Frequencies, ", ")))
+			}
+		case "interval":
+			if s.Interval == 0 {
+				s.Interval = 1
+			}
+			if s.Interval < 0 || s.Interval > maxScheduleInterval {
+				v.Add("interval", fmt.Errorf("interval should be between 1 and %d", maxScheduleInterval))
+			}
+		case "start_date":
+			s.Start = NormalizeTime(s.Start)
+			v.Add("start_date", checkDate(s.Start))
+		case "until_date":
+			if !s.Until.IsZero() {
+				s.Until = NormalizeTime(s.Until)
+				v.Add("until_date", checkDate(s.Until))
+			}
+		case "count":
+			if s.Count < 0 {
+				v.Add("count", fmt.Errorf("count should not be negative"))
+			}
+		case "exceptions":
+		default:
+			v.Add(field, fmt.Errorf("'%s' is not an updatable schedule field", field))
+		}
+	}
+	template := s.template()
+	if err, ok := template.ValidateFields(recordFields).(*ValidationError); ok {
+		v = append(v, err.Violations...)
+	}
+	s.Tags = template.Tags
+	return v.Err()
+}
+
+// Check verifies the rule of a complete schedule and the references of
+// its records.
+func (s Schedule) Check(refs RecordRefs) error {
+	if !s.Until.IsZero() && s.Until.Before(s.Start) {
+		return InvalidField("until_date", fmt.Errorf("until date should not be before the start date"))
+	}
+	return refs.Check(s.template())
+}
+
+// ScheduleUpdateAttempts bounds the read-modify-write retries of an
+// update racing with the materializer.
+const ScheduleUpdateAttempts = 3
+
+// MergeScheduleUpdate applies the given fields of sc to the stored
+// schedule, checks the result and bumps its version.
+func MergeScheduleUpdate(stored, sc Schedule, fields []string, refs RecordRefs) (Schedule, error) {
+	merged := stored
+	merged.ApplyFields(sc, fields)
+	if err := merged.Check(refs); err != nil {
+		return merged, err
+	}
+	merged.UpdatedAt = Now()
+	merged.Version++
+	return merged, nil
+}
+
+// UpdateScheduleFields defaults an empty field list of an update to every field.
+func UpdateScheduleFields(fields []string) []string {
+	if len(fields) == 0 {
+		return ScheduleFields
+	}
+	return fields
+}
+
+// ApplyFields copies the given fields of src into s. When the rule
+// changes, the progress of s moves past the occurrences the previous
+// rule already materialized.
+func (s *Schedule) ApplyFields(src Schedule, fields []string) {
+	old := *s
+	for _, field := range fields {
+		switch field {
+		case "type":
+			s.Type = src.Type
+		case "title":
+			s.Title = src.Title
+		case "description":
+			s.Description = src.Description
+		case "amount":
+			s.Amount = src.Amount
+		case "category_id":
+			s.CategoryId = src.CategoryId
+		case "tags":
+			s.Tags = src.Tags
+		case "account_id":
+			s.AccountId = src.AccountId
+		case "frequency":
+			s.Frequency = src.Frequency
+		case "interval":
+			s.Interval = src.Interval
+		case "start_date":
+			s.Start = src.Start
+		case "until_date":
+			s.Until = src.Until
+		case "count":
+			s.Count = src.Count
+		case "exceptions":
+			s.Exceptions = src.Exceptions
+		}
+	}
+	for _, field := range fields {
+		if containsString(scheduleRuleFields, field) {
+			s.rebase(old)
+			return
+		}
+	}
+}
+
+// Begin sets the progress of a new schedule.
+func (s *Schedule) Begin() {
+	s.Materialized = 0
+	s.NextDate, _ = s.Occurrence(0)
+	if s.Exceptions == nil {
+		s.Exceptions = []OccurrenceException{}
+	}
+}
+
+// Occurrence returns the date of the nth occurrence, counting from 0,
+// and false when the schedule ends before it.
+func (s Schedule) Occurrence(n int) (time.Time, bool) {
+	date := s.at(n)
+	if (s.Count > 0 && n >= s.Count) || (!s.Until.IsZero() && date.After(s.Until)) || !date.Before(maxRecordDate) {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// Record returns the record of the nth occurrence on date, with its
+// exception applied, and false when the occurrence is skipped.
+func (s Schedule) Record(n int, date time.Time) (Record, bool) {
+	r := s.template()
+	r.Date = date
+	r.ScheduleId = s.ID
+	r.Occurrence = date
+	for _, e := range s.Exceptions {
+		if !e.Date.Equal(date) {
+			continue
+		}
+		if e.Skip {
+			return r, false
+		}
+		if e.Amount != nil {
+			r.Amount = *e.Amount
+		}
+		if e.Title != "" {
+			r.Title = e.Title
+		}
+		if e.Description != "" {
+			r.Description = e.Description
+		}
+	}
+	return r, true
+}
+
+// SetException skips or changes a single occurrence still to be
+// materialized, replacing any previous exception of the occurrence. An
+// exception changing nothing removes it.
+func (s *Schedule) SetException(e OccurrenceException) error {
+	e.Date = NormalizeTime(e.Date)
+	n, ok := s.occurrenceIndex(e.Date)
+	if !ok {
+		return InvalidField("occurrence.date", fmt.Errorf("%s is not an occurrence of the schedule", e.Date.Format(time.RFC3339)))
+	}
+	if n < s.Materialized {
+		return fmt.Errorf("%w: the occurrence of %s was already recorded, change its record instead", ErrFailedPrecondition, e.Date.Format(time.DateOnly))
+	}
+	if e.Amount != nil {
+		v := Violations{}
+		if e.Amount.Currency != s.Amount.Currency {
+			v.Add("occurrence.amount.currency_code", fmt.Errorf("the occurrences of the schedule are in %s", s.Amount.Currency))
+		}
+		if e.Amount.Units < 0 {
+			v.Add("occurrence.amount.units", fmt.Errorf("amount should not be negative, use the record type instead"))
+		}
+		if err := v.Err(); err != nil {
+			return err
+		}
+	}
+	exceptions := []OccurrenceException{}
+	for _, other := range s.Exceptions {
+		if !other.Date.Equal(e.Date) {
+			exceptions = append(exceptions, other)
+		}
+	}
+	if e.Skip || e.Amount != nil || e.Title != "" || e.Description != "" {
+		exceptions = append(exceptions, e)
+	}
+	s.Exceptions = exceptions
+	return nil
+}
+
+// template is the record every occurrence starts from.
+func (s Schedule) template() Record {
+	return Record{
+		UserId:      s.UserId,
+		Type:        s.Type,
+		Title:       s.Title,
+		Description: s.Description,
+		Amount:      s.Amount,
+		CategoryId:  s.CategoryId,
+		Tags:        s.Tags,
+		AccountId:   s.AccountId,
+	}
+}
+
+// at is the date of the nth occurrence ignoring the end of the schedule.
+func (s Schedule) at(n int) time.Time {
+	k := n * s.Interval
+	switch s.Frequency {
+	case "WEEKLY":
+		return s.Start.AddDate(0, 0, 7*k)
+	case "MONTHLY":
+		return addMonths(s.Start, k)
+	case "YEARLY":
+		return addMonths(s.Start, 12*k)
+	}
+	return s.Start.AddDate(0, 0, k)
+}
+
+// occurrenceIndex finds the occurrence on date.
+func (s Schedule) occurrenceIndex(date time.Time) (int, bool) {
+	for n := 0; ; n++ {
+		d, ok := s.Occurrence(n)
+		if !ok || d.After(date) {
+			return 0, false
+		}
+		if d.Equal(date) {
+			return n, true
+		}
+	}
+}
+
+// rebase skips the occurrences of s up to the last one old materialized.
+func (s *Schedule) rebase(old Schedule) {
+	s.Materialized = 0
+	if old.Materialized > 0 {
+		last := old.at(old.Materialized - 1)
+		for {
+			d, ok := s.Occurrence(s.Materialized)
+			if !ok || d.After(last) {
+				break
+			}
+			s.Materialized++
+		}
+	}
+	s.NextDate, _ = s.Occurrence(s.Materialized)
+}
+
+// addMonths adds months to t, moving past the end of shorter months back
+// to their last day.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := t.Day()
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
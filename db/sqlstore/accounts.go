@@ -0,0 +1,151 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const accountColumns = `id, user_id, name, kind, currency, opening_balance, created_at, updated_at`
+
+func (s *Store) CreateAccount(ctx context.Context, a *db.Account) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	now := db.Now()
+	id := primitive.NewObjectID()
+	_, err := s.exec(ctx, `INSERT INTO accounts (`+accountColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		id.Hex(), a.UserId.Hex(), a.Name, a.Kind, a.Currency, a.OpeningBalance, now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return err
+	}
+	a.ID = id
+	a.CreatedAt = now
+	a.UpdatedAt = now
+	return nil
+}
+
+func (s *Store) GetAccount(ctx context.Context, userId, id primitive.ObjectID) (db.Account, error) {
+	a, err := scanAccount(s.queryRow(ctx, `SELECT `+accountColumns+` FROM accounts WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	if err == sql.ErrNoRows {
+		return a, db.ErrAccountNotFound
+	}
+	return a, sqlError(err)
+}
+
+func (s *Store) UpdateAccount(ctx context.Context, a *db.Account, fields []string) error {
+	fields = db.UpdateAccountFields(fields)
+	if err := a.ValidateFields(fields); err != nil {
+		return err
+	}
+	stored, err := s.GetAccount(ctx, a.UserId, a.ID)
+	if err != nil {
+		return err
+	}
+	updated := stored
+	updated.ApplyFields(*a, fields)
+	if updated.Currency != stored.Currency {
+		if err := s.checkAccountUnused(ctx, a.UserId, a.ID); err != nil {
+			return err
+		}
+	}
+	updated.UpdatedAt = db.Now()
+	result, err := s.exec(ctx, `UPDATE accounts SET name = ?, kind = ?, currency = ?, opening_balance = ?, updated_at = ? WHERE id = ? AND user_id = ?`,
+		updated.Name, updated.Kind, updated.Currency, updated.OpeningBalance, updated.UpdatedAt.UnixMilli(), a.ID.Hex(), a.UserId.Hex())
+	if err := checkAffected(result, err); err != nil {
+		return notFound(err, db.ErrAccountNotFound)
+	}
+	*a = updated
+	return nil
+}
+
+func (s *Store) ListAccounts(ctx context.Context, userId primitive.ObjectID) ([]db.Account, error) {
+	rows, err := s.query(ctx, `SELECT `+accountColumns+` FROM accounts WHERE user_id = ? ORDER BY name, id`, userId.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []db.Account{}
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, sqlError(rows.Err())
+}
+
+func (s *Store) DeleteAccount(ctx context.Context, userId, id primitive.ObjectID) error {
+	if err := s.checkAccountUnused(ctx, userId, id); err != nil {
+		return err
+	}
+	err := checkAffected(s.exec(ctx, `DELETE FROM accounts WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	return notFound(err, db.ErrAccountNotFound)
+}
+
+func (s *Store) AccountTotals(ctx context.Context, userId primitive.ObjectID, before time.Time) (map[primitive.ObjectID]db.AccountTotals, error) {
+	where := `user_id = ? AND account_id IS NOT NULL`
+	args := []any{userId.Hex()}
+	if !before.IsZero() {
+		where += ` AND date < ?`
+		args = append(args, before.UnixMilli())
+	}
+	rows, err := s.query(ctx, `SELECT account_id, type, transfer_id IS NOT NULL, SUM(amount) FROM records WHERE `+where+`
+		GROUP BY account_id, type, transfer_id IS NOT NULL`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := map[primitive.ObjectID]db.AccountTotals{}
+	for rows.Next() {
+		var accountId, recordType string
+		var transfer bool
+		var total int64
+		if err := rows.Scan(&accountId, &recordType, &transfer, &total); err != nil {
+			return nil, err
+		}
+		id, err := primitive.ObjectIDFromHex(accountId)
+		if err != nil {
+			return nil, err
+		}
+		t := totals[id]
+		t.AddRecord(recordType, transfer, total)
+		totals[id] = t
+	}
+	return totals, sqlError(rows.Err())
+}
+
+func (s *Store) checkAccountUnused(ctx context.Context, userId, id primitive.ObjectID) error {
+	var used bool
+	err := s.queryRow(ctx, `SELECT EXISTS (SELECT 1 FROM records WHERE user_id = ? AND account_id = ?)`, userId.Hex(), id.Hex()).Scan(&used)
+	if err != nil {
+		return sqlError(err)
+	}
+	if used {
+		return db.ErrAccountInUse
+	}
+	return nil
+}
+
+func scanAccount(row scanner) (db.Account, error) {
+	var a db.Account
+	var id, userId string
+	var createdAt, updatedAt int64
+	err := row.Scan(&id, &userId, &a.Name, &a.Kind, &a.Currency, &a.OpeningBalance, &createdAt, &updatedAt)
+	if err != nil {
+		return a, err
+	}
+	a.CreatedAt = time.UnixMilli(createdAt).UTC()
+	a.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	if a.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return a, err
+	}
+	a.UserId, err = primitive.ObjectIDFromHex(userId)
+	return a, err
+}
@@ -0,0 +1,112 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const budgetColumns = `id, user_id, name, category_id, tag, limit_amount, currency, period, start_date, rollover, created_at, updated_at`
+
+func (s *Store) CreateBudget(ctx context.Context, b *db.Budget) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	if err := b.Check(s.recordRefs(ctx)); err != nil {
+		return err
+	}
+	now := db.Now()
+	id := primitive.NewObjectID()
+	_, err := s.exec(ctx, `INSERT INTO budgets (`+budgetColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id.Hex(), b.UserId.Hex(), b.Name, nullId(b.CategoryId), b.Tag, b.Limit.Units, b.Limit.Currency, b.Period, b.Start.UnixMilli(), b.Rollover,
+		now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return err
+	}
+	b.ID = id
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	return nil
+}
+
+func (s *Store) GetBudget(ctx context.Context, userId, id primitive.ObjectID) (db.Budget, error) {
+	b, err := scanBudget(s.queryRow(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	if err == sql.ErrNoRows {
+		return b, db.ErrBudgetNotFound
+	}
+	return b, sqlError(err)
+}
+
+func (s *Store) UpdateBudget(ctx context.Context, b *db.Budget, fields []string) error {
+	fields = db.UpdateBudgetFields(fields)
+	if err := b.ValidateFields(fields); err != nil {
+		return err
+	}
+	stored, err := s.GetBudget(ctx, b.UserId, b.ID)
+	if err != nil {
+		return err
+	}
+	updated := stored
+	updated.ApplyFields(*b, fields)
+	if err := updated.Check(s.recordRefs(ctx)); err != nil {
+		return err
+	}
+	updated.UpdatedAt = db.Now()
+	result, err := s.exec(ctx, `UPDATE budgets SET name = ?, category_id = ?, tag = ?, limit_amount = ?, currency = ?, period = ?, start_date = ?, rollover = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?`,
+		updated.Name, nullId(updated.CategoryId), updated.Tag, updated.Limit.Units, updated.Limit.Currency, updated.Period, updated.Start.UnixMilli(), updated.Rollover,
+		updated.UpdatedAt.UnixMilli(), b.ID.Hex(), b.UserId.Hex())
+	if err := checkAffected(result, err); err != nil {
+		return notFound(err, db.ErrBudgetNotFound)
+	}
+	*b = updated
+	return nil
+}
+
+func (s *Store) ListBudgets(ctx context.Context, userId primitive.ObjectID) ([]db.Budget, error) {
+	rows, err := s.query(ctx, `SELECT `+budgetColumns+` FROM budgets WHERE user_id = ? ORDER BY name, id`, userId.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []db.Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, sqlError(rows.Err())
+}
+
+func (s *Store) DeleteBudget(ctx context.Context, userId, id primitive.ObjectID) error {
+	err := checkAffected(s.exec(ctx, `DELETE FROM budgets WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	return notFound(err, db.ErrBudgetNotFound)
+}
+
+func scanBudget(row scanner) (db.Budget, error) {
+	var b db.Budget
+	var id, userId string
+	var categoryId sql.NullString
+	var start, createdAt, updatedAt int64
+	err := row.Scan(&id, &userId, &b.Name, &categoryId, &b.Tag, &b.Limit.Units, &b.Limit.Currency, &b.Period, &start, &b.Rollover, &createdAt, &updatedAt)
+	if err != nil {
+		return b, err
+	}
+	b.Start = time.UnixMilli(start).UTC()
+	b.CreatedAt = time.UnixMilli(createdAt).UTC()
+	b.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	if b.CategoryId, err = parseNullId(categoryId); err != nil {
+		return b, err
+	}
+	if b.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return b, err
+	}
+	b.UserId, err = primitive.ObjectIDFromHex(userId)
+	return b, err
+}
@@ -0,0 +1,172 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const categoryColumns = `id, user_id, parent_id, name, icon, color, type, created_at, updated_at`
+
+func (s *Store) CreateCategory(ctx context.Context, c *db.Category) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	categories, err := s.ListCategories(ctx, c.UserId)
+	if err != nil {
+		return err
+	}
+	c.ID = primitive.NilObjectID
+	if err := db.NewCategoryTree(categories).CheckPlacement(c); err != nil {
+		return err
+	}
+	now := db.Now()
+	id := primitive.NewObjectID()
+	_, err = s.exec(ctx, `INSERT INTO categories (`+categoryColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id.Hex(), c.UserId.Hex(), nullId(c.ParentId), c.Name, c.Icon, c.Color, c.Type, now.UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return err
+	}
+	c.ID = id
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+func (s *Store) GetCategory(ctx context.Context, userId, id primitive.ObjectID) (db.Category, error) {
+	c, err := scanCategory(s.queryRow(ctx, `SELECT `+categoryColumns+` FROM categories WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	if err == sql.ErrNoRows {
+		return c, db.ErrCategoryNotFound
+	}
+	return c, sqlError(err)
+}
+
+func (s *Store) UpdateCategory(ctx context.Context, c *db.Category, fields []string) error {
+	fields = db.UpdateCategoryFields(fields)
+	if err := c.ValidateFields(fields); err != nil {
+		return err
+	}
+	stored, err := s.GetCategory(ctx, c.UserId, c.ID)
+	if err != nil {
+		return err
+	}
+	categories, err := s.ListCategories(ctx, c.UserId)
+	if err != nil {
+		return err
+	}
+	updated, err := db.MergeCategoryUpdate(stored, *c, fields, categories)
+	if err != nil {
+		return err
+	}
+	if updated.Type != "" && updated.Type != stored.Type {
+		var n int64
+		err := s.queryRow(ctx, `SELECT COUNT(*) FROM records WHERE user_id = ? AND category_id = ? AND type <> ?`,
+			c.UserId.Hex(), c.ID.Hex(), updated.Type).Scan(&n)
+		if err != nil {
+			return sqlError(err)
+		}
+		if n > 0 {
+			return fmt.Errorf("%w: category has records other than %s", db.ErrFailedPrecondition, updated.Type)
+		}
+	}
+	updated.UpdatedAt = db.Now()
+	_, err = s.exec(ctx, `UPDATE categories SET parent_id = ?, name = ?, icon = ?, color = ?, type = ?, updated_at = ? WHERE id = ? AND user_id = ?`,
+		nullId(updated.ParentId), updated.Name, updated.Icon, updated.Color, updated.Type, updated.UpdatedAt.UnixMilli(), c.ID.Hex(), c.UserId.Hex())
+	if err != nil {
+		return err
+	}
+	*c = updated
+	return nil
+}
+
+func (s *Store) ListCategories(ctx context.Context, userId primitive.ObjectID) ([]db.Category, error) {
+	rows, err := s.query(ctx, `SELECT `+categoryColumns+` FROM categories WHERE user_id = ? ORDER BY name, id`, userId.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []db.Category{}
+	for rows.Next() {
+		c, err := scanCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, sqlError(rows.Err())
+}
+
+func (s *Store) DeleteCategory(ctx context.Context, userId, id, moveTo primitive.ObjectID) error {
+	categories, err := s.ListCategories(ctx, userId)
+	if err != nil {
+		return err
+	}
+	deleted, err := db.NewCategoryTree(categories).CheckMove(id, moveTo)
+	if err != nil {
+		return err
+	}
+	parent := moveTo
+	if parent.IsZero() {
+		parent = deleted.ParentId
+	}
+	revisedAt := db.Now()
+	now := revisedAt.UnixMilli()
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		moved, err := s.txRecords(ctx, tx, `user_id = ? AND category_id = ?`, userId.Hex(), id.Hex())
+		if err != nil {
+			return err
+		}
+		if _, err := s.txExec(ctx, tx, `UPDATE records SET category_id = ?, updated_at = ?, version = version + 1 WHERE user_id = ? AND category_id = ?`,
+			nullId(moveTo), now, userId.Hex(), id.Hex()); err != nil {
+			return err
+		}
+		for _, r := range moved {
+			updated := r
+			updated.CategoryId = moveTo
+			if err := s.insertRevision(ctx, tx, db.NewRevision(ctx, db.RevisionUpdated, r, revised(updated, revisedAt), revisedAt)); err != nil {
+				return err
+			}
+		}
+		// budgets follow the records, there is nothing left to track without
+		budgets, args := `DELETE FROM budgets WHERE user_id = ? AND category_id = ?`, []any{userId.Hex(), id.Hex()}
+		if !moveTo.IsZero() {
+			budgets = `UPDATE budgets SET category_id = ?, updated_at = ? WHERE user_id = ? AND category_id = ?`
+			args = append([]any{moveTo.Hex(), now}, args...)
+		}
+		if _, err := s.txExec(ctx, tx, budgets, args...); err != nil {
+			return err
+		}
+		if _, err := s.txExec(ctx, tx, `UPDATE categories SET parent_id = ?, updated_at = ? WHERE user_id = ? AND parent_id = ?`,
+			nullId(parent), now, userId.Hex(), id.Hex()); err != nil {
+			return err
+		}
+		result, err := s.txExec(ctx, tx, `DELETE FROM categories WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex())
+		return notFound(checkAffected(result, err), db.ErrCategoryNotFound)
+	})
+}
+
+func scanCategory(row scanner) (db.Category, error) {
+	var c db.Category
+	var id, userId string
+	var parentId sql.NullString
+	var createdAt, updatedAt int64
+	err := row.Scan(&id, &userId, &parentId, &c.Name, &c.Icon, &c.Color, &c.Type, &createdAt, &updatedAt)
+	if err != nil {
+		return c, err
+	}
+	c.CreatedAt = time.UnixMilli(createdAt).UTC()
+	c.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	if c.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return c, err
+	}
+	if c.UserId, err = primitive.ObjectIDFromHex(userId); err != nil {
+		return c, err
+	}
+	c.ParentId, err = parseNullId(parentId)
+	return c, err
+}
@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) GetRecordHistory(ctx context.Context, userId, recordId primitive.ObjectID) ([]db.Revision, error) {
+	rows, err := s.query(ctx, `SELECT id, action, actor_id, user_agent, address, changes, record, created_at FROM record_history
+		WHERE user_id = ? AND record_id = ? ORDER BY created_at, id`, userId.Hex(), recordId.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []db.Revision{}
+	for rows.Next() {
+		rev := db.Revision{UserId: userId, RecordId: recordId}
+		var id, changes, record string
+		var actorId sql.NullString
+		var createdAt int64
+		if err := rows.Scan(&id, &rev.Action, &actorId, &rev.Actor.UserAgent, &rev.Actor.Address, &changes, &record, &createdAt); err != nil {
+			return nil, err
+		}
+		if rev.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+			return nil, err
+		}
+		if rev.Actor.UserId, err = parseNullId(actorId); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &rev.Changes); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(record), &rev.Record); err != nil {
+			return nil, err
+		}
+		rev.CreatedAt = time.UnixMilli(createdAt).UTC()
+		revisions = append(revisions, rev)
+	}
+	return revisions, sqlError(rows.Err())
+}
+
+// txRecords reads the records matching where, with their tags, in tx for
+// the revisions of a bulk update.
+func (s *Store) txRecords(ctx context.Context, tx *sql.Tx, where string, args ...any) ([]db.Record, error) {
+	rows, err := tx.QueryContext(ctx, s.rebind(`SELECT `+recordColumns+` FROM records WHERE `+where+` ORDER BY id`), args...)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	records := []db.Record{}
+	index := map[string]int{}
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		r.Tags = []string{}
+		index[r.ID.Hex()] = len(records)
+		records = append(records, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, sqlError(err)
+	}
+
+	rows, err = tx.QueryContext(ctx, s.rebind(`SELECT record_id, tag FROM record_tags
+		WHERE record_id IN (SELECT id FROM records WHERE `+where+`) ORDER BY record_id, tag`), args...)
+	if err != nil {
+		return nil, sqlError(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			records[i].Tags = append(records[i].Tags, tag)
+		}
+	}
+	return records, sqlError(rows.Err())
+}
+
+// insertRevision appends rev to the history in the transaction of its write.
+func (s *Store) insertRevision(ctx context.Context, tx *sql.Tx, rev db.Revision) error {
+	changes, err := json.Marshal(rev.Changes)
+	if err != nil {
+		return err
+	}
+	record, err := json.Marshal(rev.Record)
+	if err != nil {
+		return err
+	}
+	_, err = s.txExec(ctx, tx, `INSERT INTO record_history (id, user_id, record_id, action, actor_id, user_agent, address, changes, record, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rev.ID.Hex(), rev.UserId.Hex(), rev.RecordId.Hex(), rev.Action, nullId(rev.Actor.UserId), rev.Actor.UserAgent, rev.Actor.Address,
+		string(changes), string(record), rev.CreatedAt.UnixMilli())
+	return err
+}
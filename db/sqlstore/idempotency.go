@@ -0,0 +1,59 @@
+package sqlstore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+func (s *Store) ReserveKey(ctx context.Context, k db.IdempotencyKey) (db.IdempotencyKey, error) {
+	// a key that is Free is replaced, the primary key keeps a single
+	// reservation when two calls race for it
+	now := k.CreatedAt.UnixMilli()
+	_, err := s.exec(ctx, `DELETE FROM idempotency_keys WHERE user_id = ? AND key = ?
+		AND (expires_at <= ? OR (response IS NULL AND locked_until <= ?))`, k.UserId.Hex(), k.Key, now, now)
+	if err != nil {
+		return db.IdempotencyKey{}, err
+	}
+	_, err = s.exec(ctx, `INSERT INTO idempotency_keys (user_id, key, request_hash, created_at, expires_at, locked_until) VALUES (?, ?, ?, ?, ?, ?)`,
+		k.UserId.Hex(), k.Key, k.RequestHash, now, k.ExpiresAt.UnixMilli(), k.LockedUntil.UnixMilli())
+	if !errors.Is(err, db.ErrAlreadyExists) {
+		return k, err
+	}
+	stored := db.IdempotencyKey{UserId: k.UserId, Key: k.Key}
+	var createdAt, expiresAt, lockedUntil int64
+	err = s.queryRow(ctx, `SELECT request_hash, response, created_at, expires_at, locked_until FROM idempotency_keys WHERE user_id = ? AND key = ?`,
+		k.UserId.Hex(), k.Key).Scan(&stored.RequestHash, &stored.Response, &createdAt, &expiresAt, &lockedUntil)
+	if err != nil {
+		return stored, sqlError(err)
+	}
+	stored.CreatedAt = time.UnixMilli(createdAt).UTC()
+	stored.ExpiresAt = time.UnixMilli(expiresAt).UTC()
+	stored.LockedUntil = time.UnixMilli(lockedUntil).UTC()
+	return stored, db.ErrAlreadyExists
+}
+
+func (s *Store) CompleteKey(ctx context.Context, k db.IdempotencyKey) error {
+	return checkAffected(s.exec(ctx, `UPDATE idempotency_keys SET request_hash = ?, response = ? WHERE user_id = ? AND key = ? AND created_at = ?`,
+		k.RequestHash, k.Response, k.UserId.Hex(), k.Key, k.CreatedAt.UnixMilli()))
+}
+
+func (s *Store) RenewKey(ctx context.Context, k db.IdempotencyKey) error {
+	return checkAffected(s.exec(ctx, `UPDATE idempotency_keys SET locked_until = ? WHERE user_id = ? AND key = ? AND created_at = ? AND response IS NULL`,
+		k.LockedUntil.UnixMilli(), k.UserId.Hex(), k.Key, k.CreatedAt.UnixMilli()))
+}
+
+func (s *Store) ReleaseKey(ctx context.Context, k db.IdempotencyKey) error {
+	_, err := s.exec(ctx, `DELETE FROM idempotency_keys WHERE user_id = ? AND key = ? AND created_at = ?`, k.UserId.Hex(), k.Key, k.CreatedAt.UnixMilli())
+	return err
+}
+
+func (s *Store) PurgeKeys(ctx context.Context, now time.Time) (int64, error) {
+	result, err := s.exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= ?`, now.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,388 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type step func(ctx context.Context, tx *sql.Tx, s *Store) error
+
+type migration struct {
+	version int
+	steps   []step
+}
+
+// exec is a migration step running a single statement.
+func exec(stmt string) step {
+	return func(ctx context.Context, tx *sql.Tx, s *Store) error {
+		_, err := tx.ExecContext(ctx, stmt)
+		return err
+	}
+}
+
+// migrations are applied in order and never edited once released,
+// add a new version instead.
+var migrations = []migration{
+	{
+		version: 1,
+		steps: []step{
+			exec(`CREATE TABLE records (
+				id          TEXT PRIMARY KEY,
+				user_id     TEXT NOT NULL,
+				type        TEXT NOT NULL,
+				date        TEXT NOT NULL,
+				title       TEXT NOT NULL,
+				description TEXT NOT NULL,
+				amount      INTEGER NOT NULL,
+				created_at  TEXT NOT NULL,
+				updated_at  TEXT NOT NULL
+			)`),
+			exec(`CREATE INDEX records_user_id ON records (user_id, id)`),
+		},
+	},
+	{
+		// amounts become 64 bit minor units with a currency, sqlite cannot
+		// alter column types so the table is rebuilt
+		version: 2,
+		steps: []step{
+			exec(`CREATE TABLE records_v2 (
+				id          TEXT PRIMARY KEY,
+				user_id     TEXT NOT NULL,
+				type        TEXT NOT NULL,
+				date        TEXT NOT NULL,
+				title       TEXT NOT NULL,
+				description TEXT NOT NULL,
+				amount      BIGINT NOT NULL,
+				currency    TEXT NOT NULL,
+				created_at  TEXT NOT NULL,
+				updated_at  TEXT NOT NULL
+			)`),
+			func(ctx context.Context, tx *sql.Tx, s *Store) error {
+				_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO records_v2
+					SELECT id, user_id, type, date, title, description, amount, ?, created_at, updated_at FROM records`), db.DefaultCurrency)
+				return err
+			},
+			exec(`DROP TABLE records`),
+			exec(`ALTER TABLE records_v2 RENAME TO records`),
+			exec(`CREATE INDEX records_user_id ON records (user_id, id)`),
+		},
+	},
+	{
+		// dates and timestamps become unix milliseconds instead of strings
+		version: 3,
+		steps: []step{
+			exec(`CREATE TABLE records_v3 (
+				id          TEXT PRIMARY KEY,
+				user_id     TEXT NOT NULL,
+				type        TEXT NOT NULL,
+				date        BIGINT NOT NULL,
+				title       TEXT NOT NULL,
+				description TEXT NOT NULL,
+				amount      BIGINT NOT NULL,
+				currency    TEXT NOT NULL,
+				created_at  BIGINT NOT NULL,
+				updated_at  BIGINT NOT NULL
+			)`),
+			convertLegacyTimes,
+			exec(`DROP TABLE records`),
+			exec(`ALTER TABLE records_v3 RENAME TO records`),
+			exec(`CREATE INDEX records_user_id ON records (user_id, id)`),
+		},
+	},
+	{
+		// indexes backing the GetRecords filters and sort orders
+		version: 4,
+		steps: []step{
+			exec(`CREATE INDEX records_user_date ON records (user_id, date, id)`),
+			exec(`CREATE INDEX records_user_amount ON records (user_id, amount, id)`),
+			exec(`CREATE INDEX records_user_created_at ON records (user_id, created_at, id)`),
+			exec(`CREATE INDEX records_user_type_date ON records (user_id, type, date)`),
+		},
+	},
+	{
+		// optimistic concurrency, existing records start at version 1
+		version: 5,
+		steps: []step{
+			exec(`ALTER TABLE records ADD COLUMN version BIGINT NOT NULL DEFAULT 1`),
+		},
+	},
+	{
+		// per user category trees, records without a category keep a NULL id
+		version: 6,
+		steps: []step{
+			exec(`CREATE TABLE categories (
+				id         TEXT PRIMARY KEY,
+				user_id    TEXT NOT NULL,
+				parent_id  TEXT,
+				name       TEXT NOT NULL,
+				icon       TEXT NOT NULL,
+				color      TEXT NOT NULL,
+				type       TEXT NOT NULL,
+				created_at BIGINT NOT NULL,
+				updated_at BIGINT NOT NULL
+			)`),
+			exec(`CREATE INDEX categories_user_parent ON categories (user_id, parent_id)`),
+			exec(`ALTER TABLE records ADD COLUMN category_id TEXT`),
+			exec(`CREATE INDEX records_user_category ON records (user_id, category_id)`),
+		},
+	},
+	{
+		// normalized tags of the records, one row per record and tag
+		version: 7,
+		steps: []step{
+			exec(`CREATE TABLE record_tags (
+				record_id TEXT NOT NULL,
+				user_id   TEXT NOT NULL,
+				tag       TEXT NOT NULL,
+				PRIMARY KEY (record_id, tag)
+			)`),
+			exec(`CREATE INDEX record_tags_user_tag ON record_tags (user_id, tag)`),
+		},
+	},
+	{
+		// accounts and the records booked on them
+		version: 8,
+		steps: []step{
+			exec(`CREATE TABLE accounts (
+				id              TEXT PRIMARY KEY,
+				user_id         TEXT NOT NULL,
+				name            TEXT NOT NULL,
+				kind            TEXT NOT NULL,
+				currency        TEXT NOT NULL,
+				opening_balance BIGINT NOT NULL,
+				created_at      BIGINT NOT NULL,
+				updated_at      BIGINT NOT NULL
+			)`),
+			exec(`CREATE INDEX accounts_user_name ON accounts (user_id, name)`),
+			exec(`ALTER TABLE records ADD COLUMN account_id TEXT`),
+			exec(`CREATE INDEX records_user_account_date ON records (user_id, account_id, date)`),
+		},
+	},
+	{
+		// both records of a transfer share its id
+		version: 9,
+		steps: []step{
+			exec(`ALTER TABLE records ADD COLUMN transfer_id TEXT`),
+			exec(`CREATE INDEX records_user_transfer ON records (user_id, transfer_id)`),
+		},
+	},
+	{
+		// recurring schedules, each of their occurrences is recorded once
+		version: 10,
+		steps: []step{
+			exec(`CREATE TABLE schedules (
+				id              TEXT PRIMARY KEY,
+				user_id         TEXT NOT NULL,
+				type            TEXT NOT NULL,
+				title           TEXT NOT NULL,
+				description     TEXT NOT NULL,
+				amount          BIGINT NOT NULL,
+				currency        TEXT NOT NULL,
+				category_id     TEXT,
+				tags            TEXT NOT NULL,
+				account_id      TEXT,
+				frequency       TEXT NOT NULL,
+				repeat_interval INTEGER NOT NULL,
+				start_date      BIGINT NOT NULL,
+				until_date      BIGINT,
+				repeat_count    INTEGER NOT NULL,
+				exceptions      TEXT NOT NULL,
+				materialized    INTEGER NOT NULL,
+				next_date       BIGINT,
+				version         BIGINT NOT NULL,
+				created_at      BIGINT NOT NULL,
+				updated_at      BIGINT NOT NULL
+			)`),
+			exec(`CREATE INDEX schedules_user_title ON schedules (user_id, title)`),
+			exec(`CREATE INDEX schedules_next_date ON schedules (next_date)`),
+			exec(`ALTER TABLE records ADD COLUMN schedule_id TEXT`),
+			exec(`ALTER TABLE records ADD COLUMN occurrence BIGINT`),
+			exec(`CREATE UNIQUE INDEX records_schedule_occurrence ON records (schedule_id, occurrence)`),
+		},
+	},
+	{
+		// spending limits per category or tag
+		version: 11,
+		steps: []step{
+			exec(`CREATE TABLE budgets (
+				id           TEXT PRIMARY KEY,
+				user_id      TEXT NOT NULL,
+				name         TEXT NOT NULL,
+				category_id  TEXT,
+				tag          TEXT NOT NULL,
+				limit_amount BIGINT NOT NULL,
+				currency     TEXT NOT NULL,
+				period       TEXT NOT NULL,
+				start_date   BIGINT NOT NULL,
+				rollover     BOOLEAN NOT NULL,
+				created_at   BIGINT NOT NULL,
+				updated_at   BIGINT NOT NULL
+			)`),
+			exec(`CREATE INDEX budgets_user_name ON budgets (user_id, name)`),
+		},
+	},
+	{
+		// a single record per bank transaction and account
+		version: 12,
+		steps: []step{
+			exec(`ALTER TABLE records ADD COLUMN import_id TEXT`),
+			exec(`CREATE UNIQUE INDEX records_import ON records (user_id, (COALESCE(account_id, '')), import_id) WHERE import_id IS NOT NULL`),
+		},
+	},
+	{
+		// the idempotency keys of the users, response is NULL while the
+		// call runs
+		version: 13,
+		steps: []step{
+			func(ctx context.Context, tx *sql.Tx, s *Store) error {
+				blob := "BLOB"
+				if s.driver == Postgres {
+					blob = "BYTEA"
+				}
+				_, err := tx.ExecContext(ctx, `CREATE TABLE idempotency_keys (
+					user_id      TEXT NOT NULL,
+					key          TEXT NOT NULL,
+					request_hash TEXT NOT NULL,
+					response     `+blob+`,
+					created_at   BIGINT NOT NULL,
+					expires_at   BIGINT NOT NULL,
+					locked_until BIGINT NOT NULL,
+					PRIMARY KEY (user_id, key)
+				)`)
+				return err
+			},
+			exec(`CREATE INDEX idempotency_keys_expires_at ON idempotency_keys (expires_at)`),
+		},
+	},
+	{
+		// the deleted records until restored or purged, their tags joined
+		// by commas as for schedules
+		version: 14,
+		steps: []step{
+			exec(`CREATE TABLE trashed_records (
+				id          TEXT PRIMARY KEY,
+				user_id     TEXT NOT NULL,
+				type        TEXT NOT NULL,
+				date        BIGINT NOT NULL,
+				title       TEXT NOT NULL,
+				description TEXT NOT NULL,
+				amount      BIGINT NOT NULL,
+				currency    TEXT NOT NULL,
+				created_at  BIGINT NOT NULL,
+				updated_at  BIGINT NOT NULL,
+				version     BIGINT NOT NULL,
+				category_id TEXT,
+				account_id  TEXT,
+				transfer_id TEXT,
+				schedule_id TEXT,
+				occurrence  BIGINT,
+				import_id   TEXT,
+				tags        TEXT NOT NULL,
+				deleted_at  BIGINT NOT NULL
+			)`),
+			exec(`CREATE INDEX trashed_records_user_deleted_at ON trashed_records (user_id, deleted_at, id)`),
+			exec(`CREATE INDEX trashed_records_deleted_at ON trashed_records (deleted_at)`),
+		},
+	},
+	{
+		// the revisions of the records, their changes and record in JSON
+		version: 15,
+		steps: []step{
+			exec(`CREATE TABLE record_history (
+				id         TEXT PRIMARY KEY,
+				user_id    TEXT NOT NULL,
+				record_id  TEXT NOT NULL,
+				action     TEXT NOT NULL,
+				actor_id   TEXT,
+				user_agent TEXT NOT NULL,
+				address    TEXT NOT NULL,
+				changes    TEXT NOT NULL,
+				record     TEXT NOT NULL,
+				created_at BIGINT NOT NULL
+			)`),
+			exec(`CREATE INDEX record_history_user_record ON record_history (user_id, record_id, created_at, id)`),
+		},
+	},
+}
+
+func convertLegacyTimes(ctx context.Context, tx *sql.Tx, s *Store) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, date, created_at, updated_at FROM records`)
+	if err != nil {
+		return err
+	}
+	type times struct{ id, date, createdAt, updatedAt string }
+	all := []times{}
+	for rows.Next() {
+		var t times
+		if err := rows.Scan(&t.id, &t.date, &t.createdAt, &t.updatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		all = append(all, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, t := range all {
+		fallback := time.Unix(0, 0)
+		if id, err := primitive.ObjectIDFromHex(t.id); err == nil {
+			fallback = id.Timestamp()
+		}
+		createdAt := db.LegacyTime(t.createdAt, fallback)
+		_, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO records_v3
+			SELECT id, user_id, type, ?, title, description, amount, currency, ?, ? FROM records WHERE id = ?`),
+			db.LegacyTime(t.date, createdAt).UnixMilli(), createdAt.UnixMilli(), db.LegacyTime(t.updatedAt, createdAt).UnixMilli(), t.id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) migrate(ctx context.Context) error {
+	if _, err := s.exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	current := 0
+	if err := s.queryRow(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := s.apply(ctx, m); err != nil {
+			return fmt.Errorf("migration %d: %w", m.version, err)
+		}
+	}
+	return nil
+}
+
+func (s *Store) apply(ctx context.Context, m migration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, step := range m.steps {
+		if err := step(ctx, tx, s); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, s.rebind(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`), m.version, time.Now().UTC().String()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
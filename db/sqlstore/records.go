@@ -0,0 +1,592 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const recordColumns = `id, user_id, type, date, title, description, amount, currency, created_at, updated_at, version, category_id, account_id, transfer_id, schedule_id, occurrence, import_id`
+
+func (s *Store) Create(ctx context.Context, r *db.Record) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if err := s.recordRefs(ctx).Check(*r); err != nil {
+		return err
+	}
+	created := *r
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.insertRecord(ctx, tx, &created)
+	})
+	if err != nil {
+		return err
+	}
+	*r = created
+	return nil
+}
+
+func (s *Store) CreateMany(ctx context.Context, records []db.Record) error {
+	if err := db.CheckBatch(records, s.recordRefs(ctx)); err != nil {
+		return err
+	}
+	created := append([]db.Record{}, records...)
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range created {
+			if err := s.insertRecord(ctx, tx, &created[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	copy(records, created)
+	return nil
+}
+
+// insertRecord writes r with its tags and sets its ID and first version.
+func (s *Store) insertRecord(ctx context.Context, tx *sql.Tx, r *db.Record) error {
+	now := db.Now()
+	id := primitive.NewObjectID()
+	_, err := s.txExec(ctx, tx, `INSERT INTO records (`+recordColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id.Hex(), r.UserId.Hex(), r.Type, r.Date.UnixMilli(), r.Title, r.Description, r.Amount.Units, r.Amount.Currency, now.UnixMilli(), now.UnixMilli(), 1,
+		nullId(r.CategoryId), nullId(r.AccountId), nullId(r.TransferId), nullId(r.ScheduleId), nullTime(r.Occurrence), nullString(r.ImportId))
+	if err != nil {
+		return err
+	}
+	if err := s.insertTags(ctx, tx, r.UserId, id, r.Tags); err != nil {
+		return err
+	}
+	created := *r
+	created.ID = id
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	created.Version = 1
+	if err := s.insertRevision(ctx, tx, db.NewRevision(ctx, db.RevisionCreated, db.Record{}, created, now)); err != nil {
+		return err
+	}
+	*r = created
+	return nil
+}
+
+func (s *Store) ImportedIds(ctx context.Context, userId, accountId primitive.ObjectID, importIds []string) (map[string]bool, error) {
+	taken := map[string]bool{}
+	if len(importIds) == 0 {
+		return taken, nil
+	}
+	where, args := `user_id = ? AND account_id IS NULL`, []any{userId.Hex()}
+	if !accountId.IsZero() {
+		where, args = `user_id = ? AND account_id = ?`, append(args, accountId.Hex())
+	}
+	for _, id := range importIds {
+		args = append(args, id)
+	}
+	rows, err := s.query(ctx, `SELECT import_id FROM records WHERE `+where+` AND import_id IN (`+placeholders(len(importIds))+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		taken[id] = true
+	}
+	return taken, rows.Err()
+}
+
+func (s *Store) Get(ctx context.Context, userId, id primitive.ObjectID) (db.Record, error) {
+	r, err := scanRecord(s.queryRow(ctx, `SELECT `+recordColumns+` FROM records WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	if err != nil {
+		return r, sqlError(err)
+	}
+	records := []db.Record{r}
+	err = s.loadTags(ctx, records)
+	return records[0], err
+}
+
+func (s *Store) Update(ctx context.Context, r *db.Record, fields []string) error {
+	u, err := s.checkUpdate(ctx, *r, fields)
+	if err != nil {
+		return err
+	}
+	now := db.Now()
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.writeUpdate(ctx, tx, u, now)
+	})
+	if err != nil {
+		return s.missingRecord(ctx, r.UserId, r.ID, err)
+	}
+	stored, err := s.Get(ctx, r.UserId, r.ID)
+	if err != nil {
+		return err
+	}
+	*r = stored
+	return nil
+}
+
+func (s *Store) UpdateMany(ctx context.Context, updates []db.RecordUpdate) error {
+	targets := db.BatchTargets{}
+	checked := []recordUpdate{}
+	for i := range updates {
+		u, err := s.checkUpdate(ctx, updates[i].Record, updates[i].Fields)
+		if err == nil {
+			err = targets.Add(u.r.ID, u.other.ID)
+		}
+		if err != nil {
+			return db.BatchItemError(i, err)
+		}
+		checked = append(checked, u)
+	}
+	now := db.Now()
+	failed := -1
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for i, u := range checked {
+			if err := s.writeUpdate(ctx, tx, u, now); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if failed >= 0 {
+		r := updates[failed].Record
+		return db.BatchItemError(failed, s.missingRecord(ctx, r.UserId, r.ID, err))
+	}
+	if err != nil {
+		return err
+	}
+	for i := range updates {
+		r := &updates[i].Record
+		stored, err := s.Get(ctx, r.UserId, r.ID)
+		if err != nil {
+			return err
+		}
+		*r = stored
+	}
+	return nil
+}
+
+// recordUpdate is an update checked against the stored record by
+// checkUpdate, ready to be written by writeUpdate.
+type recordUpdate struct {
+	r      db.Record
+	fields []string
+	// updated is the stored record with the update applied
+	stored  db.Record
+	updated db.Record
+	// other is the other side of a transfer, shared its fields to write
+	other  db.Record
+	shared []string
+}
+
+func (s *Store) checkUpdate(ctx context.Context, r db.Record, fields []string) (recordUpdate, error) {
+	u := recordUpdate{r: r, fields: db.UpdateFields(fields)}
+	if err := r.ValidateFields(u.fields); err != nil {
+		return u, err
+	}
+	stored, err := s.Get(ctx, r.UserId, r.ID)
+	if err != nil {
+		return u, err
+	}
+	u.stored, u.updated = stored, stored
+	u.updated.ApplyFields(r, u.fields)
+	if db.RefsChanged(u.fields) {
+		if err := s.recordRefs(ctx).Check(u.updated); err != nil {
+			return u, err
+		}
+	}
+	if stored.IsTransfer() {
+		if u.other, err = s.otherSide(ctx, stored); err != nil {
+			return u, err
+		}
+		if u.shared, err = db.CheckTransferSide(stored, u.updated, u.other, u.fields, s.recordRefs(ctx)); err != nil {
+			return u, err
+		}
+	}
+	return u, nil
+}
+
+func (s *Store) writeUpdate(ctx context.Context, tx *sql.Tx, u recordUpdate, now time.Time) error {
+	r := u.r
+	sets, args := sqlRecordSets(r, u.fields, now)
+	where, whereArgs := sqlRecordVersion(r.UserId, r.ID, r.Version)
+	result, err := s.txExec(ctx, tx, `UPDATE records SET `+strings.Join(sets, ", ")+` WHERE `+where, append(args, whereArgs...)...)
+	if err := checkAffected(result, err); err != nil {
+		return err
+	}
+	if err := s.insertRevision(ctx, tx, db.NewRevision(ctx, db.RevisionUpdated, u.stored, revised(u.updated, now), now)); err != nil {
+		return err
+	}
+	if len(u.shared) > 0 {
+		sets, args := sqlRecordSets(u.updated, u.shared, now)
+		if _, err := s.txExec(ctx, tx, `UPDATE records SET `+strings.Join(sets, ", ")+` WHERE id = ?`, append(args, u.other.ID.Hex())...); err != nil {
+			return err
+		}
+		other := u.other
+		other.ApplyFields(u.updated, u.shared)
+		if err := s.insertRevision(ctx, tx, db.NewRevision(ctx, db.RevisionUpdated, u.other, revised(other, now), now)); err != nil {
+			return err
+		}
+	}
+	if !containsField(u.fields, "tags") {
+		return nil
+	}
+	if _, err := s.txExec(ctx, tx, `DELETE FROM record_tags WHERE record_id = ?`, r.ID.Hex()); err != nil {
+		return err
+	}
+	return s.insertTags(ctx, tx, r.UserId, r.ID, r.Tags)
+}
+
+// revised is the stored record r once an update written at now.
+func revised(r db.Record, now time.Time) db.Record {
+	r.UpdatedAt = now
+	r.Version++
+	return r
+}
+
+func (s *Store) Delete(ctx context.Context, userId, id primitive.ObjectID, version int64) error {
+	sides, err := s.deleteSides(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.writeDelete(ctx, tx, sides, version, db.Now())
+	})
+	if err != nil {
+		return s.missingRecord(ctx, userId, id, err)
+	}
+	return nil
+}
+
+func (s *Store) DeleteMany(ctx context.Context, userId primitive.ObjectID, deletes []db.RecordDelete) error {
+	targets := db.BatchTargets{}
+	stored := [][]db.Record{}
+	for i, d := range deletes {
+		sides, err := s.deleteSides(ctx, userId, d.ID)
+		for _, r := range sides {
+			if err == nil {
+				err = targets.Add(r.ID)
+			}
+		}
+		if err != nil {
+			return db.BatchItemError(i, err)
+		}
+		stored = append(stored, sides)
+	}
+	failed := -1
+	now := db.Now()
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for i, sides := range stored {
+			if err := s.writeDelete(ctx, tx, sides, deletes[i].Version, now); err != nil {
+				failed = i
+				return err
+			}
+		}
+		return nil
+	})
+	if failed >= 0 {
+		return db.BatchItemError(failed, s.missingRecord(ctx, userId, deletes[failed].ID, err))
+	}
+	return err
+}
+
+// deleteSides reads the user's record with its tags, followed by the
+// other side of a transfer.
+func (s *Store) deleteSides(ctx context.Context, userId, id primitive.ObjectID) ([]db.Record, error) {
+	r, err := s.Get(ctx, userId, id)
+	if err != nil || !r.IsTransfer() {
+		return []db.Record{r}, err
+	}
+	other, err := s.otherSide(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return []db.Record{r, other}, nil
+}
+
+// writeDelete moves the records read by deleteSides to the trash, the first
+// one at the given version.
+func (s *Store) writeDelete(ctx context.Context, tx *sql.Tx, sides []db.Record, version int64, now time.Time) error {
+	for i, r := range sides {
+		if i > 0 {
+			version = 0
+		}
+		where, args := sqlRecordVersion(r.UserId, r.ID, version)
+		args = append([]any{strings.Join(r.Tags, ","), now.UnixMilli()}, args...)
+		if err := checkAffected(s.txExec(ctx, tx, `INSERT INTO trashed_records (`+recordColumns+`, tags, deleted_at)
+			SELECT `+recordColumns+`, ?, ? FROM records WHERE `+where, args...)); err != nil {
+			return err
+		}
+		if _, err := s.txExec(ctx, tx, `DELETE FROM record_tags WHERE record_id = ?`, r.ID.Hex()); err != nil {
+			return err
+		}
+		if _, err := s.txExec(ctx, tx, `DELETE FROM records WHERE id = ?`, r.ID.Hex()); err != nil {
+			return err
+		}
+		r.DeletedAt = now
+		if err := s.insertRevision(ctx, tx, db.NewRevision(ctx, db.RevisionDeleted, r, db.Record{}, now)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sqlRecordSets lists the assignments writing the given fields of r in
+// an UPDATE, along with a new version.
+func sqlRecordSets(r db.Record, fields []string, now time.Time) ([]string, []any) {
+	sets := []string{"updated_at = ?", "version = version + 1"}
+	args := []any{now.UnixMilli()}
+	for _, field := range fields {
+		switch field {
+		case "title":
+			sets, args = append(sets, "title = ?"), append(args, r.Title)
+		case "amount":
+			sets, args = append(sets, "amount = ?", "currency = ?"), append(args, r.Amount.Units, r.Amount.Currency)
+		case "description":
+			sets, args = append(sets, "description = ?"), append(args, r.Description)
+		case "date":
+			sets, args = append(sets, "date = ?"), append(args, r.Date.UnixMilli())
+		case "type":
+			sets, args = append(sets, "type = ?"), append(args, r.Type)
+		case "category_id":
+			sets, args = append(sets, "category_id = ?"), append(args, nullId(r.CategoryId))
+		case "account_id":
+			sets, args = append(sets, "account_id = ?"), append(args, nullId(r.AccountId))
+		}
+	}
+	return sets, args
+}
+
+// recordRefs looks up the references of records for db.RecordRefs.Check.
+func (s *Store) recordRefs(ctx context.Context) db.RecordRefs {
+	return db.RecordRefs{
+		Category: func(userId, id primitive.ObjectID) (db.Category, error) {
+			return s.GetCategory(ctx, userId, id)
+		},
+		Account: func(userId, id primitive.ObjectID) (db.Account, error) {
+			return s.GetAccount(ctx, userId, id)
+		},
+	}
+}
+
+// sqlRecordVersion selects the user's record, only at the given version
+// unless it is zero.
+func sqlRecordVersion(userId, id primitive.ObjectID, version int64) (string, []any) {
+	if version != 0 {
+		return `id = ? AND user_id = ? AND version = ?`, []any{id.Hex(), userId.Hex(), version}
+	}
+	return `id = ? AND user_id = ?`, []any{id.Hex(), userId.Hex()}
+}
+
+// missingRecord tells why a conditional write matched nothing, other
+// errors than ErrNotFound are returned as they are.
+func (s *Store) missingRecord(ctx context.Context, userId, id primitive.ObjectID, err error) error {
+	if !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+	if _, err := s.Get(ctx, userId, id); err != nil {
+		return err
+	}
+	return db.ErrVersionMismatch
+}
+
+// notFound replaces ErrNotFound by the more specific notFoundErr.
+func notFound(err, notFoundErr error) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return notFoundErr
+	}
+	return err
+}
+
+// checkAffected turns a statement that changed no rows into ErrNotFound.
+func checkAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return db.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) GetUserRecords(ctx context.Context, q db.RecordQuery) (db.RecordPage, error) {
+	if err := q.Validate(); err != nil {
+		return db.RecordPage{}, err
+	}
+
+	where, args := sqlRecordFilter(q.UserId, q.Filter)
+	total := int64(0)
+	if q.CountTotal {
+		if err := s.queryRow(ctx, `SELECT COUNT(*) FROM records WHERE `+where, args...).Scan(&total); err != nil {
+			return db.RecordPage{}, sqlError(err)
+		}
+	}
+	if q.After != nil {
+		cond, values := sqlAfterCursor(q.Sort, *q.After)
+		where += " AND " + cond
+		args = append(args, values...)
+	}
+
+	args = append(args, q.PageSize+1)
+	rows, err := s.query(ctx, `SELECT `+recordColumns+` FROM records WHERE `+where+` ORDER BY `+sqlRecordSort(q.Sort)+` LIMIT ?`, args...)
+	if err != nil {
+		return db.RecordPage{}, err
+	}
+	defer rows.Close()
+
+	rl := []db.Record{}
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return db.RecordPage{}, err
+		}
+		rl = append(rl, r)
+	}
+	if err := rows.Err(); err != nil {
+		return db.RecordPage{}, sqlError(err)
+	}
+	// sqlite has a single connection, release it before loading the tags
+	rows.Close()
+	if err := s.loadTags(ctx, rl); err != nil {
+		return db.RecordPage{}, err
+	}
+	page := db.NewRecordPage(q, rl)
+	page.Total = total
+	return page, nil
+}
+
+func sqlRecordFilter(userId primitive.ObjectID, f db.RecordFilter) (string, []any) {
+	conds := []string{"user_id = ?"}
+	args := []any{userId.Hex()}
+	add := func(cond string, values ...any) {
+		conds = append(conds, cond)
+		args = append(args, values...)
+	}
+	if f.Type != "" {
+		add("type = ?", f.Type)
+	}
+	if !f.From.IsZero() {
+		add("date >= ?", f.From.UnixMilli())
+	}
+	if !f.To.IsZero() {
+		add("date < ?", f.To.UnixMilli())
+	}
+	if f.MinAmount != nil {
+		add("amount >= ?", *f.MinAmount)
+	}
+	if f.MaxAmount != nil {
+		add("amount <= ?", *f.MaxAmount)
+	}
+	if f.Currency != "" {
+		add("currency = ?", f.Currency)
+	}
+	if len(f.Categories) > 0 {
+		ids := []any{}
+		for _, id := range f.Categories {
+			ids = append(ids, id.Hex())
+		}
+		add("category_id IN ("+placeholders(len(ids))+")", ids...)
+	}
+	if !f.AccountId.IsZero() {
+		add("account_id = ?", f.AccountId.Hex())
+	}
+	if f.ExcludeTransfers {
+		add("transfer_id IS NULL")
+	}
+	if len(f.AnyTags) > 0 {
+		add("id IN (SELECT record_id FROM record_tags WHERE user_id = ? AND tag IN ("+placeholders(len(f.AnyTags))+"))",
+			append([]any{userId.Hex()}, stringArgs(f.AnyTags)...)...)
+	}
+	if len(f.AllTags) > 0 {
+		add("id IN (SELECT record_id FROM record_tags WHERE user_id = ? AND tag IN ("+placeholders(len(f.AllTags))+") GROUP BY record_id HAVING COUNT(*) = ?)",
+			append(append([]any{userId.Hex()}, stringArgs(f.AllTags)...), len(f.AllTags))...)
+	}
+	if f.Text != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(f.Text)) + "%"
+		add(`(LOWER(title) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+var sqlSortColumns = map[db.SortField]string{
+	db.SortByDate:      "date",
+	db.SortByAmount:    "amount",
+	db.SortByCreatedAt: "created_at",
+	db.SortByDeletedAt: "deleted_at",
+}
+
+// sqlAfterCursor matches the records after c in the sort order.
+func sqlAfterCursor(s db.RecordSort, c db.RecordCursor) (string, []any) {
+	op := "<"
+	if s.Ascending {
+		op = ">"
+	}
+	col := sqlSortColumns[s.Field]
+	return "(" + col + " " + op + " ? OR (" + col + " = ? AND id " + op + " ?))", []any{c.Value, c.Value, c.ID.Hex()}
+}
+
+func sqlRecordSort(s db.RecordSort) string {
+	dir := " DESC"
+	if s.Ascending {
+		dir = " ASC"
+	}
+	return sqlSortColumns[s.Field] + dir + ", id" + dir
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecord(row scanner) (db.Record, error) {
+	var r db.Record
+	var id, userId string
+	var categoryId, accountId, transferId, scheduleId, importId sql.NullString
+	var occurrence sql.NullInt64
+	var date, createdAt, updatedAt int64
+	err := row.Scan(&id, &userId, &r.Type, &date, &r.Title, &r.Description, &r.Amount.Units, &r.Amount.Currency, &createdAt, &updatedAt, &r.Version,
+		&categoryId, &accountId, &transferId, &scheduleId, &occurrence, &importId)
+	if err != nil {
+		return r, err
+	}
+	if r.CategoryId, err = parseNullId(categoryId); err != nil {
+		return r, err
+	}
+	if r.AccountId, err = parseNullId(accountId); err != nil {
+		return r, err
+	}
+	if r.TransferId, err = parseNullId(transferId); err != nil {
+		return r, err
+	}
+	if r.ScheduleId, err = parseNullId(scheduleId); err != nil {
+		return r, err
+	}
+	r.Occurrence = parseNullTime(occurrence)
+	r.ImportId = importId.String
+	r.Date = time.UnixMilli(date).UTC()
+	r.CreatedAt = time.UnixMilli(createdAt).UTC()
+	r.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	if r.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return r, err
+	}
+	r.UserId, err = primitive.ObjectIDFromHex(userId)
+	return r, err
+}
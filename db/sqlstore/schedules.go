@@ -0,0 +1,179 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var scheduleColumnNames = []string{
+	"id", "user_id", "type", "title", "description", "amount", "currency", "category_id", "tags", "account_id",
+	"frequency", "repeat_interval", "start_date", "until_date", "repeat_count", "exceptions", "materialized", "next_date",
+	"version", "created_at", "updated_at",
+}
+
+var scheduleColumns = strings.Join(scheduleColumnNames, ", ")
+
+func (s *Store) CreateSchedule(ctx context.Context, sc *db.Schedule) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	if err := sc.Check(s.recordRefs(ctx)); err != nil {
+		return err
+	}
+	created := *sc
+	now := db.Now()
+	created.ID = primitive.NewObjectID()
+	created.Begin()
+	created.Version = 1
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	args, err := scheduleArgs(created)
+	if err != nil {
+		return err
+	}
+	if _, err := s.exec(ctx, `INSERT INTO schedules (`+scheduleColumns+`) VALUES (`+placeholders(len(args))+`)`, args...); err != nil {
+		return err
+	}
+	*sc = created
+	return nil
+}
+
+func (s *Store) GetSchedule(ctx context.Context, userId, id primitive.ObjectID) (db.Schedule, error) {
+	sc, err := scanSchedule(s.queryRow(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	if err == sql.ErrNoRows {
+		return sc, db.ErrScheduleNotFound
+	}
+	return sc, sqlError(err)
+}
+
+// UpdateSchedule retries when the materializer advanced the schedule
+// between reading and writing it.
+func (s *Store) UpdateSchedule(ctx context.Context, sc *db.Schedule, fields []string) error {
+	fields = db.UpdateScheduleFields(fields)
+	if err := sc.ValidateFields(fields); err != nil {
+		return err
+	}
+	for attempt := 0; attempt < db.ScheduleUpdateAttempts; attempt++ {
+		stored, err := s.GetSchedule(ctx, sc.UserId, sc.ID)
+		if err != nil {
+			return err
+		}
+		updated, err := db.MergeScheduleUpdate(stored, *sc, fields, s.recordRefs(ctx))
+		if err != nil {
+			return err
+		}
+		args, err := scheduleArgs(updated)
+		if err != nil {
+			return err
+		}
+		err = checkAffected(s.exec(ctx, `UPDATE schedules SET `+strings.Join(scheduleColumnNames, " = ?, ")+` = ? WHERE id = ? AND user_id = ? AND version = ?`,
+			append(args, sc.ID.Hex(), sc.UserId.Hex(), stored.Version)...))
+		if err == nil {
+			*sc = updated
+			return nil
+		}
+		if !errors.Is(err, db.ErrNotFound) {
+			return err
+		}
+	}
+	return db.ErrVersionMismatch
+}
+
+func (s *Store) ListSchedules(ctx context.Context, userId primitive.ObjectID) ([]db.Schedule, error) {
+	return s.querySchedules(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE user_id = ? ORDER BY title, id`, userId.Hex())
+}
+
+func (s *Store) DeleteSchedule(ctx context.Context, userId, id primitive.ObjectID) error {
+	err := checkAffected(s.exec(ctx, `DELETE FROM schedules WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex()))
+	return notFound(err, db.ErrScheduleNotFound)
+}
+
+func (s *Store) DueSchedules(ctx context.Context, now time.Time) ([]db.Schedule, error) {
+	return s.querySchedules(ctx, `SELECT `+scheduleColumns+` FROM schedules WHERE next_date IS NOT NULL AND next_date <= ?`, now.UnixMilli())
+}
+
+func (s *Store) AdvanceSchedule(ctx context.Context, sc db.Schedule, materialized int) error {
+	advanced := sc.AdvanceTo(materialized)
+	result, err := s.exec(ctx, `UPDATE schedules SET materialized = ?, next_date = ?, version = version + 1 WHERE id = ? AND user_id = ? AND version = ?`,
+		advanced.Materialized, nullTime(advanced.NextDate), sc.ID.Hex(), sc.UserId.Hex(), sc.Version)
+	if err := checkAffected(result, err); err != nil {
+		return notFound(err, db.ErrVersionMismatch)
+	}
+	return nil
+}
+
+func (s *Store) querySchedules(ctx context.Context, query string, args ...any) ([]db.Schedule, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []db.Schedule{}
+	for rows.Next() {
+		sc, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, sc)
+	}
+	return schedules, sqlError(rows.Err())
+}
+
+// scheduleArgs are the values of scheduleColumns. Tags never contain
+// commas and are stored joined by them, exceptions as JSON.
+func scheduleArgs(sc db.Schedule) ([]any, error) {
+	exceptions, err := json.Marshal(sc.Exceptions)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		sc.ID.Hex(), sc.UserId.Hex(), sc.Type, sc.Title, sc.Description, sc.Amount.Units, sc.Amount.Currency,
+		nullId(sc.CategoryId), strings.Join(sc.Tags, ","), nullId(sc.AccountId),
+		sc.Frequency, sc.Interval, sc.Start.UnixMilli(), nullTime(sc.Until), sc.Count, string(exceptions),
+		sc.Materialized, nullTime(sc.NextDate), sc.Version, sc.CreatedAt.UnixMilli(), sc.UpdatedAt.UnixMilli(),
+	}, nil
+}
+
+func scanSchedule(row scanner) (db.Schedule, error) {
+	var sc db.Schedule
+	var id, userId, tags, exceptions string
+	var categoryId, accountId sql.NullString
+	var until, next sql.NullInt64
+	var start, createdAt, updatedAt int64
+	err := row.Scan(&id, &userId, &sc.Type, &sc.Title, &sc.Description, &sc.Amount.Units, &sc.Amount.Currency, &categoryId, &tags, &accountId,
+		&sc.Frequency, &sc.Interval, &start, &until, &sc.Count, &exceptions, &sc.Materialized, &next, &sc.Version, &createdAt, &updatedAt)
+	if err != nil {
+		return sc, err
+	}
+	if err := json.Unmarshal([]byte(exceptions), &sc.Exceptions); err != nil {
+		return sc, err
+	}
+	sc.Tags = []string{}
+	if tags != "" {
+		sc.Tags = strings.Split(tags, ",")
+	}
+	sc.Start = time.UnixMilli(start).UTC()
+	sc.Until = parseNullTime(until)
+	sc.NextDate = parseNullTime(next)
+	sc.CreatedAt = time.UnixMilli(createdAt).UTC()
+	sc.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+	if sc.CategoryId, err = parseNullId(categoryId); err != nil {
+		return sc, err
+	}
+	if sc.AccountId, err = parseNullId(accountId); err != nil {
+		return sc, err
+	}
+	if sc.ID, err = primitive.ObjectIDFromHex(id); err != nil {
+		return sc, err
+	}
+	sc.UserId, err = primitive.ObjectIDFromHex(userId)
+	return sc, err
+}
@@ -0,0 +1,184 @@
+// Package sqlstore implements the db store interfaces on top of SQLite or
+// PostgreSQL through database/sql.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SQLite   = "sqlite3"
+	Postgres = "postgres"
+)
+
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+// Open connects to the database and brings its schema up to date.
+// driver is either SQLite or Postgres.
+func Open(ctx context.Context, driver string, dsn string) (*Store, error) {
+	if driver != SQLite && driver != Postgres {
+		return nil, fmt.Errorf("unsupported sql driver '%s'", driver)
+	}
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == SQLite {
+		// sqlite only allows a single writer, serialize access instead of
+		// failing with "database is locked"
+		conn.SetMaxOpenConns(1)
+	}
+	if err := conn.PingContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	s := &Store{db: conn, driver: driver}
+	if err := s.migrate(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// rebind rewrites the '?' placeholders used in this package into the
+// numbered form postgres expects.
+func (s *Store) rebind(query string) string {
+	if s.driver != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func (s *Store) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	result, err := s.db.ExecContext(ctx, s.rebind(query), args...)
+	return result, sqlError(err)
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := s.db.QueryContext(ctx, s.rebind(query), args...)
+	return rows, sqlError(err)
+}
+
+func (s *Store) queryRow(ctx context.Context, query string, args ...any) *sql.Row {
+	return s.db.QueryRowContext(ctx, s.rebind(query), args...)
+}
+
+// inTx runs fn in a transaction committed when it returns nil. With
+// sqlite the single connection is held by the transaction, fn must only
+// use tx.
+func (s *Store) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return sqlError(err)
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return sqlError(tx.Commit())
+}
+
+func (s *Store) txExec(ctx context.Context, tx *sql.Tx, query string, args ...any) (sql.Result, error) {
+	result, err := tx.ExecContext(ctx, s.rebind(query), args...)
+	return result, sqlError(err)
+}
+
+// nullId stores the NilObjectID as NULL.
+func nullId(id primitive.ObjectID) any {
+	if id.IsZero() {
+		return nil
+	}
+	return id.Hex()
+}
+
+// nullString stores the empty string as NULL, so unique indexes skip it.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// parseNullId reads a column written with nullId.
+func parseNullId(s sql.NullString) (primitive.ObjectID, error) {
+	if !s.Valid {
+		return primitive.NilObjectID, nil
+	}
+	return primitive.ObjectIDFromHex(s.String)
+}
+
+// nullTime stores the zero time as NULL.
+func nullTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UnixMilli()
+}
+
+// parseNullTime reads a column written with nullTime.
+func parseNullTime(ms sql.NullInt64) time.Time {
+	if !ms.Valid {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms.Int64).UTC()
+}
+
+// sqlError translates driver errors into the db errors every store shares.
+func sqlError(err error) error {
+	var netErr net.Error
+	var liteErr sqlite3.Error
+	var pqErr *pq.Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return db.ErrNotFound
+	case errors.As(err, &liteErr):
+		switch {
+		case liteErr.ExtendedCode == sqlite3.ErrConstraintUnique, liteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey:
+			return fmt.Errorf("%w: %w", db.ErrAlreadyExists, err)
+		case liteErr.Code == sqlite3.ErrBusy, liteErr.Code == sqlite3.ErrLocked:
+			return fmt.Errorf("%w: %w", db.ErrUnavailable, err)
+		}
+	case errors.As(err, &pqErr):
+		switch {
+		case pqErr.Code == "23505":
+			return fmt.Errorf("%w: %w", db.ErrAlreadyExists, err)
+		case pqErr.Code.Class() == "08", pqErr.Code.Class() == "57":
+			return fmt.Errorf("%w: %w", db.ErrUnavailable, err)
+		}
+	case errors.Is(err, driver.ErrBadConn), errors.As(err, &netErr):
+		return fmt.Errorf("%w: %w", db.ErrUnavailable, err)
+	}
+	return err
+}
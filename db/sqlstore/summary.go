@@ -0,0 +1,49 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+// Summarize reads the matching records in a single query and leaves the
+// periods to db.Summarizer, neither SQLite nor Postgres can be relied on
+// for time zones the same way.
+func (s *Store) Summarize(ctx context.Context, q db.SummaryQuery) ([]db.SummaryRow, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	where, args := sqlRecordFilter(q.UserId, q.Filter)
+	group := "NULL"
+	switch q.GroupBy {
+	case "CATEGORY":
+		group = "category_id"
+	case "ACCOUNT":
+		group = "account_id"
+	}
+	query := `SELECT date, type, amount, currency, ` + group + ` FROM records WHERE ` + where
+	if q.GroupBy == "TAG" {
+		query = `SELECT r.date, r.type, r.amount, r.currency, t.tag FROM (SELECT id, date, type, amount, currency FROM records WHERE ` + where + `) r
+			LEFT JOIN record_tags t ON t.record_id = r.id`
+	}
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := db.NewSummarizer(q)
+	for rows.Next() {
+		var date int64
+		var recordType string
+		var amount db.Money
+		var group sql.NullString
+		if err := rows.Scan(&date, &recordType, &amount.Units, &amount.Currency, &group); err != nil {
+			return nil, err
+		}
+		summary.Add(time.UnixMilli(date), recordType, group.String, amount, 1)
+	}
+	return summary.Rows(), sqlError(rows.Err())
+}
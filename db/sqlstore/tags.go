@@ -0,0 +1,127 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) ListTags(ctx context.Context, userId primitive.ObjectID) ([]db.TagCount, error) {
+	rows, err := s.query(ctx, `SELECT tag, COUNT(*) FROM record_tags WHERE user_id = ? GROUP BY tag ORDER BY COUNT(*) DESC, tag`, userId.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []db.TagCount{}
+	for rows.Next() {
+		var t db.TagCount
+		if err := rows.Scan(&t.Tag, &t.Records); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, sqlError(rows.Err())
+}
+
+func (s *Store) RenameTag(ctx context.Context, userId primitive.ObjectID, from, to string) (int64, error) {
+	from, to, err := db.CheckRename(from, to)
+	if err != nil || from == to {
+		return 0, err
+	}
+	changed := int64(0)
+	now := db.Now()
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		tagged, err := s.txRecords(ctx, tx, `user_id = ? AND id IN (SELECT record_id FROM record_tags WHERE user_id = ? AND tag = ?)`,
+			userId.Hex(), userId.Hex(), from)
+		if err != nil {
+			return err
+		}
+		result, err := s.txExec(ctx, tx, `UPDATE records SET updated_at = ?, version = version + 1
+			WHERE user_id = ? AND id IN (SELECT record_id FROM record_tags WHERE user_id = ? AND tag = ?)`,
+			now.UnixMilli(), userId.Hex(), userId.Hex(), from)
+		if err != nil {
+			return err
+		}
+		if changed, err = result.RowsAffected(); err != nil {
+			return err
+		}
+		for _, r := range tagged {
+			renamed := r
+			renamed.Tags = db.RenameTagIn(r.Tags, from, to)
+			if err := s.insertRevision(ctx, tx, db.NewRevision(ctx, db.RevisionUpdated, r, revised(renamed, now), now)); err != nil {
+				return err
+			}
+		}
+		// records carrying both tags keep a single one
+		if _, err := s.txExec(ctx, tx, `DELETE FROM record_tags WHERE user_id = ? AND tag = ?
+			AND record_id IN (SELECT record_id FROM record_tags WHERE user_id = ? AND tag = ?)`,
+			userId.Hex(), from, userId.Hex(), to); err != nil {
+			return err
+		}
+		if _, err := s.txExec(ctx, tx, `UPDATE record_tags SET tag = ? WHERE user_id = ? AND tag = ?`, to, userId.Hex(), from); err != nil {
+			return err
+		}
+		_, err = s.txExec(ctx, tx, `UPDATE budgets SET tag = ?, updated_at = ? WHERE user_id = ? AND tag = ?`, to, now.UnixMilli(), userId.Hex(), from)
+		return err
+	})
+	return changed, err
+}
+
+func (s *Store) insertTags(ctx context.Context, tx *sql.Tx, userId, recordId primitive.ObjectID, tags []string) error {
+	for _, tag := range tags {
+		if _, err := s.txExec(ctx, tx, `INSERT INTO record_tags (record_id, user_id, tag) VALUES (?, ?, ?)`,
+			recordId.Hex(), userId.Hex(), tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTags fills in the tags of the records in a single query.
+func (s *Store) loadTags(ctx context.Context, records []db.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	index := map[string]int{}
+	ids := []any{}
+	for i := range records {
+		records[i].Tags = []string{}
+		index[records[i].ID.Hex()] = i
+		ids = append(ids, records[i].ID.Hex())
+	}
+	rows, err := s.query(ctx, `SELECT record_id, tag FROM record_tags WHERE record_id IN (`+placeholders(len(ids))+`) ORDER BY record_id, tag`, ids...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+		if i, ok := index[id]; ok {
+			records[i].Tags = append(records[i].Tags, tag)
+		}
+	}
+	return sqlError(rows.Err())
+}
+
+func stringArgs(values []string) []any {
+	args := []any{}
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return args
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func (s *Store) CreateTransfer(ctx context.Context, t *db.Transfer) (db.Record, db.Record, error) {
+	if err := t.Validate(s.recordRefs(ctx)); err != nil {
+		return db.Record{}, db.Record{}, err
+	}
+	transfer := *t
+	transfer.ID = primitive.NewObjectID()
+	debit, credit := transfer.Records()
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := s.insertRecord(ctx, tx, &debit); err != nil {
+			return err
+		}
+		return s.insertRecord(ctx, tx, &credit)
+	})
+	if err != nil {
+		return db.Record{}, db.Record{}, err
+	}
+	*t = transfer
+	return debit, credit, nil
+}
+
+// otherSide reads the other record of the transfer r belongs to, with
+// its tags.
+func (s *Store) otherSide(ctx context.Context, r db.Record) (db.Record, error) {
+	other, err := scanRecord(s.queryRow(ctx, `SELECT `+recordColumns+` FROM records WHERE user_id = ? AND transfer_id = ? AND id <> ?`,
+		r.UserId.Hex(), r.TransferId.Hex(), r.ID.Hex()))
+	if err != nil {
+		return other, sqlError(err)
+	}
+	records := []db.Record{other}
+	err = s.loadTags(ctx, records)
+	return records[0], err
+}
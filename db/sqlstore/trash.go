@@ -0,0 +1,166 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const trashColumns = recordColumns + `, tags, deleted_at`
+
+func (s *Store) ListTrash(ctx context.Context, q db.RecordQuery) (db.RecordPage, error) {
+	q.Filter, q.Sort = db.RecordFilter{}, db.TrashSort
+	if err := q.Validate(); err != nil {
+		return db.RecordPage{}, err
+	}
+
+	where, args := `user_id = ?`, []any{q.UserId.Hex()}
+	total := int64(0)
+	if q.CountTotal {
+		if err := s.queryRow(ctx, `SELECT COUNT(*) FROM trashed_records WHERE `+where, args...).Scan(&total); err != nil {
+			return db.RecordPage{}, sqlError(err)
+		}
+	}
+	if q.After != nil {
+		cond, values := sqlAfterCursor(q.Sort, *q.After)
+		where += " AND " + cond
+		args = append(args, values...)
+	}
+	args = append(args, q.PageSize+1)
+	rl, err := s.queryTrash(ctx, `SELECT `+trashColumns+` FROM trashed_records WHERE `+where+` ORDER BY `+sqlRecordSort(q.Sort)+` LIMIT ?`, args...)
+	if err != nil {
+		return db.RecordPage{}, err
+	}
+	page := db.NewRecordPage(q, rl)
+	page.Total = total
+	return page, nil
+}
+
+func (s *Store) RestoreRecord(ctx context.Context, userId, id primitive.ObjectID) (db.Record, error) {
+	sides, err := s.trashed(ctx, userId, id)
+	if err != nil {
+		return db.Record{}, err
+	}
+	now := db.Now()
+	restored := []db.Record{}
+	for _, side := range sides {
+		r, err := db.CheckRestore(side, s.recordRefs(ctx), now)
+		if err != nil {
+			return db.Record{}, err
+		}
+		restored = append(restored, r)
+	}
+	err = s.inTx(ctx, func(tx *sql.Tx) error {
+		for i, r := range restored {
+			if err := checkAffected(s.txExec(ctx, tx, `DELETE FROM trashed_records WHERE id = ?`, r.ID.Hex())); err != nil {
+				return err
+			}
+			_, err := s.txExec(ctx, tx, `INSERT INTO records (`+recordColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				r.ID.Hex(), r.UserId.Hex(), r.Type, r.Date.UnixMilli(), r.Title, r.Description, r.Amount.Units, r.Amount.Currency, r.CreatedAt.UnixMilli(), r.UpdatedAt.UnixMilli(), r.Version,
+				nullId(r.CategoryId), nullId(r.AccountId), nullId(r.TransferId), nullId(r.ScheduleId), nullTime(r.Occurrence), nullString(r.ImportId))
+			if err != nil {
+				return err
+			}
+			if err := s.insertTags(ctx, tx, r.UserId, r.ID, r.Tags); err != nil {
+				return err
+			}
+			if err := s.insertRevision(ctx, tx, db.NewRevision(ctx, db.RevisionRestored, sides[i], r, now)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return db.Record{}, err
+	}
+	return restored[0], nil
+}
+
+func (s *Store) PurgeRecord(ctx context.Context, userId, id primitive.ObjectID) error {
+	sides, err := s.trashed(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, r := range sides {
+			if err := checkAffected(s.txExec(ctx, tx, `DELETE FROM trashed_records WHERE id = ?`, r.ID.Hex())); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Store) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.exec(ctx, `DELETE FROM trashed_records WHERE deleted_at < ?`, before.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// trashed reads the user's deleted record, followed by the other side of
+// its transfer.
+func (s *Store) trashed(ctx context.Context, userId, id primitive.ObjectID) ([]db.Record, error) {
+	sides, err := s.queryTrash(ctx, `SELECT `+trashColumns+` FROM trashed_records WHERE id = ? AND user_id = ?`, id.Hex(), userId.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if len(sides) == 0 {
+		return nil, db.ErrNotFound
+	}
+	if !sides[0].IsTransfer() {
+		return sides, nil
+	}
+	others, err := s.queryTrash(ctx, `SELECT `+trashColumns+` FROM trashed_records WHERE user_id = ? AND transfer_id = ? AND id <> ?`,
+		userId.Hex(), sides[0].TransferId.Hex(), id.Hex())
+	return append(sides, others...), err
+}
+
+func (s *Store) queryTrash(ctx context.Context, query string, args ...any) ([]db.Record, error) {
+	rows, err := s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rl := []db.Record{}
+	for rows.Next() {
+		r, err := scanTrashed(rows)
+		if err != nil {
+			return nil, err
+		}
+		rl = append(rl, r)
+	}
+	return rl, sqlError(rows.Err())
+}
+
+// scanTrashed scans the trashColumns of a deleted record.
+func scanTrashed(row scanner) (db.Record, error) {
+	var tags string
+	var deletedAt int64
+	r, err := scanRecord(extraColumns{row, []any{&tags, &deletedAt}})
+	if err != nil {
+		return r, err
+	}
+	r.Tags = []string{}
+	if tags != "" {
+		r.Tags = strings.Split(tags, ",")
+	}
+	r.DeletedAt = time.UnixMilli(deletedAt).UTC()
+	return r, nil
+}
+
+// extraColumns scans the columns following the ones of another scan.
+type extraColumns struct {
+	scanner
+	dest []any
+}
+
+func (e extraColumns) Scan(dest ...any) error {
+	return e.scanner.Scan(append(dest, e.dest...)...)
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SummaryPeriods are the periods a summary totals records over.
+var SummaryPeriods = []string{"DAY", "WEEK", "MONTH", "YEAR"}
+
+// SummaryGroups are the ways a summary splits the totals of a period
+// besides the currency, "" for none.
+var SummaryGroups = []string{"", "CATEGORY", "TAG", "ACCOUNT"}
+
+// SummaryQuery totals a user's records matching Filter per Period, as
+// seen from Location. The records of transfers are always left out, they
+// are neither income nor expense.
+type SummaryQuery struct {
+	UserId   primitive.ObjectID
+	Filter   RecordFilter
+	Period   string
+	GroupBy  string
+	Location *time.Location
+}
+
+// SummaryRow totals the records of a period, group and currency. Records
+// with several tags count in the row of each of them.
+type SummaryRow struct {
+	// Start is the first instant of the period in the query location,
+	// weeks start on Monday
+	Start time.Time
+	// Group is the hex id of the category or account, or the tag, of the
+	// records; empty for records without one or when not grouped
+	Group    string
+	Currency string
+	Income   int64
+	Expense  int64
+	Count    int64
+}
+
+// Net is the income minus the expense of the row.
+func (r SummaryRow) Net() int64 {
+	return r.Income - r.Expense
+}
+
+// SummaryStore aggregates records into reports.
+type SummaryStore interface {
+	// Summarize returns the rows of the query ordered by start, group and
+	// currency, periods without records are left out.
+	Summarize(ctx context.Context, q SummaryQuery) ([]SummaryRow, error)
+}
+
+// Validate checks the query and sets its defaults: monthly periods in UTC.
+func (q *SummaryQuery) Validate() error {
+	v := Violations{}
+	q.Filter.validate(&v)
+	q.Filter.ExcludeTransfers = true
+	if q.Period == "" {
+		q.Period = "MONTH"
+	}
+	if !containsString(SummaryPeriods, q.Period) {
+		v.Add("period", fmt.Errorf("period should be one of %s", strings.Join(SummaryPeriods, ", ")))
+	}
+	if !containsString(SummaryGroups, q.GroupBy) {
+		v.Add("group_by", fmt.Errorf("cannot group a summary by '%s'", q.GroupBy))
+	}
+	if q.Location == nil {
+		q.Location = time.UTC
+	}
+	return v.Err()
+}
+
+// PeriodStart is the first instant of the period of t in loc.
+func PeriodStart(t time.Time, period string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	year, month, day := t.Date()
+	switch period {
+	case "DAY":
+	case "WEEK":
+		day -= (int(t.Weekday()) + 6) % 7
+	case "YEAR":
+		month, day = time.January, 1
+	default:
+		day = 1
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
+
+// Summarizer builds the rows of a summary out of records or partial
+// totals, for stores that cannot group by period themselves.
+type Summarizer struct {
+	q    SummaryQuery
+	rows map[summaryKey]*SummaryRow
+}
+
+type summaryKey struct {
+	start    int64
+	group    string
+	currency string
+}
+
+// NewSummarizer starts the summary of a validated query.
+func NewSummarizer(q SummaryQuery) *Summarizer {
+	return &Summarizer{q: q, rows: map[summaryKey]*SummaryRow{}}
+}
+
+// AddRecord counts a record matching the query filter.
+func (s *Summarizer) AddRecord(r Record) {
+	groups := []string{""}
+	switch s.q.GroupBy {
+	case "CATEGORY":
+		groups[0] = hexOrEmpty(r.CategoryId)
+	case "ACCOUNT":
+		groups[0] = hexOrEmpty(r.AccountId)
+	case "TAG":
+		if len(r.Tags) > 0 {
+			groups = r.Tags
+		}
+	}
+	for _, group := range groups {
+		s.Add(r.Date, r.Type, group, r.Amount, 1)
+	}
+}
+
+// Add counts the total amount of count records of a type dated in the
+// period of date.
+func (s *Summarizer) Add(date time.Time, recordType, group string, amount Money, count int64) {
+	start := PeriodStart(date, s.q.Period, s.q.Location)
+	key := summaryKey{start: start.UnixMilli(), group: group, currency: amount.Currency}
+	row, ok := s.rows[key]
+	if !ok {
+		row = &SummaryRow{Start: start, Group: group, Currency: amount.Currency}
+		s.rows[key] = row
+	}
+	if recordType == "INCOME" {
+		row.Income += amount.Units
+	} else {
+		row.Expense += amount.Units
+	}
+	row.Count += count
+}
+
+// Rows returns the rows in the order of SummaryStore.Summarize.
+func (s *Summarizer) Rows() []SummaryRow {
+	rows := []SummaryRow{}
+	for _, row := range s.rows {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.Currency < b.Currency
+	})
+	return rows
+}
+
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxTagsPerRecord = 20
+	maxTagLength     = 64
+)
+
+// TagCount is a tag of a user with the number of records carrying it.
+type TagCount struct {
+	Tag     string
+	Records int64
+}
+
+// TagStore queries and maintains the tags of a user's records.
+type TagStore interface {
+	// ListTags returns the user's tags, most used first.
+	ListTags(ctx context.Context, userId primitive.ObjectID) ([]TagCount, error)
+	// RenameTag replaces the tag from by to on every record of the user,
+	// merging them on records carrying both, and returns the number of
+	// records changed, each with an UPDATED revision. Budgets of the tag
+	// follow it.
+	RenameTag(ctx context.Context, userId primitive.ObjectID, from, to string) (int64, error)
+}
+
+// NormalizeTag trims and lowercases a tag so tags compare case-insensitively.
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	switch {
+	case tag == "":
+		return tag, fmt.Errorf("tags should not be empty")
+	case utf8.RuneCountInString(tag) > maxTagLength:
+		return tag, fmt.Errorf("tag '%s' should not be longer than %d characters", tag, maxTagLength)
+	case strings.IndexFunc(tag, unicode.IsControl) >= 0 || strings.Contains(tag, ","):
+		return tag, fmt.Errorf("tag '%s' should not contain commas or control characters", tag)
+	}
+	return tag, nil
+}
+
+// NormalizeTags normalizes every tag and returns them sorted without duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTagsPerRecord {
+		return nil, fmt.Errorf("a record should not have more than %d tags", MaxTagsPerRecord)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// RenameTagIn returns tags with from replaced by to, still normalized.
+func RenameTagIn(tags []string, from, to string) []string {
+	renamed := []string{}
+	for _, tag := range tags {
+		if tag == from {
+			tag = to
+		}
+		if !containsString(renamed, tag) {
+			renamed = append(renamed, tag)
+		}
+	}
+	sort.Strings(renamed)
+	return renamed
+}
+
+// CheckRename normalizes the tags of a RenameTag call.
+func CheckRename(from, to string) (string, string, error) {
+	v := Violations{}
+	from, err := NormalizeTag(from)
+	v.Add("from", err)
+	to, err = NormalizeTag(to)
+	v.Add("to", err)
+	return from, to, v.Err()
+}
+
+// hasTags reports whether tags contains any or, when all is set, every
+// one of the wanted tags.
+func hasTags(tags, wanted []string, all bool) bool {
+	for _, w := range wanted {
+		if containsString(tags, w) != all {
+			return !all
+		}
+	}
+	return all
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	minRecordDate = time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxRecordDate = time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Now returns the current time at the millisecond precision every store keeps.
+func Now() time.Time {
+	return NormalizeTime(time.Now())
+}
+
+// NormalizeTime converts t to UTC and drops anything below milliseconds,
+// so times read back from any store equal the ones written.
+func NormalizeTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Millisecond)
+}
+
+func checkDate(t time.Time) error {
+	if t.IsZero() {
+		return fmt.Errorf("date is required")
+	}
+	if t.Before(minRecordDate) || !t.Before(maxRecordDate) {
+		return fmt.Errorf("date should be between %s and %s", minRecordDate.Format(time.DateOnly), maxRecordDate.Format(time.DateOnly))
+	}
+	return nil
+}
+
+// legacyTimeLayouts are the formats dates and timestamps were stored with
+// while they were free-form strings.
+var legacyTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+	"02-01-2006",
+}
+
+// LegacyTime converts a value stored before times were typed, falling back
+// when it is missing or cannot be parsed.
+func LegacyTime(v any, fallback time.Time) time.Time {
+	switch v := v.(type) {
+	case time.Time:
+		return NormalizeTime(v)
+	case primitive.DateTime:
+		return NormalizeTime(v.Time())
+	case string:
+		for _, layout := range legacyTimeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return NormalizeTime(t)
+			}
+		}
+	}
+	return NormalizeTime(fallback)
+}
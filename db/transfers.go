@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Transfer moves money between two accounts of a user. It is stored as a
+// pair of records sharing its ID as TransferId: an EXPENSE debiting the
+// From account and an INCOME crediting the To account. Account totals
+// keep both records apart from the user's income and expenses.
+type Transfer struct {
+	ID            primitive.ObjectID
+	UserId        primitive.ObjectID
+	FromAccountId primitive.ObjectID
+	ToAccountId   primitive.ObjectID
+	Amount        Money
+	Date          time.Time
+	Title         string
+	Description   string
+}
+
+// TransferStore writes transfers. Once created, the records of a transfer
+// are read and changed through the RecordStore, which keeps both sides
+// consistent: TransferFields written on one side are copied to the other
+// and deleting one side deletes the other.
+type TransferStore interface {
+	// CreateTransfer atomically inserts the debit and credit records of t,
+	// setting t.ID, and returns them.
+	CreateTransfer(ctx context.Context, t *Transfer) (debit Record, credit Record, err error)
+}
+
+// TransferFields are the record fields both sides of a transfer share.
+var TransferFields = []string{"date", "title", "description", "amount"}
+
+// IsTransfer reports whether r is one side of a transfer.
+func (r Record) IsTransfer() bool {
+	return !r.TransferId.IsZero()
+}
+
+// Records returns the debit and credit records of t, without ids.
+func (t Transfer) Records() (Record, Record) {
+	debit := Record{
+		UserId:      t.UserId,
+		Type:        "EXPENSE",
+		Date:        t.Date,
+		Title:       t.Title,
+		Description: t.Description,
+		Amount:      t.Amount,
+		Tags:        []string{},
+		AccountId:   t.FromAccountId,
+		TransferId:  t.ID,
+	}
+	credit := debit
+	credit.Type = "INCOME"
+	credit.AccountId = t.ToAccountId
+	return debit, credit
+}
+
+// Validate checks the fields of a transfer being created and normalizes
+// its date, refs looks up its accounts.
+func (t *Transfer) Validate(refs RecordRefs) error {
+	v := Violations{}
+	t.Date = NormalizeTime(t.Date)
+	v.Add("date", checkDate(t.Date))
+	v.Add("amount.currency_code", t.Amount.Validate())
+	if t.Amount.Units <= 0 {
+		v.Add("amount.units", fmt.Errorf("a transfer should move a positive amount"))
+	}
+	if t.FromAccountId == t.ToAccountId && !t.FromAccountId.IsZero() {
+		v.Add("to_account_id", fmt.Errorf("a transfer needs two different accounts"))
+	}
+	accounts := []struct {
+		field string
+		id    primitive.ObjectID
+	}{{"from_account_id", t.FromAccountId}, {"to_account_id", t.ToAccountId}}
+	for _, a := range accounts {
+		if a.id.IsZero() {
+			v.Add(a.field, fmt.Errorf("a transfer needs an account on both sides"))
+			continue
+		}
+		account, err := refs.Account(t.UserId, a.id)
+		switch {
+		case errors.Is(err, ErrNotFound):
+			v.Add(a.field, fmt.Errorf("account '%s' does not exist", a.id.Hex()))
+		case err != nil:
+			return err
+		case account.Currency != t.Amount.Currency:
+			v.Add(a.field, fmt.Errorf("account '%s' holds %s, not %s", account.Name, account.Currency, t.Amount.Currency))
+		}
+	}
+	return v.Err()
+}
+
+// CheckTransferSide verifies updated, a record of a transfer with the
+// fields of an update applied, still pairs with other, the stored record
+// on the other side, and returns the fields to copy to other.
+func CheckTransferSide(stored, updated, other Record, fields []string, refs RecordRefs) ([]string, error) {
+	v := Violations{}
+	if updated.Type != stored.Type {
+		v.Add("type", fmt.Errorf("the type of a transfer record cannot change"))
+	}
+	switch {
+	case updated.AccountId.IsZero():
+		v.Add("account_id", fmt.Errorf("transfer records must be booked on an account"))
+	case updated.AccountId == other.AccountId:
+		v.Add("account_id", fmt.Errorf("a transfer needs two different accounts"))
+	}
+	if updated.Amount.Currency != other.Amount.Currency {
+		a, err := refs.Account(other.UserId, other.AccountId)
+		if err != nil {
+			return nil, err
+		}
+		if a.Currency != updated.Amount.Currency {
+			v.Add("amount.currency_code", fmt.Errorf("account '%s' on the other side of the transfer holds %s", a.Name, a.Currency))
+		}
+	}
+	shared := []string{}
+	for _, field := range fields {
+		if containsString(TransferFields, field) {
+			shared = append(shared, field)
+		}
+	}
+	return shared, v.Err()
+}
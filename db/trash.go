@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultTrashRetention is how long deleted records stay in the trash
+// unless configured otherwise.
+const DefaultTrashRetention = 30 * 24 * time.Hour
+
+// TrashSort orders the trash, the most recently deleted records first.
+var TrashSort = RecordSort{Field: SortByDeletedAt}
+
+// TrashStore keeps the deleted records, see RecordStore.Delete, until they
+// are restored or purged. The records of the trash keep their id and the
+// other side of a transfer goes everywhere with its record.
+type TrashStore interface {
+	// ListTrash returns the page of the user's deleted records selected
+	// by q, sorted by TrashSort whatever its sort and filter.
+	ListTrash(ctx context.Context, q RecordQuery) (RecordPage, error)
+	// RestoreRecord moves the user's deleted record back among the records
+	// and returns it, see CheckRestore. It returns ErrNotFound when the
+	// trash has no such record and ErrAlreadyExists when the occurrence or
+	// import id of the record were recorded again since.
+	RestoreRecord(ctx context.Context, userId, id primitive.ObjectID) (Record, error)
+	// PurgeRecord removes the user's deleted record for good or returns
+	// ErrNotFound.
+	PurgeRecord(ctx context.Context, userId, id primitive.ObjectID) error
+	// PurgeTrash removes the records of every user deleted before the
+	// given time and returns how many.
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+}
+
+// CheckRestore prepares a deleted record to go back among the records at
+// now: a category deleted or changed since is dropped, which makes a new
+// version of the record, while an account that no longer holds the record
+// fails the restore with ErrFailedPrecondition.
+func CheckRestore(r Record, refs RecordRefs, now time.Time) (Record, error) {
+	if !r.CategoryId.IsZero() {
+		c, err := refs.Category(r.UserId, r.CategoryId)
+		switch {
+		case errors.Is(err, ErrNotFound), err == nil && !c.Accepts(r.Type):
+			r.CategoryId = primitive.NilObjectID
+			r.UpdatedAt = now
+			r.Version++
+		case err != nil:
+			return r, err
+		}
+	}
+	err := refs.Check(r)
+	var validation *ValidationError
+	if errors.As(err, &validation) {
+		return r, fmt.Errorf("%w: record %s cannot be restored, %s", ErrFailedPrecondition, r.ID.Hex(), validation.Violations[0].Description)
+	}
+	r.DeletedAt = time.Time{}
+	return r, err
+}
@@ -0,0 +1,162 @@
+package exports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CSVColumns are the columns a CSV export can have. date is the day in
+// the export location and timestamp the instant in RFC 3339, amount is
+// unsigned and signed_amount negative for expenses. category and account
+// are names, tags are separated by ";".
+var CSVColumns = []string{
+	"id", "date", "timestamp", "type", "title", "description", "amount", "signed_amount", "currency_code",
+	"category_id", "category", "tags", "account_id", "account", "transfer_id", "schedule_id", "import_id",
+	"created_at", "updated_at",
+}
+
+// DefaultCSVColumns are the columns of a CSV export when none are given,
+// the imports package reads them back with a default mapping.
+var DefaultCSVColumns = []string{"date", "type", "title", "description", "signed_amount", "currency_code", "category", "tags", "account"}
+
+// CSV writes records as the rows of a CSV file with a header.
+type CSV struct {
+	Columns   []string
+	Delimiter rune
+	// Location is where the dates are, UTC when nil
+	Location *time.Location
+	// Categories and Accounts name the ids of the category and account
+	// columns
+	Categories map[primitive.ObjectID]string
+	Accounts   map[primitive.ObjectID]string
+}
+
+// Validate checks the columns and sets the defaults.
+func (f *CSV) Validate() error {
+	v := db.Violations{}
+	if len(f.Columns) == 0 {
+		f.Columns = DefaultCSVColumns
+	}
+	for _, column := range f.Columns {
+		if !containsString(CSVColumns, column) {
+			v.Add("columns", fmt.Errorf("'%s' is not a CSV column, columns are %s", column, strings.Join(CSVColumns, ", ")))
+		}
+	}
+	if f.Delimiter == 0 {
+		f.Delimiter = ','
+	}
+	if f.Delimiter == '"' || f.Delimiter == '\r' || f.Delimiter == '\n' || f.Delimiter == utf8.RuneError {
+		v.Add("delimiter", fmt.Errorf("'%c' cannot delimit columns", f.Delimiter))
+	}
+	if f.Location == nil {
+		f.Location = time.UTC
+	}
+	return v.Err()
+}
+
+// NeedsNames reports whether the columns name categories or accounts.
+func (f *CSV) NeedsNames() (categories, accounts bool) {
+	return containsString(f.Columns, "category"), containsString(f.Columns, "account")
+}
+
+func (f *CSV) Begin(w io.Writer) error {
+	return f.write(w, f.Columns)
+}
+
+func (f *CSV) Write(w io.Writer, r db.Record) error {
+	cells := make([]string, len(f.Columns))
+	for i, column := range f.Columns {
+		cells[i] = f.cell(r, column)
+	}
+	return f.write(w, cells)
+}
+
+func (f *CSV) End(w io.Writer) error {
+	return nil
+}
+
+func (f *CSV) cell(r db.Record, column string) string {
+	switch column {
+	case "id":
+		return r.ID.Hex()
+	case "date":
+		return r.Date.In(f.Location).Format("2006-01-02")
+	case "timestamp":
+		return r.Date.In(f.Location).Format(time.RFC3339)
+	case "type":
+		return r.Type
+	case "title":
+		return r.Title
+	case "description":
+		return r.Description
+	case "amount":
+		return r.Amount.Decimal()
+	case "signed_amount":
+		return signedAmount(r).Decimal()
+	case "currency_code":
+		return r.Amount.Currency
+	case "category_id":
+		return hexOrEmpty(r.CategoryId)
+	case "category":
+		return f.Categories[r.CategoryId]
+	case "tags":
+		return strings.Join(r.Tags, ";")
+	case "account_id":
+		return hexOrEmpty(r.AccountId)
+	case "account":
+		return f.Accounts[r.AccountId]
+	case "transfer_id":
+		return hexOrEmpty(r.TransferId)
+	case "schedule_id":
+		return hexOrEmpty(r.ScheduleId)
+	case "import_id":
+		return r.ImportId
+	case "created_at":
+		return r.CreatedAt.In(f.Location).Format(time.RFC3339)
+	case "updated_at":
+		return r.UpdatedAt.In(f.Location).Format(time.RFC3339)
+	}
+	return ""
+}
+
+func (f *CSV) write(w io.Writer, cells []string) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = f.Delimiter
+	if err := cw.Write(cells); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// signedAmount is the amount of the record, negative for expenses.
+func signedAmount(r db.Record) db.Money {
+	m := r.Amount
+	if r.Type == "EXPENSE" {
+		m.Units = -m.Units
+	}
+	return m
+}
+
+func hexOrEmpty(id primitive.ObjectID) string {
+	if id.IsZero() {
+		return ""
+	}
+	return id.Hex()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+// Package exports writes records into files users can take elsewhere.
+package exports
+
+import (
+	"io"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+// Format writes records one at a time so an export holds a single record
+// in memory. Begin starts a new file and End completes it, an export
+// resumed in the middle of a file skips Begin.
+type Format interface {
+	Begin(w io.Writer) error
+	Write(w io.Writer, r db.Record) error
+	End(w io.Writer) error
+}
+
+// NDJSON writes every record as a JSON object on its own line.
+type NDJSON struct {
+	// Marshal encodes a record as JSON on a single line
+	Marshal func(r db.Record) ([]byte, error)
+}
+
+func (f *NDJSON) Begin(w io.Writer) error {
+	return nil
+}
+
+func (f *NDJSON) Write(w io.Writer, r db.Record) error {
+	b, err := f.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+func (f *NDJSON) End(w io.Writer) error {
+	return nil
+}
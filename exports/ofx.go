@@ -0,0 +1,89 @@
+package exports
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ofxNameLength is the longest NAME an OFX transaction can have.
+const ofxNameLength = 32
+
+var ofxEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// OFX writes records as the transactions of an OFX 2 bank statement. A
+// statement holds a single currency so every record must be in Currency.
+// The FITID of a transaction is the import id of its record, or its id,
+// so the transactions keep their FITID from one export to the next.
+type OFX struct {
+	Currency string
+	// AccountId is the ACCTID of the statement, "all" when zero
+	AccountId primitive.ObjectID
+	// From and To bound the dates of the statement, from the start of time
+	// and until now when zero
+	From time.Time
+	To   time.Time
+}
+
+func (f *OFX) Begin(w io.Writer) error {
+	account := "all"
+	if !f.AccountId.IsZero() {
+		account = f.AccountId.Hex()
+	}
+	to := f.To
+	if to.IsZero() {
+		to = db.Now()
+	}
+	_, err := fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS><DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>0</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>
+<STMTRS><CURDEF>%s</CURDEF>
+<BANKACCTFROM><BANKID>journals</BANKID><ACCTID>%s</ACCTID><ACCTTYPE>CHECKING</ACCTTYPE></BANKACCTFROM>
+<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>
+`, ofxDate(db.Now()), f.Currency, account, ofxDate(f.From), ofxDate(to))
+	return err
+}
+
+func (f *OFX) Write(w io.Writer, r db.Record) error {
+	if r.Amount.Currency != f.Currency {
+		return fmt.Errorf("record %s is in %s, not in the %s of the statement", r.ID.Hex(), r.Amount.Currency, f.Currency)
+	}
+	kind := "CREDIT"
+	if r.Type == "EXPENSE" {
+		kind = "DEBIT"
+	}
+	fitId := r.ImportId
+	if fitId == "" {
+		fitId = r.ID.Hex()
+	}
+	name := r.Title
+	if len([]rune(name)) > ofxNameLength {
+		name = string([]rune(name)[:ofxNameLength])
+	}
+	memo := ""
+	if r.Description != "" {
+		memo = "<MEMO>" + ofxEscaper.Replace(r.Description) + "</MEMO>"
+	}
+	_, err := fmt.Fprintf(w, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME>%s</STMTTRN>\n",
+		kind, ofxDate(r.Date), signedAmount(r).Decimal(), ofxEscaper.Replace(fitId), ofxEscaper.Replace(name), memo)
+	return err
+}
+
+func (f *OFX) End(w io.Writer) error {
+	_, err := io.WriteString(w, "</BANKTRANLIST>\n</STMTRS></STMTTRNRS></BANKMSGSRSV1>\n</OFX>\n")
+	return err
+}
+
+// ofxDate formats t in UTC with its milliseconds, as OFX dates are.
+func ofxDate(t time.Time) string {
+	if t.IsZero() {
+		t = time.Unix(0, 0)
+	}
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
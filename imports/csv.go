@@ -0,0 +1,299 @@
+package imports
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+// Signs are the conventions for the sign of CSV amounts.
+var Signs = []string{"NEGATIVE_IS_EXPENSE", "POSITIVE_IS_EXPENSE", "UNSIGNED"}
+
+var (
+	defaultIncomeValues  = []string{"income", "credit", "cr"}
+	defaultExpenseValues = []string{"expense", "debit", "dr"}
+)
+
+// CSVMapping tells which columns of a CSV file hold the fields of a
+// record and how to read them. Columns are numbered from 1, 0 when the
+// file has none.
+type CSVMapping struct {
+	Delimiter rune
+	HasHeader bool
+
+	DateColumn        int
+	AmountColumn      int
+	TypeColumn        int
+	TitleColumn       int
+	DescriptionColumn int
+	TagsColumn        int
+	CurrencyColumn    int
+
+	// DateFormat is made of the tokens YYYY, YY, MM, M, DD, D, HH, mm
+	// and ss, "YYYY-MM-DD" by default. Dates are read in Location.
+	DateFormat string
+	Location   *time.Location
+	// DecimalSeparator is "." or ",", ThousandsSeparator is dropped
+	DecimalSeparator   string
+	ThousandsSeparator string
+	// Sign is one of Signs. Without a type column the sign of the amount
+	// gives the type of the record, with one the amount is taken unsigned.
+	Sign string
+	// IncomeValues and ExpenseValues are the values of the type column,
+	// compared case-insensitively
+	IncomeValues  []string
+	ExpenseValues []string
+	// TagSeparator splits the tags column, ";" by default
+	TagSeparator string
+	// Currency is used when there is no currency column or its cell is
+	// empty
+	Currency string
+}
+
+// Validate checks the mapping and sets its defaults.
+func (m *CSVMapping) Validate() error {
+	v := db.Violations{}
+	if m.Delimiter == 0 {
+		m.Delimiter = ','
+	}
+	if m.Delimiter == '"' || m.Delimiter == '\r' || m.Delimiter == '\n' || m.Delimiter == utf8.RuneError {
+		v.Add("mapping.delimiter", fmt.Errorf("'%c' cannot delimit columns", m.Delimiter))
+	}
+	columns := map[string]int{
+		"mapping.date_column":        m.DateColumn,
+		"mapping.amount_column":      m.AmountColumn,
+		"mapping.type_column":        m.TypeColumn,
+		"mapping.title_column":       m.TitleColumn,
+		"mapping.description_column": m.DescriptionColumn,
+		"mapping.tags_column":        m.TagsColumn,
+		"mapping.currency_column":    m.CurrencyColumn,
+	}
+	for field, column := range columns {
+		if column < 0 {
+			v.Add(field, fmt.Errorf("columns are numbered from 1"))
+		}
+	}
+	if m.DateColumn == 0 {
+		v.Add("mapping.date_column", fmt.Errorf("date column is required"))
+	}
+	if m.AmountColumn == 0 {
+		v.Add("mapping.amount_column", fmt.Errorf("amount column is required"))
+	}
+	if m.DateFormat == "" {
+		m.DateFormat = "YYYY-MM-DD"
+	}
+	if _, err := dateLayout(m.DateFormat); err != nil {
+		v.Add("mapping.date_format", err)
+	}
+	if m.Location == nil {
+		m.Location = time.UTC
+	}
+	if m.DecimalSeparator == "" {
+		m.DecimalSeparator = "."
+	}
+	if m.DecimalSeparator != "." && m.DecimalSeparator != "," {
+		v.Add("mapping.decimal_separator", fmt.Errorf("decimal separator should be '.' or ','"))
+	} else if m.ThousandsSeparator == m.DecimalSeparator {
+		v.Add("mapping.thousands_separator", fmt.Errorf("thousands separator should differ from the decimal separator"))
+	}
+	if m.Sign == "" {
+		m.Sign = "NEGATIVE_IS_EXPENSE"
+	}
+	if !containsString(Signs, m.Sign) {
+		v.Add("mapping.sign", fmt.Errorf("sign should be one of %s", strings.Join(Signs, ", ")))
+	} else if m.Sign == "UNSIGNED" && m.TypeColumn == 0 {
+		v.Add("mapping.type_column", fmt.Errorf("unsigned amounts need a type column"))
+	}
+	if len(m.IncomeValues) == 0 {
+		m.IncomeValues = defaultIncomeValues
+	}
+	if len(m.ExpenseValues) == 0 {
+		m.ExpenseValues = defaultExpenseValues
+	}
+	if m.TagSeparator == "" {
+		m.TagSeparator = ";"
+	}
+	if m.CurrencyColumn == 0 && m.Currency == "" {
+		m.Currency = db.DefaultCurrency
+	}
+	if m.Currency != "" {
+		v.Add("mapping.currency_code", db.Money{Currency: m.Currency}.Validate())
+	}
+	return v.Err()
+}
+
+// CSVSource reads records out of a CSV file.
+type CSVSource struct {
+	m      CSVMapping
+	layout string
+	r      *csv.Reader
+	first  bool
+}
+
+// NewCSVSource reads r with the mapping, which must have been validated.
+func NewCSVSource(r io.Reader, m CSVMapping) *CSVSource {
+	reader := csv.NewReader(r)
+	reader.Comma = m.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+	layout, _ := dateLayout(m.DateFormat)
+	return &CSVSource{m: m, layout: layout, r: reader, first: true}
+}
+
+func (s *CSVSource) Next() (Row, error) {
+	for {
+		cells, err := s.r.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) && parseErr.Err != csv.ErrFieldCount {
+			s.first = false
+			return Row{Line: parseErr.StartLine, Err: parseErr.Err}, nil
+		}
+		if err != nil {
+			return Row{}, err
+		}
+		line, _ := s.r.FieldPos(0)
+		if s.first {
+			s.first = false
+			cells[0] = strings.TrimPrefix(cells[0], "\ufeff")
+			if s.m.HasHeader {
+				continue
+			}
+		}
+		return s.row(line, cells), nil
+	}
+}
+
+// row maps the cells of a line onto a record.
+func (s *CSVSource) row(line int, cells []string) Row {
+	v := db.Violations{}
+	// spreadsheets drop the empty cells ending a line
+	cell := func(column int) string {
+		if column == 0 || column > len(cells) {
+			return ""
+		}
+		return strings.TrimSpace(cells[column-1])
+	}
+	r := db.Record{
+		Title:       cell(s.m.TitleColumn),
+		Description: cell(s.m.DescriptionColumn),
+	}
+	date := cell(s.m.DateColumn)
+	if date == "" {
+		v.Add("date", fmt.Errorf("date is required"))
+	} else {
+		var err error
+		if r.Date, err = time.ParseInLocation(s.layout, date, s.m.Location); err != nil {
+			v.Add("date", fmt.Errorf("'%s' does not match the date format %s", date, s.m.DateFormat))
+		}
+	}
+	currency := cell(s.m.CurrencyColumn)
+	if currency == "" {
+		currency = s.m.Currency
+	}
+	amount, err := s.amount(cell(s.m.AmountColumn), strings.ToUpper(currency))
+	v.Add("amount", err)
+	r.Amount = amount
+	r.Type = "EXPENSE"
+	if s.m.TypeColumn > 0 {
+		recordType := cell(s.m.TypeColumn)
+		switch {
+		case containsFold(s.m.IncomeValues, recordType):
+			r.Type = "INCOME"
+		case containsFold(s.m.ExpenseValues, recordType):
+		default:
+			v.Add("type", fmt.Errorf("'%s' is neither an income nor an expense value", recordType))
+		}
+		if r.Amount.Units < 0 {
+			r.Amount.Units = -r.Amount.Units
+		}
+	} else {
+		negative := r.Amount.Units < 0
+		if negative == (s.m.Sign == "POSITIVE_IS_EXPENSE") {
+			r.Type = "INCOME"
+		}
+		if negative {
+			r.Amount.Units = -r.Amount.Units
+		}
+	}
+	if tags := cell(s.m.TagsColumn); tags != "" {
+		r.Tags = strings.Split(tags, s.m.TagSeparator)
+	}
+	return Row{Line: line, Record: r, Err: v.Err()}
+}
+
+// amount parses a localized amount, parentheses mark negative ones as in
+// accounting exports.
+func (s *CSVSource) amount(text, currency string) (db.Money, error) {
+	if text == "" {
+		return db.Money{Currency: currency}, fmt.Errorf("amount is required")
+	}
+	normalized := text
+	if strings.HasPrefix(normalized, "(") && strings.HasSuffix(normalized, ")") {
+		normalized = "-" + strings.TrimSuffix(strings.TrimPrefix(normalized, "("), ")")
+	}
+	if s.m.ThousandsSeparator != "" {
+		normalized = strings.ReplaceAll(normalized, s.m.ThousandsSeparator, "")
+	}
+	normalized = strings.ReplaceAll(normalized, s.m.DecimalSeparator, ".")
+	m, err := db.ParseMoney(normalized, currency)
+	if err != nil {
+		return m, fmt.Errorf("'%s' is not an amount in %s", text, currency)
+	}
+	return m, nil
+}
+
+var dateTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"}, {"YY", "06"}, {"MM", "01"}, {"M", "1"}, {"DD", "02"}, {"D", "2"},
+	{"HH", "15"}, {"mm", "04"}, {"ss", "05"},
+}
+
+// dateLayout translates a date format into a time layout.
+func dateLayout(format string) (string, error) {
+	layout := strings.Builder{}
+	hasDate := false
+next:
+	for i := 0; i < len(format); {
+		for _, t := range dateTokens {
+			if strings.HasPrefix(format[i:], t.token) {
+				layout.WriteString(t.layout)
+				hasDate = hasDate || t.token[0] == 'D'
+				i += len(t.token)
+				continue next
+			}
+		}
+		if c := format[i]; c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' {
+			return "", fmt.Errorf("'%c' is not a date format token", c)
+		}
+		layout.WriteByte(format[i])
+		i++
+	}
+	if !hasDate {
+		return "", fmt.Errorf("date format should have a day")
+	}
+	return layout.String(), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(strings.TrimSpace(v), value) {
+			return true
+		}
+	}
+	return false
+}
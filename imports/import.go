@@ -0,0 +1,173 @@
+// Package imports reads records out of spreadsheet exports and bank
+// statements and creates them in batches.
+package imports
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BatchSize is the number of records created at once.
+const BatchSize = 100
+
+// MaxReportedErrors bounds the row errors of a Result, ErrorCount keeps
+// counting past it.
+const MaxReportedErrors = 100
+
+// Row is a record read from a file, or why it could not be read.
+type Row struct {
+	// Line is the line of the file the row starts on, counting from 1
+	Line   int
+	Record db.Record
+	Err    error
+}
+
+// Source reads the rows of a file until io.EOF, any other error ends the
+// import.
+type Source interface {
+	Next() (Row, error)
+}
+
+// RowError is a problem with a field of a row, Field is empty when the
+// whole row could not be read.
+type RowError struct {
+	Line        int
+	Field       string
+	Description string
+}
+
+// Result sums up an import, or what it would do on a dry run.
+type Result struct {
+	Rows     int
+	Imported int
+	// Duplicates counts the rows skipped because their import id was
+	// already imported, or seen earlier in the file
+	Duplicates int
+	ErrorCount int
+	Errors     []RowError
+}
+
+// Options apply to every row of an import.
+type Options struct {
+	UserId primitive.ObjectID
+	// Account receives every record when its ID is set, rows in another
+	// currency are rejected
+	Account db.Account
+	// DryRun validates the rows without creating any record
+	DryRun bool
+}
+
+// Import validates every row of src and creates the valid ones in batches
+// of BatchSize. The invalid rows are reported in the result and skipped,
+// as are the rows with an import id taken on the account so importing a
+// statement twice creates its records once. A batch failing to be created
+// ends the import, the earlier batches are kept.
+func Import(ctx context.Context, store db.RecordStore, src Source, opts Options) (Result, error) {
+	result := Result{}
+	batch := []db.Record{}
+	seen := map[string]bool{}
+	flush := func() error {
+		fresh, err := dropImported(ctx, store, opts, batch)
+		if err != nil {
+			return err
+		}
+		if len(fresh) > 0 && !opts.DryRun {
+			if err := store.CreateMany(ctx, fresh); err != nil {
+				return err
+			}
+		}
+		result.Duplicates += len(batch) - len(fresh)
+		result.Imported += len(fresh)
+		batch = batch[:0]
+		return nil
+	}
+	for {
+		row, err := src.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+		result.Rows++
+		if row.Err == nil {
+			row.Err = prepare(&row.Record, opts)
+		}
+		if row.Err != nil {
+			result.addError(row.Line, row.Err)
+			continue
+		}
+		if id := row.Record.ImportId; id != "" {
+			if seen[id] {
+				result.Duplicates++
+				continue
+			}
+			seen[id] = true
+		}
+		batch = append(batch, row.Record)
+		if len(batch) == BatchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, flush()
+}
+
+// dropImported leaves out the records of the batch whose import id is
+// taken on the account.
+func dropImported(ctx context.Context, store db.RecordStore, opts Options, batch []db.Record) ([]db.Record, error) {
+	ids := []string{}
+	for _, r := range batch {
+		if r.ImportId != "" {
+			ids = append(ids, r.ImportId)
+		}
+	}
+	if len(ids) == 0 {
+		return batch, nil
+	}
+	taken, err := store.ImportedIds(ctx, opts.UserId, opts.Account.ID, ids)
+	if err != nil {
+		return nil, err
+	}
+	fresh := []db.Record{}
+	for _, r := range batch {
+		if r.ImportId == "" || !taken[r.ImportId] {
+			fresh = append(fresh, r)
+		}
+	}
+	return fresh, nil
+}
+
+// prepare sets the owner and account of a record read from a file and
+// validates it.
+func prepare(r *db.Record, opts Options) error {
+	r.UserId = opts.UserId
+	r.AccountId = opts.Account.ID
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if !r.AccountId.IsZero() && r.Amount.Currency != opts.Account.Currency {
+		return db.InvalidField("amount.currency_code", fmt.Errorf("account '%s' holds %s, not %s", opts.Account.Name, opts.Account.Currency, r.Amount.Currency))
+	}
+	return nil
+}
+
+func (r *Result) addError(line int, err error) {
+	var validation *db.ValidationError
+	violations := []db.FieldViolation{{Description: err.Error()}}
+	if errors.As(err, &validation) {
+		violations = validation.Violations
+	}
+	r.ErrorCount++
+	for _, v := range violations {
+		if len(r.Errors) < MaxReportedErrors {
+			r.Errors = append(r.Errors, RowError{Line: line, Field: v.Field, Description: v.Description})
+		}
+	}
+}
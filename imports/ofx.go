@@ -0,0 +1,168 @@
+package imports
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+// OFXSource reads the transactions of OFX and QFX statements, in the SGML
+// syntax of OFX 1.x where leaf elements are not closed as well as in the
+// XML of OFX 2.x. Every STMTTRN becomes a record, its FITID the import id.
+// The amounts are taken in the currency of their statement, CURDEF.
+type OFXSource struct {
+	r    *bufio.Reader
+	line int
+	opts StatementOptions
+	// currency is the CURDEF of the statement being read
+	currency string
+}
+
+// NewOFXSource reads r with the options, which must have been validated.
+func NewOFXSource(r io.Reader, opts StatementOptions) *OFXSource {
+	return &OFXSource{r: bufio.NewReader(r), line: 1, opts: opts}
+}
+
+// ofxToken is an opening or closing tag, or the text up to the next tag.
+type ofxToken struct {
+	tag     string
+	closing bool
+	text    string
+}
+
+func (s *OFXSource) Next() (Row, error) {
+	var txn map[string]string
+	start := 0
+	// leaf is the element the next text is the value of
+	leaf := ""
+	for {
+		t, err := s.token()
+		if errors.Is(err, io.EOF) && txn != nil {
+			return Row{Line: start, Err: fmt.Errorf("the transaction is not closed")}, nil
+		}
+		if err != nil {
+			return Row{}, err
+		}
+		switch {
+		case t.text != "":
+			if leaf == "CURDEF" {
+				s.currency = strings.ToUpper(t.text)
+			} else if txn != nil && leaf != "" {
+				txn[leaf] = t.text
+			}
+			leaf = ""
+		case t.closing:
+			leaf = ""
+			if t.tag == "STMTTRN" && txn != nil {
+				return s.row(start, txn), nil
+			}
+		case t.tag == "STMTTRN":
+			txn = map[string]string{}
+			start = s.line
+			leaf = ""
+		default:
+			leaf = t.tag
+		}
+	}
+}
+
+// row maps the elements of a STMTTRN onto a record.
+func (s *OFXSource) row(line int, txn map[string]string) Row {
+	v := db.Violations{}
+	date, err := parseOFXDate(txn["DTPOSTED"], s.opts.Location)
+	v.Add("date", err)
+	currency := s.currency
+	if currency == "" {
+		currency = s.opts.Currency
+	}
+	amount := db.Money{Currency: currency}
+	if txn["TRNAMT"] == "" {
+		v.Add("amount", fmt.Errorf("TRNAMT is required"))
+	} else {
+		amount, err = parseStatementAmount(txn["TRNAMT"], currency)
+		v.Add("amount", err)
+	}
+	r := statementRecord(date, amount, txn["NAME"], txn["MEMO"])
+	if r.ImportId = txn["FITID"]; r.ImportId == "" {
+		v.Add("import_id", fmt.Errorf("FITID is required"))
+	}
+	return Row{Line: line, Record: r, Err: v.Err()}
+}
+
+// token reads the next token of the file, skipping XML declarations,
+// processing instructions and comments.
+func (s *OFXSource) token() (ofxToken, error) {
+	text := strings.Builder{}
+	for {
+		b, err := s.r.ReadByte()
+		if errors.Is(err, io.EOF) && strings.TrimSpace(text.String()) != "" {
+			return ofxToken{text: html.UnescapeString(strings.TrimSpace(text.String()))}, nil
+		}
+		if err != nil {
+			return ofxToken{}, err
+		}
+		if b != '<' {
+			if b == '\n' {
+				s.line++
+			}
+			text.WriteByte(b)
+			continue
+		}
+		if value := strings.TrimSpace(text.String()); value != "" {
+			if err := s.r.UnreadByte(); err != nil {
+				return ofxToken{}, err
+			}
+			return ofxToken{text: html.UnescapeString(value)}, nil
+		}
+		tag, err := s.r.ReadString('>')
+		if err != nil {
+			return ofxToken{}, fmt.Errorf("line %d: unterminated tag: %w", s.line, io.ErrUnexpectedEOF)
+		}
+		s.line += strings.Count(tag, "\n")
+		tag = strings.TrimSuffix(tag, ">")
+		if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+			continue
+		}
+		t := ofxToken{closing: strings.HasPrefix(tag, "/")}
+		if name := strings.Fields(strings.TrimSuffix(strings.TrimPrefix(tag, "/"), "/")); len(name) > 0 {
+			t.tag = strings.ToUpper(name[0])
+		}
+		return t, nil
+	}
+}
+
+var ofxDateLayouts = map[int]string{8: "20060102", 12: "200601021504", 14: "20060102150405"}
+
+// parseOFXDate parses dates such as "20230805", "20230805120000.000" or
+// "20230805120000[-5:EST]", read in loc when they have no offset.
+func parseOFXDate(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("DTPOSTED is required")
+	}
+	digits, zone, hasZone := strings.Cut(value, "[")
+	digits, _, _ = strings.Cut(digits, ".")
+	layout, ok := ofxDateLayouts[len(digits)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("'%s' is not an OFX date", value)
+	}
+	if hasZone {
+		hours, _, _ := strings.Cut(strings.TrimSuffix(zone, "]"), ":")
+		offset, err := strconv.ParseFloat(hours, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("'%s' is not an OFX date", value)
+		}
+		loc = time.FixedZone("", int(offset*3600))
+	}
+	date, err := time.ParseInLocation(layout, digits, loc)
+	if err != nil {
+		return date, fmt.Errorf("'%s' is not an OFX date", value)
+	}
+	return date, nil
+}
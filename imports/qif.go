@@ -0,0 +1,136 @@
+package imports
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+// qifTypes are the QIF account types whose entries are transactions.
+var qifTypes = []string{"BANK", "CASH", "CCARD", "OTH A", "OTH L"}
+
+// QIFSource reads the transactions of QIF files. The entries of other
+// sections, such as investments or category lists, are skipped. QIF has
+// no transaction id so the import id of a record is derived from its
+// date, amount, payee, memo and number, and from how many identical
+// entries precede it in the file.
+type QIFSource struct {
+	scanner *bufio.Scanner
+	line    int
+	opts    StatementOptions
+	// skip is set in the sections which are not transaction lists
+	skip bool
+	seen map[string]int
+}
+
+// NewQIFSource reads r with the options, which must have been validated.
+func NewQIFSource(r io.Reader, opts StatementOptions) *QIFSource {
+	return &QIFSource{scanner: bufio.NewScanner(r), opts: opts, seen: map[string]int{}}
+}
+
+func (s *QIFSource) Next() (Row, error) {
+	var fields map[byte]string
+	start := 0
+	for s.scanner.Scan() {
+		s.line++
+		line := strings.TrimPrefix(s.scanner.Text(), "\ufeff")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if line[0] == '!' {
+			header := strings.ToUpper(strings.TrimSpace(line[1:]))
+			switch {
+			case strings.HasPrefix(header, "TYPE:"):
+				s.skip = !containsString(qifTypes, strings.TrimSpace(header[len("TYPE:"):]))
+			case header == "ACCOUNT":
+				s.skip = true
+			}
+			continue
+		}
+		if s.skip {
+			continue
+		}
+		if fields == nil {
+			fields = map[byte]string{}
+			start = s.line
+		}
+		if line[0] == '^' {
+			return s.row(start, fields), nil
+		}
+		// the splits of an entry repeat their codes, the first is kept
+		if _, ok := fields[line[0]]; !ok {
+			fields[line[0]] = strings.TrimSpace(line[1:])
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		return Row{}, err
+	}
+	if fields != nil {
+		// the last entry may lack its ^
+		return s.row(start, fields), nil
+	}
+	return Row{}, io.EOF
+}
+
+// row maps the fields of an entry onto a record.
+func (s *QIFSource) row(line int, fields map[byte]string) Row {
+	v := db.Violations{}
+	date, err := parseQIFDate(fields['D'], s.opts.DateOrder, s.opts.Location)
+	v.Add("date", err)
+	text := fields['T']
+	if text == "" {
+		text = fields['U']
+	}
+	amount := db.Money{Currency: s.opts.Currency}
+	if text == "" {
+		v.Add("amount", fmt.Errorf("amount is required"))
+	} else {
+		amount, err = parseStatementAmount(text, s.opts.Currency)
+		v.Add("amount", err)
+	}
+	r := statementRecord(date, amount, fields['P'], fields['M'])
+	key := strings.Join([]string{fields['D'], text, fields['P'], fields['M'], fields['N']}, "\x00")
+	s.seen[key]++
+	sum := sha256.Sum256([]byte(key))
+	r.ImportId = fmt.Sprintf("qif:%s:%d", hex.EncodeToString(sum[:12]), s.seen[key])
+	return Row{Line: line, Record: r, Err: v.Err()}
+}
+
+// parseQIFDate parses dates such as "8/5/23", "08/05'2023" or "5.8.2023"
+// in the given order, two digit years are taken in 1970 to 2069.
+func parseQIFDate(value, order string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("date is required")
+	}
+	parts := strings.FieldsFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+	if len(parts) != 3 {
+		return time.Time{}, fmt.Errorf("'%s' is not a %s date", value, order)
+	}
+	numbers := map[byte]int{}
+	for i := range parts {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("'%s' is not a %s date", value, order)
+		}
+		numbers[order[i]] = n
+	}
+	year, month, day := numbers['Y'], numbers['M'], numbers['D']
+	if len(parts[strings.IndexByte(order, 'Y')]) <= 2 {
+		year += 1900
+		if year < 1970 {
+			year += 100
+		}
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, loc)
+	if date.Month() != time.Month(month) || date.Day() != day {
+		return time.Time{}, fmt.Errorf("'%s' is not a %s date", value, order)
+	}
+	return date, nil
+}
@@ -0,0 +1,85 @@
+package imports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+// DateOrders are the orders of the day, month and year in QIF dates.
+var DateOrders = []string{"MDY", "DMY", "YMD"}
+
+// StatementOptions tell how to read the bank statements that leave it
+// open.
+type StatementOptions struct {
+	// Currency of the amounts when the statement does not give it, the
+	// default currency when empty
+	Currency string
+	// Location of the dates without a time zone, UTC when nil
+	Location *time.Location
+	// DateOrder is one of DateOrders, "MDY" when empty
+	DateOrder string
+}
+
+// Validate checks the options and sets their defaults.
+func (o *StatementOptions) Validate() error {
+	v := db.Violations{}
+	if o.Currency == "" {
+		o.Currency = db.DefaultCurrency
+	}
+	v.Add("currency_code", db.Money{Currency: o.Currency}.Validate())
+	if o.Location == nil {
+		o.Location = time.UTC
+	}
+	if o.DateOrder == "" {
+		o.DateOrder = "MDY"
+	}
+	if !containsString(DateOrders, o.DateOrder) {
+		v.Add("date_order", fmt.Errorf("date order should be one of %s", strings.Join(DateOrders, ", ")))
+	}
+	return v.Err()
+}
+
+// statementRecord is a record of a bank statement, an expense when the
+// amount is negative.
+func statementRecord(date time.Time, amount db.Money, title, memo string) db.Record {
+	r := db.Record{Type: "INCOME", Date: date, Amount: amount, Title: title, Description: memo}
+	if r.Amount.Units < 0 {
+		r.Type = "EXPENSE"
+		r.Amount.Units = -r.Amount.Units
+	}
+	if r.Title == "" {
+		r.Title, r.Description = memo, ""
+	}
+	if r.Description == r.Title {
+		r.Description = ""
+	}
+	return r
+}
+
+// parseStatementAmount parses an amount written by a bank, which may use
+// a decimal comma and separate thousands. When both separators appear the
+// last one is the decimal one, a lone comma followed by three digits
+// separates thousands.
+func parseStatementAmount(text, currency string) (db.Money, error) {
+	normalized := strings.ReplaceAll(strings.TrimSpace(text), " ", "")
+	dot, comma := strings.LastIndex(normalized, "."), strings.LastIndex(normalized, ",")
+	switch {
+	case dot >= 0 && comma > dot:
+		normalized = strings.ReplaceAll(normalized, ".", "")
+		normalized = strings.Replace(normalized, ",", ".", 1)
+	case comma >= 0 && dot > comma:
+		normalized = strings.ReplaceAll(normalized, ",", "")
+	case comma >= 0 && strings.Count(normalized, ",") == 1 && len(normalized)-comma-1 != 3:
+		normalized = strings.Replace(normalized, ",", ".", 1)
+	default:
+		normalized = strings.ReplaceAll(normalized, ",", "")
+	}
+	m, err := db.ParseMoney(normalized, currency)
+	if err != nil {
+		return m, fmt.Errorf("'%s' is not an amount in %s", text, currency)
+	}
+	return m, nil
+}
@@ -5,8 +5,13 @@ import (
 	"log"
 	"net"
 	"os"
+	"time"
+	_ "time/tzdata" // GetSummary time zones on hosts without a zoneinfo database
 
+	"github.com/fine-track/journals-app/auth"
 	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/db/memstore"
+	"github.com/fine-track/journals-app/db/sqlstore"
 	"github.com/fine-track/journals-app/services"
 	"github.com/joho/godotenv"
 	"google.golang.org/grpc"
@@ -17,8 +22,15 @@ func main() {
 		panic(err)
 	}
 
-	dbClient := db.ConnectDB()
-	defer func() { dbClient.Disconnect(context.TODO()) }()
+	if currency := os.Getenv("DEFAULT_CURRENCY"); currency != "" {
+		if err := (db.Money{Currency: currency}).Validate(); err != nil {
+			log.Fatalf("invalid DEFAULT_CURRENCY: %v\n", err)
+		}
+		db.DefaultCurrency = currency
+	}
+
+	store, closeStore := openStore()
+	defer closeStore()
 
 	port := os.Getenv("PORT")
 	listener, err := net.Listen("tcp", ":"+port)
@@ -27,11 +39,78 @@ func main() {
 	}
 	log.Printf("listening on: %s", listener.Addr())
 
-	s := grpc.NewServer()
-	services.RegisterRecordsService(s)
+	verifier, err := auth.NewVerifier(auth.ConfigFromEnv())
+	if err != nil {
+		log.Fatalf("failed to set up authentication: %v\n", err)
+	}
+
+	window := services.DefaultIdempotencyWindow
+	if value := os.Getenv("IDEMPOTENCY_WINDOW"); value != "" {
+		if window, err = time.ParseDuration(value); err != nil || window <= 0 {
+			log.Fatalf("invalid IDEMPOTENCY_WINDOW %q, expected a positive duration such as 24h\n", value)
+		}
+	}
+	retention := db.DefaultTrashRetention
+	if value := os.Getenv("TRASH_RETENTION"); value != "" {
+		if retention, err = time.ParseDuration(value); err != nil || retention <= 0 {
+			log.Fatalf("invalid TRASH_RETENTION %q, expected a positive duration such as 720h\n", value)
+		}
+	}
+
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(services.ErrorInterceptor(), verifier.UnaryInterceptor(), services.IdempotencyInterceptor(store, window)),
+		grpc.ChainStreamInterceptor(services.StreamErrorInterceptor(), verifier.StreamInterceptor(), services.StreamIdempotencyInterceptor(store, window)),
+	)
+	services.RegisterRecordsService(s, store)
+	services.RegisterCategoriesService(s, store)
+	services.RegisterTagsService(s, store)
+	services.RegisterAccountsService(s, store)
+	services.RegisterSchedulesService(s, store)
+	services.RegisterBudgetsService(s, store)
+
+	go services.RunMaterializer(context.Background(), store, time.Minute)
+	go services.RunKeyPurger(context.Background(), store, time.Hour)
+	go services.RunTrashPurger(context.Background(), store, retention, time.Hour)
 
 	if err := s.Serve(listener); err != nil {
 		log.Fatalf("failed to serve: %v\n", err)
 	}
 	defer func() { s.Stop() }()
 }
+
+// openStore picks the storage backend from the STORE env variable:
+// "mongodb" (default), "sqlite", "postgres" or "memory".
+func openStore() (db.Store, func()) {
+	switch os.Getenv("STORE") {
+	case "memory":
+		log.Println("using the in-memory store, records are lost on exit")
+		return memstore.New(), func() {}
+	case "sqlite":
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "finetrack.db"
+		}
+		store, err := sqlstore.Open(context.Background(), sqlstore.SQLite, path)
+		if err != nil {
+			log.Fatalf("failed to open sqlite database: %v\n", err)
+		}
+		return store, func() { store.Close() }
+	case "postgres":
+		dsn := os.Getenv("DATABASE_URL")
+		if dsn == "" {
+			log.Fatalln("no postgres DATABASE_URL found")
+		}
+		store, err := sqlstore.Open(context.Background(), sqlstore.Postgres, dsn)
+		if err != nil {
+			log.Fatalf("failed to open postgres database: %v\n", err)
+		}
+		return store, func() { store.Close() }
+	default:
+		dbClient := db.ConnectDB()
+		store := db.NewMongoStore(db.DB)
+		if err := store.Migrate(context.Background()); err != nil {
+			log.Fatalf("failed to migrate mongodb: %v\n", err)
+		}
+		return store, func() { dbClient.Disconnect(context.TODO()) }
+	}
+}
@@ -0,0 +1,294 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.23.4
+// source: budget.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BudgetsService_CreateBudget_FullMethodName    = "/BudgetsService/CreateBudget"
+	BudgetsService_GetBudget_FullMethodName       = "/BudgetsService/GetBudget"
+	BudgetsService_UpdateBudget_FullMethodName    = "/BudgetsService/UpdateBudget"
+	BudgetsService_ListBudgets_FullMethodName     = "/BudgetsService/ListBudgets"
+	BudgetsService_DeleteBudget_FullMethodName    = "/BudgetsService/DeleteBudget"
+	BudgetsService_GetBudgetStatus_FullMethodName = "/BudgetsService/GetBudgetStatus"
+)
+
+// BudgetsServiceClient is the client API for BudgetsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BudgetsServiceClient interface {
+	CreateBudget(ctx context.Context, in *CreateBudgetRequest, opts ...grpc.CallOption) (*BudgetResponse, error)
+	GetBudget(ctx context.Context, in *GetBudgetRequest, opts ...grpc.CallOption) (*BudgetResponse, error)
+	UpdateBudget(ctx context.Context, in *UpdateBudgetRequest, opts ...grpc.CallOption) (*BudgetResponse, error)
+	ListBudgets(ctx context.Context, in *ListBudgetsRequest, opts ...grpc.CallOption) (*ListBudgetsResponse, error)
+	DeleteBudget(ctx context.Context, in *DeleteBudgetRequest, opts ...grpc.CallOption) (*DeleteBudgetResponse, error)
+	GetBudgetStatus(ctx context.Context, in *GetBudgetStatusRequest, opts ...grpc.CallOption) (*GetBudgetStatusResponse, error)
+}
+
+type budgetsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBudgetsServiceClient(cc grpc.ClientConnInterface) BudgetsServiceClient {
+	return &budgetsServiceClient{cc}
+}
+
+func (c *budgetsServiceClient) CreateBudget(ctx context.Context, in *CreateBudgetRequest, opts ...grpc.CallOption) (*BudgetResponse, error) {
+	out := new(BudgetResponse)
+	err := c.cc.Invoke(ctx, BudgetsService_CreateBudget_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *budgetsServiceClient) GetBudget(ctx context.Context, in *GetBudgetRequest, opts ...grpc.CallOption) (*BudgetResponse, error) {
+	out := new(BudgetResponse)
+	err := c.cc.Invoke(ctx, BudgetsService_GetBudget_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *budgetsServiceClient) UpdateBudget(ctx context.Context, in *UpdateBudgetRequest, opts ...grpc.CallOption) (*BudgetResponse, error) {
+	out := new(BudgetResponse)
+	err := c.cc.Invoke(ctx, BudgetsService_UpdateBudget_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *budgetsServiceClient) ListBudgets(ctx context.Context, in *ListBudgetsRequest, opts ...grpc.CallOption) (*ListBudgetsResponse, error) {
+	out := new(ListBudgetsResponse)
+	err := c.cc.Invoke(ctx, BudgetsService_ListBudgets_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *budgetsServiceClient) DeleteBudget(ctx context.Context, in *DeleteBudgetRequest, opts ...grpc.CallOption) (*DeleteBudgetResponse, error) {
+	out := new(DeleteBudgetResponse)
+	err := c.cc.Invoke(ctx, BudgetsService_DeleteBudget_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *budgetsServiceClient) GetBudgetStatus(ctx context.Context, in *GetBudgetStatusRequest, opts ...grpc.CallOption) (*GetBudgetStatusResponse, error) {
+	out := new(GetBudgetStatusResponse)
+	err := c.cc.Invoke(ctx, BudgetsService_GetBudgetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BudgetsServiceServer is the server API for BudgetsService service.
+// All implementations must embed UnimplementedBudgetsServiceServer
+// for forward compatibility
+type BudgetsServiceServer interface {
+	CreateBudget(context.Context, *CreateBudgetRequest) (*BudgetResponse, error)
+	GetBudget(context.Context, *GetBudgetRequest) (*BudgetResponse, error)
+	UpdateBudget(context.Context, *UpdateBudgetRequest) (*BudgetResponse, error)
+	ListBudgets(context.Context, *ListBudgetsRequest) (*ListBudgetsResponse, error)
+	DeleteBudget(context.Context, *DeleteBudgetRequest) (*DeleteBudgetResponse, error)
+	GetBudgetStatus(context.Context, *GetBudgetStatusRequest) (*GetBudgetStatusResponse, error)
+	mustEmbedUnimplementedBudgetsServiceServer()
+}
+
+// UnimplementedBudgetsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBudgetsServiceServer struct {
+}
+
+func (UnimplementedBudgetsServiceServer) CreateBudget(context.Context, *CreateBudgetRequest) (*BudgetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBudget not implemented")
+}
+func (UnimplementedBudgetsServiceServer) GetBudget(context.Context, *GetBudgetRequest) (*BudgetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBudget not implemented")
+}
+func (UnimplementedBudgetsServiceServer) UpdateBudget(context.Context, *UpdateBudgetRequest) (*BudgetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateBudget not implemented")
+}
+func (UnimplementedBudgetsServiceServer) ListBudgets(context.Context, *ListBudgetsRequest) (*ListBudgetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBudgets not implemented")
+}
+func (UnimplementedBudgetsServiceServer) DeleteBudget(context.Context, *DeleteBudgetRequest) (*DeleteBudgetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBudget not implemented")
+}
+func (UnimplementedBudgetsServiceServer) GetBudgetStatus(context.Context, *GetBudgetStatusRequest) (*GetBudgetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBudgetStatus not implemented")
+}
+func (UnimplementedBudgetsServiceServer) mustEmbedUnimplementedBudgetsServiceServer() {}
+
+// UnsafeBudgetsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BudgetsServiceServer will
+// result in compilation errors.
+type UnsafeBudgetsServiceServer interface {
+	mustEmbedUnimplementedBudgetsServiceServer()
+}
+
+func RegisterBudgetsServiceServer(s grpc.ServiceRegistrar, srv BudgetsServiceServer) {
+	s.RegisterService(&BudgetsService_ServiceDesc, srv)
+}
+
+func _BudgetsService_CreateBudget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateBudgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BudgetsServiceServer).CreateBudget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BudgetsService_CreateBudget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BudgetsServiceServer).CreateBudget(ctx, req.(*CreateBudgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BudgetsService_GetBudget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBudgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BudgetsServiceServer).GetBudget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BudgetsService_GetBudget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BudgetsServiceServer).GetBudget(ctx, req.(*GetBudgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BudgetsService_UpdateBudget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateBudgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BudgetsServiceServer).UpdateBudget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BudgetsService_UpdateBudget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BudgetsServiceServer).UpdateBudget(ctx, req.(*UpdateBudgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BudgetsService_ListBudgets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBudgetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BudgetsServiceServer).ListBudgets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BudgetsService_ListBudgets_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BudgetsServiceServer).ListBudgets(ctx, req.(*ListBudgetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BudgetsService_DeleteBudget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBudgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BudgetsServiceServer).DeleteBudget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BudgetsService_DeleteBudget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BudgetsServiceServer).DeleteBudget(ctx, req.(*DeleteBudgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BudgetsService_GetBudgetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBudgetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BudgetsServiceServer).GetBudgetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: BudgetsService_GetBudgetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BudgetsServiceServer).GetBudgetStatus(ctx, req.(*GetBudgetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// BudgetsService_ServiceDesc is the grpc.ServiceDesc for BudgetsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BudgetsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "BudgetsService",
+	HandlerType: (*BudgetsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateBudget",
+			Handler:    _BudgetsService_CreateBudget_Handler,
+		},
+		{
+			MethodName: "GetBudget",
+			Handler:    _BudgetsService_GetBudget_Handler,
+		},
+		{
+			MethodName: "UpdateBudget",
+			Handler:    _BudgetsService_UpdateBudget_Handler,
+		},
+		{
+			MethodName: "ListBudgets",
+			Handler:    _BudgetsService_ListBudgets_Handler,
+		},
+		{
+			MethodName: "DeleteBudget",
+			Handler:    _BudgetsService_DeleteBudget_Handler,
+		},
+		{
+			MethodName: "GetBudgetStatus",
+			Handler:    _BudgetsService_GetBudgetStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "budget.proto",
+}
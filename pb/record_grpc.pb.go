@@ -19,11 +19,25 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	RecordsService_Create_FullMethodName     = "/RecordsService/Create"
-	RecordsService_Update_FullMethodName     = "/RecordsService/Update"
-	RecordsService_Delete_FullMethodName     = "/RecordsService/Delete"
-	RecordsService_GetRecords_FullMethodName = "/RecordsService/GetRecords"
-	RecordsService_Ping_FullMethodName       = "/RecordsService/Ping"
+	RecordsService_Create_FullMethodName             = "/RecordsService/Create"
+	RecordsService_GetRecord_FullMethodName          = "/RecordsService/GetRecord"
+	RecordsService_Update_FullMethodName             = "/RecordsService/Update"
+	RecordsService_Delete_FullMethodName             = "/RecordsService/Delete"
+	RecordsService_GetRecords_FullMethodName         = "/RecordsService/GetRecords"
+	RecordsService_CreateTransfer_FullMethodName     = "/RecordsService/CreateTransfer"
+	RecordsService_GetSummary_FullMethodName         = "/RecordsService/GetSummary"
+	RecordsService_ImportRecords_FullMethodName      = "/RecordsService/ImportRecords"
+	RecordsService_ImportStatement_FullMethodName    = "/RecordsService/ImportStatement"
+	RecordsService_ExportRecords_FullMethodName      = "/RecordsService/ExportRecords"
+	RecordsService_BatchCreateRecords_FullMethodName = "/RecordsService/BatchCreateRecords"
+	RecordsService_BatchUpdateRecords_FullMethodName = "/RecordsService/BatchUpdateRecords"
+	RecordsService_BatchDeleteRecords_FullMethodName = "/RecordsService/BatchDeleteRecords"
+	RecordsService_ListTrash_FullMethodName          = "/RecordsService/ListTrash"
+	RecordsService_RestoreRecord_FullMethodName      = "/RecordsService/RestoreRecord"
+	RecordsService_PurgeRecord_FullMethodName        = "/RecordsService/PurgeRecord"
+	RecordsService_GetRecordHistory_FullMethodName   = "/RecordsService/GetRecordHistory"
+	RecordsService_RevertRecord_FullMethodName       = "/RecordsService/RevertRecord"
+	RecordsService_Ping_FullMethodName               = "/RecordsService/Ping"
 )
 
 // RecordsServiceClient is the client API for RecordsService service.
@@ -31,9 +45,41 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type RecordsServiceClient interface {
 	Create(ctx context.Context, in *CreateRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error)
-	Update(ctx context.Context, in *Record, opts ...grpc.CallOption) (*UpdateRecordResponse, error)
+	GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error)
+	Update(ctx context.Context, in *UpdateRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error)
+	// moves the record to the trash, where it is purged once the retention
+	// period of the server is over
 	Delete(ctx context.Context, in *DeleteRecordRequest, opts ...grpc.CallOption) (*DeleteRecordResponse, error)
 	GetRecords(ctx context.Context, in *GetRecordsRequest, opts ...grpc.CallOption) (*GetRecordsResponse, error)
+	CreateTransfer(ctx context.Context, in *CreateTransferRequest, opts ...grpc.CallOption) (*CreateTransferResponse, error)
+	GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*GetSummaryResponse, error)
+	// creates a record for every valid row of a CSV file, in batches; the
+	// invalid rows are reported and skipped
+	ImportRecords(ctx context.Context, opts ...grpc.CallOption) (RecordsService_ImportRecordsClient, error)
+	// creates a record for every new transaction of a bank statement, the
+	// type given by the sign of its amount; importing a statement again
+	// only creates the records of the transactions it did not have before
+	ImportStatement(ctx context.Context, opts ...grpc.CallOption) (RecordsService_ImportStatementClient, error)
+	// streams the records matching the filter in a file format; an OFX
+	// export needs the filter to select a single currency or account
+	ExportRecords(ctx context.Context, in *ExportRecordsRequest, opts ...grpc.CallOption) (RecordsService_ExportRecordsClient, error)
+	// create, update or delete up to 100 records at once; the batch is
+	// written in a transaction and fails as a whole unless best_effort is
+	// set, then every item is written on its own and gets its result
+	BatchCreateRecords(ctx context.Context, in *BatchCreateRecordsRequest, opts ...grpc.CallOption) (*BatchRecordsResponse, error)
+	BatchUpdateRecords(ctx context.Context, in *BatchUpdateRecordsRequest, opts ...grpc.CallOption) (*BatchRecordsResponse, error)
+	BatchDeleteRecords(ctx context.Context, in *BatchDeleteRecordsRequest, opts ...grpc.CallOption) (*BatchRecordsResponse, error)
+	ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error)
+	// moves the record out of the trash; its category is dropped when it
+	// was deleted since, a deleted account fails it with FAILED_PRECONDITION
+	RestoreRecord(ctx context.Context, in *RestoreRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error)
+	// removes the record of the trash for good
+	PurgeRecord(ctx context.Context, in *PurgeRecordRequest, opts ...grpc.CallOption) (*DeleteRecordResponse, error)
+	// lists the revisions of a record, which outlive the record itself
+	GetRecordHistory(ctx context.Context, in *GetRecordHistoryRequest, opts ...grpc.CallOption) (*GetRecordHistoryResponse, error)
+	// updates the record back to how a revision left it; the record must
+	// be out of the trash
+	RevertRecord(ctx context.Context, in *RevertRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error)
 	Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error)
 }
 
@@ -54,7 +100,16 @@ func (c *recordsServiceClient) Create(ctx context.Context, in *CreateRecordReque
 	return out, nil
 }
 
-func (c *recordsServiceClient) Update(ctx context.Context, in *Record, opts ...grpc.CallOption) (*UpdateRecordResponse, error) {
+func (c *recordsServiceClient) GetRecord(ctx context.Context, in *GetRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error) {
+	out := new(UpdateRecordResponse)
+	err := c.cc.Invoke(ctx, RecordsService_GetRecord_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) Update(ctx context.Context, in *UpdateRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error) {
 	out := new(UpdateRecordResponse)
 	err := c.cc.Invoke(ctx, RecordsService_Update_FullMethodName, in, out, opts...)
 	if err != nil {
@@ -81,6 +136,196 @@ func (c *recordsServiceClient) GetRecords(ctx context.Context, in *GetRecordsReq
 	return out, nil
 }
 
+func (c *recordsServiceClient) CreateTransfer(ctx context.Context, in *CreateTransferRequest, opts ...grpc.CallOption) (*CreateTransferResponse, error) {
+	out := new(CreateTransferResponse)
+	err := c.cc.Invoke(ctx, RecordsService_CreateTransfer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) GetSummary(ctx context.Context, in *GetSummaryRequest, opts ...grpc.CallOption) (*GetSummaryResponse, error) {
+	out := new(GetSummaryResponse)
+	err := c.cc.Invoke(ctx, RecordsService_GetSummary_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) ImportRecords(ctx context.Context, opts ...grpc.CallOption) (RecordsService_ImportRecordsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RecordsService_ServiceDesc.Streams[0], RecordsService_ImportRecords_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &recordsServiceImportRecordsClient{stream}
+	return x, nil
+}
+
+type RecordsService_ImportRecordsClient interface {
+	Send(*ImportRecordsRequest) error
+	CloseAndRecv() (*ImportRecordsResponse, error)
+	grpc.ClientStream
+}
+
+type recordsServiceImportRecordsClient struct {
+	grpc.ClientStream
+}
+
+func (x *recordsServiceImportRecordsClient) Send(m *ImportRecordsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *recordsServiceImportRecordsClient) CloseAndRecv() (*ImportRecordsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportRecordsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *recordsServiceClient) ImportStatement(ctx context.Context, opts ...grpc.CallOption) (RecordsService_ImportStatementClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RecordsService_ServiceDesc.Streams[1], RecordsService_ImportStatement_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &recordsServiceImportStatementClient{stream}
+	return x, nil
+}
+
+type RecordsService_ImportStatementClient interface {
+	Send(*ImportStatementRequest) error
+	CloseAndRecv() (*ImportRecordsResponse, error)
+	grpc.ClientStream
+}
+
+type recordsServiceImportStatementClient struct {
+	grpc.ClientStream
+}
+
+func (x *recordsServiceImportStatementClient) Send(m *ImportStatementRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *recordsServiceImportStatementClient) CloseAndRecv() (*ImportRecordsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportRecordsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *recordsServiceClient) ExportRecords(ctx context.Context, in *ExportRecordsRequest, opts ...grpc.CallOption) (RecordsService_ExportRecordsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &RecordsService_ServiceDesc.Streams[2], RecordsService_ExportRecords_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &recordsServiceExportRecordsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type RecordsService_ExportRecordsClient interface {
+	Recv() (*ExportRecordsResponse, error)
+	grpc.ClientStream
+}
+
+type recordsServiceExportRecordsClient struct {
+	grpc.ClientStream
+}
+
+func (x *recordsServiceExportRecordsClient) Recv() (*ExportRecordsResponse, error) {
+	m := new(ExportRecordsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *recordsServiceClient) BatchCreateRecords(ctx context.Context, in *BatchCreateRecordsRequest, opts ...grpc.CallOption) (*BatchRecordsResponse, error) {
+	out := new(BatchRecordsResponse)
+	err := c.cc.Invoke(ctx, RecordsService_BatchCreateRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) BatchUpdateRecords(ctx context.Context, in *BatchUpdateRecordsRequest, opts ...grpc.CallOption) (*BatchRecordsResponse, error) {
+	out := new(BatchRecordsResponse)
+	err := c.cc.Invoke(ctx, RecordsService_BatchUpdateRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) BatchDeleteRecords(ctx context.Context, in *BatchDeleteRecordsRequest, opts ...grpc.CallOption) (*BatchRecordsResponse, error) {
+	out := new(BatchRecordsResponse)
+	err := c.cc.Invoke(ctx, RecordsService_BatchDeleteRecords_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) ListTrash(ctx context.Context, in *ListTrashRequest, opts ...grpc.CallOption) (*ListTrashResponse, error) {
+	out := new(ListTrashResponse)
+	err := c.cc.Invoke(ctx, RecordsService_ListTrash_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) RestoreRecord(ctx context.Context, in *RestoreRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error) {
+	out := new(UpdateRecordResponse)
+	err := c.cc.Invoke(ctx, RecordsService_RestoreRecord_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) PurgeRecord(ctx context.Context, in *PurgeRecordRequest, opts ...grpc.CallOption) (*DeleteRecordResponse, error) {
+	out := new(DeleteRecordResponse)
+	err := c.cc.Invoke(ctx, RecordsService_PurgeRecord_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) GetRecordHistory(ctx context.Context, in *GetRecordHistoryRequest, opts ...grpc.CallOption) (*GetRecordHistoryResponse, error) {
+	out := new(GetRecordHistoryResponse)
+	err := c.cc.Invoke(ctx, RecordsService_GetRecordHistory_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *recordsServiceClient) RevertRecord(ctx context.Context, in *RevertRecordRequest, opts ...grpc.CallOption) (*UpdateRecordResponse, error) {
+	out := new(UpdateRecordResponse)
+	err := c.cc.Invoke(ctx, RecordsService_RevertRecord_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *recordsServiceClient) Ping(ctx context.Context, in *PingRequest, opts ...grpc.CallOption) (*PingResponse, error) {
 	out := new(PingResponse)
 	err := c.cc.Invoke(ctx, RecordsService_Ping_FullMethodName, in, out, opts...)
@@ -95,9 +340,41 @@ func (c *recordsServiceClient) Ping(ctx context.Context, in *PingRequest, opts .
 // for forward compatibility
 type RecordsServiceServer interface {
 	Create(context.Context, *CreateRecordRequest) (*UpdateRecordResponse, error)
-	Update(context.Context, *Record) (*UpdateRecordResponse, error)
+	GetRecord(context.Context, *GetRecordRequest) (*UpdateRecordResponse, error)
+	Update(context.Context, *UpdateRecordRequest) (*UpdateRecordResponse, error)
+	// moves the record to the trash, where it is purged once the retention
+	// period of the server is over
 	Delete(context.Context, *DeleteRecordRequest) (*DeleteRecordResponse, error)
 	GetRecords(context.Context, *GetRecordsRequest) (*GetRecordsResponse, error)
+	CreateTransfer(context.Context, *CreateTransferRequest) (*CreateTransferResponse, error)
+	GetSummary(context.Context, *GetSummaryRequest) (*GetSummaryResponse, error)
+	// creates a record for every valid row of a CSV file, in batches; the
+	// invalid rows are reported and skipped
+	ImportRecords(RecordsService_ImportRecordsServer) error
+	// creates a record for every new transaction of a bank statement, the
+	// type given by the sign of its amount; importing a statement again
+	// only creates the records of the transactions it did not have before
+	ImportStatement(RecordsService_ImportStatementServer) error
+	// streams the records matching the filter in a file format; an OFX
+	// export needs the filter to select a single currency or account
+	ExportRecords(*ExportRecordsRequest, RecordsService_ExportRecordsServer) error
+	// create, update or delete up to 100 records at once; the batch is
+	// written in a transaction and fails as a whole unless best_effort is
+	// set, then every item is written on its own and gets its result
+	BatchCreateRecords(context.Context, *BatchCreateRecordsRequest) (*BatchRecordsResponse, error)
+	BatchUpdateRecords(context.Context, *BatchUpdateRecordsRequest) (*BatchRecordsResponse, error)
+	BatchDeleteRecords(context.Context, *BatchDeleteRecordsRequest) (*BatchRecordsResponse, error)
+	ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error)
+	// moves the record out of the trash; its category is dropped when it
+	// was deleted since, a deleted account fails it with FAILED_PRECONDITION
+	RestoreRecord(context.Context, *RestoreRecordRequest) (*UpdateRecordResponse, error)
+	// removes the record of the trash for good
+	PurgeRecord(context.Context, *PurgeRecordRequest) (*DeleteRecordResponse, error)
+	// lists the revisions of a record, which outlive the record itself
+	GetRecordHistory(context.Context, *GetRecordHistoryRequest) (*GetRecordHistoryResponse, error)
+	// updates the record back to how a revision left it; the record must
+	// be out of the trash
+	RevertRecord(context.Context, *RevertRecordRequest) (*UpdateRecordResponse, error)
 	Ping(context.Context, *PingRequest) (*PingResponse, error)
 	mustEmbedUnimplementedRecordsServiceServer()
 }
@@ -109,7 +386,10 @@ type UnimplementedRecordsServiceServer struct {
 func (UnimplementedRecordsServiceServer) Create(context.Context, *CreateRecordRequest) (*UpdateRecordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Create not implemented")
 }
-func (UnimplementedRecordsServiceServer) Update(context.Context, *Record) (*UpdateRecordResponse, error) {
+func (UnimplementedRecordsServiceServer) GetRecord(context.Context, *GetRecordRequest) (*UpdateRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecord not implemented")
+}
+func (UnimplementedRecordsServiceServer) Update(context.Context, *UpdateRecordRequest) (*UpdateRecordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
 }
 func (UnimplementedRecordsServiceServer) Delete(context.Context, *DeleteRecordRequest) (*DeleteRecordResponse, error) {
@@ -118,6 +398,45 @@ func (UnimplementedRecordsServiceServer) Delete(context.Context, *DeleteRecordRe
 func (UnimplementedRecordsServiceServer) GetRecords(context.Context, *GetRecordsRequest) (*GetRecordsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetRecords not implemented")
 }
+func (UnimplementedRecordsServiceServer) CreateTransfer(context.Context, *CreateTransferRequest) (*CreateTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTransfer not implemented")
+}
+func (UnimplementedRecordsServiceServer) GetSummary(context.Context, *GetSummaryRequest) (*GetSummaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSummary not implemented")
+}
+func (UnimplementedRecordsServiceServer) ImportRecords(RecordsService_ImportRecordsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportRecords not implemented")
+}
+func (UnimplementedRecordsServiceServer) ImportStatement(RecordsService_ImportStatementServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportStatement not implemented")
+}
+func (UnimplementedRecordsServiceServer) ExportRecords(*ExportRecordsRequest, RecordsService_ExportRecordsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportRecords not implemented")
+}
+func (UnimplementedRecordsServiceServer) BatchCreateRecords(context.Context, *BatchCreateRecordsRequest) (*BatchRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateRecords not implemented")
+}
+func (UnimplementedRecordsServiceServer) BatchUpdateRecords(context.Context, *BatchUpdateRecordsRequest) (*BatchRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateRecords not implemented")
+}
+func (UnimplementedRecordsServiceServer) BatchDeleteRecords(context.Context, *BatchDeleteRecordsRequest) (*BatchRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchDeleteRecords not implemented")
+}
+func (UnimplementedRecordsServiceServer) ListTrash(context.Context, *ListTrashRequest) (*ListTrashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTrash not implemented")
+}
+func (UnimplementedRecordsServiceServer) RestoreRecord(context.Context, *RestoreRecordRequest) (*UpdateRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreRecord not implemented")
+}
+func (UnimplementedRecordsServiceServer) PurgeRecord(context.Context, *PurgeRecordRequest) (*DeleteRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeRecord not implemented")
+}
+func (UnimplementedRecordsServiceServer) GetRecordHistory(context.Context, *GetRecordHistoryRequest) (*GetRecordHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRecordHistory not implemented")
+}
+func (UnimplementedRecordsServiceServer) RevertRecord(context.Context, *RevertRecordRequest) (*UpdateRecordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevertRecord not implemented")
+}
 func (UnimplementedRecordsServiceServer) Ping(context.Context, *PingRequest) (*PingResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Ping not implemented")
 }
@@ -152,8 +471,26 @@ func _RecordsService_Create_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RecordsService_GetRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).GetRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_GetRecord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).GetRecord(ctx, req.(*GetRecordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _RecordsService_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(Record)
+	in := new(UpdateRecordRequest)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
@@ -165,7 +502,7 @@ func _RecordsService_Update_Handler(srv interface{}, ctx context.Context, dec fu
 		FullMethod: RecordsService_Update_FullMethodName,
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(RecordsServiceServer).Update(ctx, req.(*Record))
+		return srv.(RecordsServiceServer).Update(ctx, req.(*UpdateRecordRequest))
 	}
 	return interceptor(ctx, in, info, handler)
 }
@@ -206,6 +543,259 @@ func _RecordsService_GetRecords_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RecordsService_CreateTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).CreateTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_CreateTransfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).CreateTransfer(ctx, req.(*CreateTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_GetSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).GetSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_GetSummary_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).GetSummary(ctx, req.(*GetSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_ImportRecords_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RecordsServiceServer).ImportRecords(&recordsServiceImportRecordsServer{stream})
+}
+
+type RecordsService_ImportRecordsServer interface {
+	SendAndClose(*ImportRecordsResponse) error
+	Recv() (*ImportRecordsRequest, error)
+	grpc.ServerStream
+}
+
+type recordsServiceImportRecordsServer struct {
+	grpc.ServerStream
+}
+
+func (x *recordsServiceImportRecordsServer) SendAndClose(m *ImportRecordsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *recordsServiceImportRecordsServer) Recv() (*ImportRecordsRequest, error) {
+	m := new(ImportRecordsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _RecordsService_ImportStatement_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RecordsServiceServer).ImportStatement(&recordsServiceImportStatementServer{stream})
+}
+
+type RecordsService_ImportStatementServer interface {
+	SendAndClose(*ImportRecordsResponse) error
+	Recv() (*ImportStatementRequest, error)
+	grpc.ServerStream
+}
+
+type recordsServiceImportStatementServer struct {
+	grpc.ServerStream
+}
+
+func (x *recordsServiceImportStatementServer) SendAndClose(m *ImportRecordsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *recordsServiceImportStatementServer) Recv() (*ImportStatementRequest, error) {
+	m := new(ImportStatementRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _RecordsService_ExportRecords_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRecordsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RecordsServiceServer).ExportRecords(m, &recordsServiceExportRecordsServer{stream})
+}
+
+type RecordsService_ExportRecordsServer interface {
+	Send(*ExportRecordsResponse) error
+	grpc.ServerStream
+}
+
+type recordsServiceExportRecordsServer struct {
+	grpc.ServerStream
+}
+
+func (x *recordsServiceExportRecordsServer) Send(m *ExportRecordsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _RecordsService_BatchCreateRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).BatchCreateRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_BatchCreateRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).BatchCreateRecords(ctx, req.(*BatchCreateRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_BatchUpdateRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).BatchUpdateRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_BatchUpdateRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).BatchUpdateRecords(ctx, req.(*BatchUpdateRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_BatchDeleteRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchDeleteRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).BatchDeleteRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_BatchDeleteRecords_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).BatchDeleteRecords(ctx, req.(*BatchDeleteRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_ListTrash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTrashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).ListTrash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_ListTrash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).ListTrash(ctx, req.(*ListTrashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_RestoreRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).RestoreRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_RestoreRecord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).RestoreRecord(ctx, req.(*RestoreRecordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_PurgeRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).PurgeRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_PurgeRecord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).PurgeRecord(ctx, req.(*PurgeRecordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_GetRecordHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRecordHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).GetRecordHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_GetRecordHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).GetRecordHistory(ctx, req.(*GetRecordHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RecordsService_RevertRecord_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevertRecordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RecordsServiceServer).RevertRecord(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RecordsService_RevertRecord_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RecordsServiceServer).RevertRecord(ctx, req.(*RevertRecordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _RecordsService_Ping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(PingRequest)
 	if err := dec(in); err != nil {
@@ -235,6 +825,10 @@ var RecordsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Create",
 			Handler:    _RecordsService_Create_Handler,
 		},
+		{
+			MethodName: "GetRecord",
+			Handler:    _RecordsService_GetRecord_Handler,
+		},
 		{
 			MethodName: "Update",
 			Handler:    _RecordsService_Update_Handler,
@@ -247,11 +841,67 @@ var RecordsService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetRecords",
 			Handler:    _RecordsService_GetRecords_Handler,
 		},
+		{
+			MethodName: "CreateTransfer",
+			Handler:    _RecordsService_CreateTransfer_Handler,
+		},
+		{
+			MethodName: "GetSummary",
+			Handler:    _RecordsService_GetSummary_Handler,
+		},
+		{
+			MethodName: "BatchCreateRecords",
+			Handler:    _RecordsService_BatchCreateRecords_Handler,
+		},
+		{
+			MethodName: "BatchUpdateRecords",
+			Handler:    _RecordsService_BatchUpdateRecords_Handler,
+		},
+		{
+			MethodName: "BatchDeleteRecords",
+			Handler:    _RecordsService_BatchDeleteRecords_Handler,
+		},
+		{
+			MethodName: "ListTrash",
+			Handler:    _RecordsService_ListTrash_Handler,
+		},
+		{
+			MethodName: "RestoreRecord",
+			Handler:    _RecordsService_RestoreRecord_Handler,
+		},
+		{
+			MethodName: "PurgeRecord",
+			Handler:    _RecordsService_PurgeRecord_Handler,
+		},
+		{
+			MethodName: "GetRecordHistory",
+			Handler:    _RecordsService_GetRecordHistory_Handler,
+		},
+		{
+			MethodName: "RevertRecord",
+			Handler:    _RecordsService_RevertRecord_Handler,
+		},
 		{
 			MethodName: "Ping",
 			Handler:    _RecordsService_Ping_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportRecords",
+			Handler:       _RecordsService_ImportRecords_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ImportStatement",
+			Handler:       _RecordsService_ImportStatement_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportRecords",
+			Handler:       _RecordsService_ExportRecords_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "record.proto",
 }
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type accountsServer struct {
+	pb.UnimplementedAccountsServiceServer
+	store db.AccountStore
+}
+
+// CreateAccount
+func (s *accountsServer) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.AccountResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a := db.Account{
+		UserId:         userId,
+		Name:           req.Name,
+		Kind:           req.Kind.String(),
+		Currency:       req.CurrencyCode,
+		OpeningBalance: req.OpeningBalance,
+	}
+	if err := s.store.CreateAccount(ctx, &a); err != nil {
+		return nil, err
+	}
+	return &pb.AccountResponse{Success: true, Account: pbAccountFromAccount(a), Message: "Account created"}, nil
+}
+
+// GetAccount
+func (s *accountsServer) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.AccountResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.store.GetAccount(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.AccountResponse{Success: true, Account: pbAccountFromAccount(a), Message: "Account found"}, nil
+}
+
+// UpdateAccount
+func (s *accountsServer) UpdateAccount(ctx context.Context, req *pb.UpdateAccountRequest) (*pb.AccountResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("account.id", req.Account.GetId())
+	if err != nil {
+		return nil, err
+	}
+	fields, err := fieldsFromMask(req.UpdateMask, db.AccountFields)
+	if err != nil {
+		return nil, err
+	}
+	a := db.Account{
+		ID:             id,
+		UserId:         userId,
+		Name:           req.Account.GetName(),
+		Kind:           req.Account.GetKind().String(),
+		Currency:       req.Account.GetCurrencyCode(),
+		OpeningBalance: req.Account.GetOpeningBalance(),
+	}
+	if err := s.store.UpdateAccount(ctx, &a, fields); err != nil {
+		return nil, err
+	}
+	return &pb.AccountResponse{Success: true, Account: pbAccountFromAccount(a), Message: "Account updated"}, nil
+}
+
+// ListAccounts
+func (s *accountsServer) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.store.ListAccounts(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	pbAccounts := []*pb.Account{}
+	for _, a := range accounts {
+		pbAccounts = append(pbAccounts, pbAccountFromAccount(a))
+	}
+	return &pb.ListAccountsResponse{Success: true, Accounts: pbAccounts, Message: "Accounts found"}, nil
+}
+
+// DeleteAccount
+func (s *accountsServer) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteAccount(ctx, userId, id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteAccountResponse{Success: true, Message: "Account deleted"}, nil
+}
+
+// GetBalances computes the balances of the accounts at a point in time,
+// now unless the request asks for a historical one.
+func (s *accountsServer) GetBalances(ctx context.Context, req *pb.GetBalancesRequest) (*pb.GetBalancesResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	at, err := timeFromPb("at", req.At)
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		at = db.Now()
+	}
+	accounts, err := s.store.ListAccounts(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.AccountIds) > 0 {
+		if accounts, err = selectAccounts(accounts, req.AccountIds); err != nil {
+			return nil, err
+		}
+	}
+	totals, err := s.store.AccountTotals(ctx, userId, at)
+	if err != nil {
+		return nil, err
+	}
+	balances := []*pb.AccountBalance{}
+	for _, a := range accounts {
+		t := totals[a.ID]
+		balances = append(balances, &pb.AccountBalance{
+			AccountId:    a.ID.Hex(),
+			Balance:      pbMoneyFromMoney(db.Money{Units: a.Balance(t), Currency: a.Currency}),
+			Income:       pbMoneyFromMoney(db.Money{Units: t.Income, Currency: a.Currency}),
+			Expense:      pbMoneyFromMoney(db.Money{Units: t.Expense, Currency: a.Currency}),
+			TransfersIn:  pbMoneyFromMoney(db.Money{Units: t.TransfersIn, Currency: a.Currency}),
+			TransfersOut: pbMoneyFromMoney(db.Money{Units: t.TransfersOut, Currency: a.Currency}),
+		})
+	}
+	return &pb.GetBalancesResponse{Success: true, Balances: balances, Message: "Balances computed"}, nil
+}
+
+// selectAccounts picks the accounts with the given hex ids out of the
+// user's accounts, in the order they were asked for.
+func selectAccounts(accounts []db.Account, hexIds []string) ([]db.Account, error) {
+	selected := []db.Account{}
+	for _, hex := range hexIds {
+		id, err := parseId("account_ids", hex)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, a := range accounts {
+			if a.ID == id {
+				selected, found = append(selected, a), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: '%s'", db.ErrAccountNotFound, hex)
+		}
+	}
+	return selected, nil
+}
+
+func pbAccountFromAccount(a db.Account) *pb.Account {
+	return &pb.Account{
+		Id:             a.ID.Hex(),
+		Name:           a.Name,
+		Kind:           pb.AccountKind(pb.AccountKind_value[a.Kind]),
+		CurrencyCode:   a.Currency,
+		OpeningBalance: a.OpeningBalance,
+		UserId:         a.UserId.Hex(),
+		CreatedAt:      timestamppb.New(a.CreatedAt),
+		UpdatedAt:      timestamppb.New(a.UpdatedAt),
+	}
+}
+
+func RegisterAccountsService(s *grpc.Server, store db.AccountStore) {
+	pb.RegisterAccountsServiceServer(s, &accountsServer{store: store})
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/status"
+)
+
+// BatchCreateRecords
+func (s *recordsServer) BatchCreateRecords(ctx context.Context, req *pb.BatchCreateRecordsRequest) (*pb.BatchRecordsResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withActor(ctx, userId)
+	if err := db.CheckBatchSize(len(req.Records)); err != nil {
+		return nil, err
+	}
+	if req.BestEffort {
+		return bestEffort(ctx, len(req.Records), "created", func(i int) (*pb.Record, error) {
+			r, err := recordFromPb(userId, req.Records[i])
+			if err == nil {
+				err = s.store.Create(ctx, &r)
+			}
+			return pbRecordFromRecord(r), err
+		})
+	}
+	records := make([]db.Record, len(req.Records))
+	for i := range req.Records {
+		if records[i], err = recordFromPb(userId, req.Records[i]); err != nil {
+			return nil, db.BatchItemError(i, err)
+		}
+	}
+	if err := s.store.CreateMany(ctx, records); err != nil {
+		return nil, err
+	}
+	return pbBatchResponse(records, "created"), nil
+}
+
+// BatchUpdateRecords
+func (s *recordsServer) BatchUpdateRecords(ctx context.Context, req *pb.BatchUpdateRecordsRequest) (*pb.BatchRecordsResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withActor(ctx, userId)
+	if err := db.CheckBatchSize(len(req.Records)); err != nil {
+		return nil, err
+	}
+	if req.BestEffort {
+		return bestEffort(ctx, len(req.Records), "updated", func(i int) (*pb.Record, error) {
+			r, fields, err := recordUpdateFromPb(userId, req.Records[i])
+			if err == nil {
+				err = s.store.Update(ctx, &r, fields)
+			}
+			return pbRecordFromRecord(r), err
+		})
+	}
+	updates := make([]db.RecordUpdate, len(req.Records))
+	for i := range req.Records {
+		u := &updates[i]
+		if u.Record, u.Fields, err = recordUpdateFromPb(userId, req.Records[i]); err != nil {
+			return nil, db.BatchItemError(i, err)
+		}
+	}
+	if err := s.store.UpdateMany(ctx, updates); err != nil {
+		return nil, err
+	}
+	records := make([]db.Record, len(updates))
+	for i := range updates {
+		records[i] = updates[i].Record
+	}
+	return pbBatchResponse(records, "updated"), nil
+}
+
+// BatchDeleteRecords
+func (s *recordsServer) BatchDeleteRecords(ctx context.Context, req *pb.BatchDeleteRecordsRequest) (*pb.BatchRecordsResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withActor(ctx, userId)
+	if err := db.CheckBatchSize(len(req.Records)); err != nil {
+		return nil, err
+	}
+	if req.BestEffort {
+		return bestEffort(ctx, len(req.Records), "deleted", func(i int) (*pb.Record, error) {
+			id, err := parseId("id", req.Records[i].Id)
+			if err == nil {
+				err = s.store.Delete(ctx, userId, id, req.Records[i].Version)
+			}
+			return nil, err
+		})
+	}
+	deletes := make([]db.RecordDelete, len(req.Records))
+	for i, d := range req.Records {
+		if deletes[i].ID, err = parseId("id", d.Id); err != nil {
+			return nil, db.BatchItemError(i, err)
+		}
+		deletes[i].Version = d.Version
+	}
+	if err := s.store.DeleteMany(ctx, userId, deletes); err != nil {
+		return nil, err
+	}
+	return pbBatchResponse(make([]db.Record, len(deletes)), "deleted"), nil
+}
+
+// bestEffort writes the n items of a batch one at a time and reports the
+// result of every one of them. When the call is canceled the items left
+// are not written and fail with the code of the cancellation.
+func bestEffort(ctx context.Context, n int, verb string, write func(i int) (*pb.Record, error)) (*pb.BatchRecordsResponse, error) {
+	res := &pb.BatchRecordsResponse{}
+	for i := 0; i < n; i++ {
+		if err := ctx.Err(); err != nil {
+			st := status.FromContextError(err)
+			res.Results = append(res.Results, &pb.BatchItemResult{Code: int32(st.Code()), Message: st.Message()})
+			continue
+		}
+		record, err := write(i)
+		result := &pb.BatchItemResult{}
+		if err == nil {
+			result.Record = record
+			res.Written++
+		} else {
+			st := status.Convert(toStatus(err))
+			result.Code = int32(st.Code())
+			result.Message = st.Message()
+			var validation *db.ValidationError
+			if errors.As(err, &validation) {
+				for _, v := range validation.Violations {
+					result.Violations = append(result.Violations, &pb.BatchFieldViolation{Field: v.Field, Description: v.Description})
+				}
+			}
+		}
+		res.Results = append(res.Results, result)
+	}
+	res.Success = int(res.Written) == n
+	res.Message = fmt.Sprintf("%d of %d records %s", res.Written, n, verb)
+	return res, nil
+}
+
+// pbBatchResponse reports a batch written as a whole, the records are
+// left out of the results when they have no ID.
+func pbBatchResponse(records []db.Record, verb string) *pb.BatchRecordsResponse {
+	res := &pb.BatchRecordsResponse{
+		Success: true,
+		Written: int32(len(records)),
+		Message: fmt.Sprintf("%d records %s", len(records), verb),
+	}
+	for _, r := range records {
+		result := &pb.BatchItemResult{}
+		if !r.ID.IsZero() {
+			result.Record = pbRecordFromRecord(r)
+		}
+		res.Results = append(res.Results, result)
+	}
+	return res
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type budgetsServer struct {
+	pb.UnimplementedBudgetsServiceServer
+	store db.Store
+}
+
+// CreateBudget
+func (s *budgetsServer) CreateBudget(ctx context.Context, req *pb.CreateBudgetRequest) (*pb.BudgetResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := budgetFromPb(req.Budget)
+	if err != nil {
+		return nil, err
+	}
+	b.UserId = userId
+	if err := s.store.CreateBudget(ctx, &b); err != nil {
+		return nil, err
+	}
+	return &pb.BudgetResponse{Success: true, Budget: pbBudgetFromBudget(b), Message: "Budget created"}, nil
+}
+
+// GetBudget
+func (s *budgetsServer) GetBudget(ctx context.Context, req *pb.GetBudgetRequest) (*pb.BudgetResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.store.GetBudget(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BudgetResponse{Success: true, Budget: pbBudgetFromBudget(b), Message: "Budget found"}, nil
+}
+
+// UpdateBudget
+func (s *budgetsServer) UpdateBudget(ctx context.Context, req *pb.UpdateBudgetRequest) (*pb.BudgetResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("budget.id", req.Budget.GetId())
+	if err != nil {
+		return nil, err
+	}
+	fields, err := fieldsFromMask(req.UpdateMask, db.BudgetFields)
+	if err != nil {
+		return nil, err
+	}
+	b, err := budgetFromPb(req.Budget)
+	if err != nil {
+		return nil, err
+	}
+	b.ID = id
+	b.UserId = userId
+	if err := s.store.UpdateBudget(ctx, &b, fields); err != nil {
+		return nil, err
+	}
+	return &pb.BudgetResponse{Success: true, Budget: pbBudgetFromBudget(b), Message: "Budget updated"}, nil
+}
+
+// ListBudgets
+func (s *budgetsServer) ListBudgets(ctx context.Context, req *pb.ListBudgetsRequest) (*pb.ListBudgetsResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := s.store.ListBudgets(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	pbBudgets := []*pb.Budget{}
+	for _, b := range budgets {
+		pbBudgets = append(pbBudgets, pbBudgetFromBudget(b))
+	}
+	return &pb.ListBudgetsResponse{Success: true, Budgets: pbBudgets, Message: "Budgets found"}, nil
+}
+
+// DeleteBudget
+func (s *budgetsServer) DeleteBudget(ctx context.Context, req *pb.DeleteBudgetRequest) (*pb.DeleteBudgetResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteBudget(ctx, userId, id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteBudgetResponse{Success: true, Message: "Budget deleted"}, nil
+}
+
+// GetBudgetStatus computes the spend of the budgets over the periods
+// containing a point in time, now unless the request asks for another one.
+func (s *budgetsServer) GetBudgetStatus(ctx context.Context, req *pb.GetBudgetStatusRequest) (*pb.GetBudgetStatusResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	at, err := timeFromPb("at", req.At)
+	if err != nil {
+		return nil, err
+	}
+	if at.IsZero() {
+		at = db.Now()
+	}
+	loc, err := locationFromPb("time_zone", req.TimeZone)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := s.store.ListBudgets(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.BudgetIds) > 0 {
+		if budgets, err = selectBudgets(budgets, req.BudgetIds); err != nil {
+			return nil, err
+		}
+	}
+	categories, err := s.store.ListCategories(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	tree := db.NewCategoryTree(categories)
+	statuses := []*pb.BudgetStatus{}
+	for _, b := range budgets {
+		var subtree []primitive.ObjectID
+		if !b.CategoryId.IsZero() {
+			subtree = tree.Descendants(b.CategoryId)
+		}
+		rows, err := s.store.Summarize(ctx, b.SummaryQuery(at, loc, subtree))
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, pbBudgetStatusFromStatus(b.Status(rows, at, loc)))
+	}
+	return &pb.GetBudgetStatusResponse{Success: true, Statuses: statuses, Message: "Budget status computed"}, nil
+}
+
+// selectBudgets picks the budgets with the given hex ids out of the
+// user's budgets, in the order they were asked for.
+func selectBudgets(budgets []db.Budget, hexIds []string) ([]db.Budget, error) {
+	selected := []db.Budget{}
+	for _, hex := range hexIds {
+		id, err := parseId("budget_ids", hex)
+		if err != nil {
+			return nil, err
+		}
+		found := false
+		for _, b := range budgets {
+			if b.ID == id {
+				selected, found = append(selected, b), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: '%s'", db.ErrBudgetNotFound, hex)
+		}
+	}
+	return selected, nil
+}
+
+func budgetFromPb(p *pb.Budget) (db.Budget, error) {
+	categoryId, err := parseOptionalId("budget.category_id", p.GetCategoryId())
+	if err != nil {
+		return db.Budget{}, err
+	}
+	start, err := timeFromPb("budget.start_date", p.GetStartDate())
+	if err != nil {
+		return db.Budget{}, err
+	}
+	return db.Budget{
+		Name:       p.GetName(),
+		CategoryId: categoryId,
+		Tag:        p.GetTag(),
+		Limit:      moneyFromPb(p.GetLimit()),
+		Period:     p.GetPeriod().String(),
+		Start:      start,
+		Rollover:   p.GetRollover(),
+	}, nil
+}
+
+func pbBudgetFromBudget(b db.Budget) *pb.Budget {
+	p := &pb.Budget{
+		Id:        b.ID.Hex(),
+		Name:      b.Name,
+		Tag:       b.Tag,
+		Limit:     pbMoneyFromMoney(b.Limit),
+		Period:    pb.SummaryPeriod(pb.SummaryPeriod_value[b.Period]),
+		StartDate: timestamppb.New(b.Start),
+		Rollover:  b.Rollover,
+		UserId:    b.UserId.Hex(),
+		CreatedAt: timestamppb.New(b.CreatedAt),
+		UpdatedAt: timestamppb.New(b.UpdatedAt),
+	}
+	if !b.CategoryId.IsZero() {
+		p.CategoryId = b.CategoryId.Hex()
+	}
+	return p
+}
+
+func pbBudgetStatusFromStatus(s db.BudgetStatus) *pb.BudgetStatus {
+	money := func(units int64) *pb.Money {
+		return pbMoneyFromMoney(db.Money{Units: units, Currency: s.Budget.Limit.Currency})
+	}
+	state := pb.BudgetState_ON_TRACK
+	switch {
+	case s.Over():
+		state = pb.BudgetState_OVER
+	case s.AtRisk():
+		state = pb.BudgetState_AT_RISK
+	}
+	return &pb.BudgetStatus{
+		BudgetId:    s.Budget.ID.Hex(),
+		PeriodStart: timestamppb.New(s.Start),
+		PeriodEnd:   timestamppb.New(s.End),
+		Available:   money(s.Available()),
+		Rollover:    money(s.Rollover),
+		Spent:       money(s.Spent),
+		Remaining:   money(s.Remaining()),
+		Projected:   money(s.Projected),
+		State:       state,
+	}
+}
+
+func RegisterBudgetsService(s *grpc.Server, store db.Store) {
+	pb.RegisterBudgetsServiceServer(s, &budgetsServer{store: store})
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type categoriesServer struct {
+	pb.UnimplementedCategoriesServiceServer
+	store db.CategoryStore
+}
+
+// CreateCategory
+func (s *categoriesServer) CreateCategory(ctx context.Context, req *pb.CreateCategoryRequest) (*pb.CategoryResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	parentId, err := parseOptionalId("parent_id", req.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	c := db.Category{
+		UserId:   userId,
+		ParentId: parentId,
+		Name:     req.Name,
+		Icon:     req.Icon,
+		Color:    req.Color,
+		Type:     categoryTypeFromPb(req.Type),
+	}
+	if err := s.store.CreateCategory(ctx, &c); err != nil {
+		return nil, err
+	}
+	return &pb.CategoryResponse{Success: true, Category: pbCategoryFromCategory(c), Message: "Category created"}, nil
+}
+
+// GetCategory
+func (s *categoriesServer) GetCategory(ctx context.Context, req *pb.GetCategoryRequest) (*pb.CategoryResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.store.GetCategory(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CategoryResponse{Success: true, Category: pbCategoryFromCategory(c), Message: "Category found"}, nil
+}
+
+// UpdateCategory renames, moves or restyles a category
+func (s *categoriesServer) UpdateCategory(ctx context.Context, req *pb.UpdateCategoryRequest) (*pb.CategoryResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("category.id", req.Category.GetId())
+	if err != nil {
+		return nil, err
+	}
+	fields, err := fieldsFromMask(req.UpdateMask, db.CategoryFields)
+	if err != nil {
+		return nil, err
+	}
+	parentId, err := parseOptionalId("parent_id", req.Category.GetParentId())
+	if err != nil {
+		return nil, err
+	}
+	c := db.Category{
+		ID:       id,
+		UserId:   userId,
+		ParentId: parentId,
+		Name:     req.Category.GetName(),
+		Icon:     req.Category.GetIcon(),
+		Color:    req.Category.GetColor(),
+	}
+	if req.Category != nil {
+		c.Type = categoryTypeFromPb(req.Category.Type)
+	}
+	if err := s.store.UpdateCategory(ctx, &c, fields); err != nil {
+		return nil, err
+	}
+	return &pb.CategoryResponse{Success: true, Category: pbCategoryFromCategory(c), Message: "Category updated"}, nil
+}
+
+// ListCategories
+func (s *categoriesServer) ListCategories(ctx context.Context, req *pb.ListCategoriesRequest) (*pb.ListCategoriesResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.store.ListCategories(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	pbCategories := []*pb.Category{}
+	for _, c := range categories {
+		pbCategories = append(pbCategories, pbCategoryFromCategory(c))
+	}
+	return &pb.ListCategoriesResponse{Success: true, Categories: pbCategories, Message: "Categories found"}, nil
+}
+
+// DeleteCategory
+func (s *categoriesServer) DeleteCategory(ctx context.Context, req *pb.DeleteCategoryRequest) (*pb.DeleteCategoryResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	moveTo, err := parseOptionalId("move_to", req.MoveTo)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withActor(ctx, userId)
+	if err := s.store.DeleteCategory(ctx, userId, id, moveTo); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteCategoryResponse{Success: true, Message: "Category deleted"}, nil
+}
+
+// MergeCategories
+func (s *categoriesServer) MergeCategories(ctx context.Context, req *pb.MergeCategoriesRequest) (*pb.CategoryResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sourceId, err := parseId("source_id", req.SourceId)
+	if err != nil {
+		return nil, err
+	}
+	targetId, err := parseId("target_id", req.TargetId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteCategory(ctx, userId, sourceId, targetId); err != nil {
+		return nil, mergeFieldNames(err)
+	}
+	target, err := s.store.GetCategory(ctx, userId, targetId)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CategoryResponse{Success: true, Category: pbCategoryFromCategory(target), Message: "Categories merged"}, nil
+}
+
+// mergeFieldNames reports the move_to violations of DeleteCategory
+// against the target_id of a merge.
+func mergeFieldNames(err error) error {
+	validation, ok := err.(*db.ValidationError)
+	if !ok {
+		return err
+	}
+	for i, v := range validation.Violations {
+		if v.Field == "move_to" {
+			validation.Violations[i].Field = "target_id"
+		}
+	}
+	return validation
+}
+
+func categoryTypeFromPb(t *pb.RecordType) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+func pbCategoryFromCategory(c db.Category) *pb.Category {
+	category := &pb.Category{
+		Id:        c.ID.Hex(),
+		Name:      c.Name,
+		Icon:      c.Icon,
+		Color:     c.Color,
+		UserId:    c.UserId.Hex(),
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+	if !c.ParentId.IsZero() {
+		category.ParentId = c.ParentId.Hex()
+	}
+	if c.Type != "" {
+		t := strToEnumType(c.Type)
+		category.Type = &t
+	}
+	return category
+}
+
+func RegisterCategoriesService(s *grpc.Server, store db.CategoryStore) {
+	pb.RegisterCategoriesServiceServer(s, &categoriesServer{store: store})
+}
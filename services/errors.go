@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorInterceptor translates the errors returned by every unary handler
+// into gRPC statuses, see toStatus.
+func ErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		res, err := handler(ctx, req)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+		return res, nil
+	}
+}
+
+// StreamErrorInterceptor is ErrorInterceptor for streaming handlers.
+func StreamErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return toStatus(handler(srv, ss))
+	}
+}
+
+// toStatus is the single mapping from db and validation errors to gRPC
+// statuses, errors that already are statuses are kept as they are.
+// Anything unexpected is logged and hidden behind Internal.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var validation *db.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		return invalidArgument(validation)
+	case errors.Is(err, db.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, db.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, db.ErrVersionMismatch):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, db.ErrFailedPrecondition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, db.ErrUnavailable):
+		log.Printf("storage unavailable: %v\n", err)
+		return status.Error(codes.Unavailable, "storage is unavailable, try again later")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	log.Printf("internal error: %v\n", err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+// invalidArgument attaches the violations as google.rpc.BadRequest details.
+func invalidArgument(err *db.ValidationError) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range err.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(br)
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return st.Err()
+}
+
+// parseId parses the hex id sent in the given request field.
+func parseId(field string, hex string) (primitive.ObjectID, error) {
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return id, db.InvalidField(field, fmt.Errorf("'%s' is not a valid id", hex))
+	}
+	return id, nil
+}
+
+// parseOptionalId is parseId accepting an empty field as the NilObjectID.
+func parseOptionalId(field string, hex string) (primitive.ObjectID, error) {
+	if hex == "" {
+		return primitive.NilObjectID, nil
+	}
+	return parseId(field, hex)
+}
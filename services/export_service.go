@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/exports"
+	"github.com/fine-track/journals-app/pb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ExportRecords
+func (s *recordsServer) ExportRecords(req *pb.ExportRecordsRequest, stream pb.RecordsService_ExportRecordsServer) error {
+	ctx := stream.Context()
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	filter := db.RecordFilter{}
+	if req.Filter != nil {
+		if filter, err = recordFilterFromPb(req.Filter); err != nil {
+			return err
+		}
+		if filter.Categories, err = s.categorySubtree(ctx, userId, req.Filter.CategoryId); err != nil {
+			return err
+		}
+	}
+	format, err := s.exportFormat(ctx, userId, req, &filter)
+	if err != nil {
+		return err
+	}
+	// pages of a fixed sort keep a single page in memory and give every
+	// chunk a cursor to resume after
+	query := db.RecordQuery{
+		UserId:   userId,
+		Filter:   filter,
+		Sort:     db.RecordSort{Field: db.SortByDate, Ascending: true},
+		PageSize: db.MaxPageSize,
+	}
+	if err := query.Validate(); err != nil {
+		return err
+	}
+	if query.After, err = db.DecodePageToken(query, req.ResumeToken); err != nil {
+		return db.InvalidField("resume_token", fmt.Errorf("resume token is invalid or does not match the request"))
+	}
+	buf := bytes.Buffer{}
+	if query.After == nil {
+		if err := format.Begin(&buf); err != nil {
+			return err
+		}
+	}
+	for {
+		page, err := s.store.GetUserRecords(ctx, query)
+		if err != nil {
+			return err
+		}
+		for _, r := range page.Records {
+			if err := format.Write(&buf, r); err != nil {
+				return err
+			}
+		}
+		if n := len(page.Records); n > 0 {
+			last := page.Records[n-1]
+			query.After = &db.RecordCursor{Value: query.Sort.SortValue(last), ID: last.ID}
+		}
+		if page.Next == nil {
+			if err := format.End(&buf); err != nil {
+				return err
+			}
+		}
+		err = stream.Send(&pb.ExportRecordsResponse{
+			Chunk:       buf.Bytes(),
+			ResumeToken: db.EncodePageToken(query, query.After),
+			RecordCount: int32(len(page.Records)),
+		})
+		if err != nil || page.Next == nil {
+			return err
+		}
+		buf.Reset()
+	}
+}
+
+// exportFormat builds the format of the request, narrowing the filter of
+// OFX exports down to the currency of their statement.
+func (s *recordsServer) exportFormat(ctx context.Context, userId primitive.ObjectID, req *pb.ExportRecordsRequest, filter *db.RecordFilter) (exports.Format, error) {
+	switch req.Format {
+	case pb.ExportFormat_EXPORT_CSV:
+		f := &exports.CSV{Columns: req.Columns}
+		if req.Delimiter != "" {
+			if utf8.RuneCountInString(req.Delimiter) != 1 {
+				return nil, db.InvalidField("delimiter", fmt.Errorf("delimiter should be a single character"))
+			}
+			f.Delimiter, _ = utf8.DecodeRuneInString(req.Delimiter)
+		}
+		var err error
+		if f.Location, err = locationFromPb("time_zone", req.TimeZone); err != nil {
+			return nil, err
+		}
+		if err := f.Validate(); err != nil {
+			return nil, err
+		}
+		categories, accounts := f.NeedsNames()
+		if categories {
+			list, err := s.store.ListCategories(ctx, userId)
+			if err != nil {
+				return nil, err
+			}
+			f.Categories = map[primitive.ObjectID]string{}
+			for _, c := range list {
+				f.Categories[c.ID] = c.Name
+			}
+		}
+		if accounts {
+			list, err := s.store.ListAccounts(ctx, userId)
+			if err != nil {
+				return nil, err
+			}
+			f.Accounts = map[primitive.ObjectID]string{}
+			for _, a := range list {
+				f.Accounts[a.ID] = a.Name
+			}
+		}
+		return f, nil
+	case pb.ExportFormat_EXPORT_NDJSON:
+		marshal := protojson.MarshalOptions{UseProtoNames: true}
+		return &exports.NDJSON{Marshal: func(r db.Record) ([]byte, error) {
+			return marshal.Marshal(pbRecordFromRecord(r))
+		}}, nil
+	case pb.ExportFormat_EXPORT_OFX:
+		if filter.Currency == "" && !filter.AccountId.IsZero() {
+			a, err := s.store.GetAccount(ctx, userId, filter.AccountId)
+			if err != nil {
+				return nil, err
+			}
+			filter.Currency = a.Currency
+		}
+		if filter.Currency == "" {
+			return nil, db.InvalidField("filter.currency_code", fmt.Errorf("an OFX statement holds a single currency, select it or an account"))
+		}
+		return &exports.OFX{Currency: filter.Currency, AccountId: filter.AccountId, From: filter.From, To: filter.To}, nil
+	}
+	return nil, db.InvalidField("format", fmt.Errorf("unknown export format %d", req.Format))
+}
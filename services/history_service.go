@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// GetRecordHistory
+func (s *recordsServer) GetRecordHistory(ctx context.Context, req *pb.GetRecordHistoryRequest) (*pb.GetRecordHistoryResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.store.GetRecordHistory(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	// records written before the history was kept have none
+	if len(revisions) == 0 {
+		if _, err := s.store.Get(ctx, userId, id); err != nil {
+			return nil, err
+		}
+	}
+
+	pbRevisions := []*pb.Revision{}
+	for _, rev := range revisions {
+		pbRevisions = append(pbRevisions, pbRevisionFromRevision(rev))
+	}
+	return &pb.GetRecordHistoryResponse{
+		Success:   true,
+		Revisions: pbRevisions,
+		Message:   "History found",
+	}, nil
+}
+
+// RevertRecord
+func (s *recordsServer) RevertRecord(ctx context.Context, req *pb.RevertRecordRequest) (*pb.UpdateRecordResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withActor(ctx, userId)
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	revisionId, err := parseId("revision_id", req.RevisionId)
+	if err != nil {
+		return nil, err
+	}
+	revisions, err := s.store.GetRecordHistory(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	var target *db.Revision
+	for i := range revisions {
+		if revisions[i].ID == revisionId {
+			target = &revisions[i]
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: record %s has no revision %s", db.ErrNotFound, id.Hex(), revisionId.Hex())
+	}
+	current, err := s.store.Get(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Version != 0 && req.Version != current.Version {
+		return nil, db.ErrVersionMismatch
+	}
+
+	// writing at the version read keeps a concurrent change from being
+	// overwritten
+	r := target.Record
+	r.UserId, r.ID, r.Version = userId, id, current.Version
+	if fields := db.RevertFields(current, *target); len(fields) > 0 {
+		if err := s.store.Update(ctx, &r, fields); err != nil {
+			return nil, err
+		}
+	} else {
+		r = current
+	}
+	return &pb.UpdateRecordResponse{
+		Success: true,
+		Record:  pbRecordFromRecord(r),
+		Message: "Record reverted",
+	}, nil
+}
+
+// withActor is ctx carrying the user and client of the call for the
+// history of the records it writes.
+func withActor(ctx context.Context, userId primitive.ObjectID) context.Context {
+	actor := db.Actor{UserId: userId}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("user-agent"); len(values) > 0 {
+		actor.UserAgent = values[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		actor.Address = p.Addr.String()
+	}
+	return db.WithActor(ctx, actor)
+}
+
+var revisionActions = map[string]pb.RevisionAction{
+	db.RevisionCreated:  pb.RevisionAction_REVISION_CREATED,
+	db.RevisionUpdated:  pb.RevisionAction_REVISION_UPDATED,
+	db.RevisionDeleted:  pb.RevisionAction_REVISION_DELETED,
+	db.RevisionRestored: pb.RevisionAction_REVISION_RESTORED,
+}
+
+func pbRevisionFromRevision(rev db.Revision) *pb.Revision {
+	changes := []*pb.FieldChange{}
+	for _, c := range rev.Changes {
+		changes = append(changes, &pb.FieldChange{Field: c.Field, OldValue: c.OldValue, NewValue: c.NewValue})
+	}
+	r := &pb.Revision{
+		Id:        rev.ID.Hex(),
+		RecordId:  rev.RecordId.Hex(),
+		Action:    revisionActions[rev.Action],
+		UserAgent: rev.Actor.UserAgent,
+		Address:   rev.Actor.Address,
+		Changes:   changes,
+		Record:    pbRecordFromRecord(rev.Record),
+		CreatedAt: timestamppb.New(rev.CreatedAt),
+	}
+	if !rev.Actor.UserId.IsZero() {
+		r.ActorId = rev.Actor.UserId.Hex()
+	}
+	return r
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	// IdempotencyKeyHeader is the metadata carrying the idempotency key of
+	// a call, instead of the idempotency_key field of its request.
+	IdempotencyKeyHeader = "idempotency-key"
+	// ReplayedHeader is set to "true" on the header of a response replayed
+	// for a retry.
+	ReplayedHeader = "idempotent-replayed"
+	// DefaultIdempotencyWindow is how long a key answers retries unless
+	// configured otherwise.
+	DefaultIdempotencyWindow = 24 * time.Hour
+	// maxIdempotencyKeyLength bounds the keys users choose.
+	maxIdempotencyKeyLength = 255
+	// idempotencyLock is how long a call holds its key before a retry can
+	// run it again, in case the server stopped before the call completed;
+	// a running call renews it.
+	idempotencyLock = time.Minute
+)
+
+// IdempotencyInterceptor answers the retry of a call, made with the same
+// idempotency key by the same user within window, with the response of the
+// first call instead of running it again. Only the methods whose request
+// has an idempotency_key field take keys. Reusing a key for another request
+// fails with InvalidArgument, retrying while the first call still runs
+// with Aborted. Failed calls are not kept, their retries run again.
+func IdempotencyInterceptor(store db.IdempotencyStore, window time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		field := msg.ProtoReflect().Descriptor().Fields().ByName("idempotency_key")
+		if field == nil || field.Kind() != protoreflect.StringKind {
+			return handler(ctx, req)
+		}
+		key, err := idempotencyKey(ctx, msg, field)
+		if err != nil {
+			return nil, err
+		}
+		if key == "" {
+			return handler(ctx, req)
+		}
+		userId, err := currentUser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		hash, err := requestHash(info.FullMethod, msg, field)
+		if err != nil {
+			return nil, err
+		}
+		reserved, err := reserveKey(ctx, store, window, userId, key, hash)
+		if errors.Is(err, db.ErrAlreadyExists) {
+			res, err := storedResponse(reserved, hash)
+			if err != nil {
+				return nil, err
+			}
+			if err := grpc.SetHeader(ctx, metadata.Pairs(ReplayedHeader, "true")); err != nil {
+				return nil, err
+			}
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		release := holdKey(store, reserved)
+		res, err := handler(ctx, req)
+		release()
+		if err != nil {
+			completeKey(store, reserved, nil)
+			return nil, err
+		}
+		completeKey(store, reserved, res.(proto.Message))
+		return res, nil
+	}
+}
+
+// StreamIdempotencyInterceptor is the IdempotencyInterceptor of the client
+// streams, such as imports, whose request has an idempotency_key field. The
+// key is read from the first message and the call is identified by every
+// message of the stream: a retry is read to its end before it is answered
+// with the stored response.
+func StreamIdempotencyInterceptor(store db.IdempotencyStore, window time.Duration) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !info.IsClientStream || info.IsServerStream {
+			return handler(srv, ss)
+		}
+		msg, err := newRequest(info.FullMethod)
+		if err != nil {
+			return handler(srv, ss)
+		}
+		field := msg.ProtoReflect().Descriptor().Fields().ByName("idempotency_key")
+		if field == nil || field.Kind() != protoreflect.StringKind {
+			return handler(srv, ss)
+		}
+		ctx := ss.Context()
+		stream := &idempotentStream{ServerStream: ss}
+		if err := ss.RecvMsg(msg); errors.Is(err, io.EOF) {
+			// the handler tells an empty stream apart
+			return handler(srv, stream)
+		} else if err != nil {
+			return err
+		}
+		stream.first = msg
+		key, err := idempotencyKey(ctx, msg, field)
+		if err != nil {
+			return err
+		}
+		if key == "" {
+			return handler(srv, stream)
+		}
+		userId, err := currentUser(ctx)
+		if err != nil {
+			return err
+		}
+		stream.hash = newStreamHash(info.FullMethod, msg, field)
+		if err := stream.hash.add(msg); err != nil {
+			return err
+		}
+		// the hash of the stream is only known once read, the reservation
+		// gets it on completion
+		reserved, err := reserveKey(ctx, store, window, userId, key, "")
+		if errors.Is(err, db.ErrAlreadyExists) {
+			if reserved.Response == nil {
+				return errKeyRunning
+			}
+			if err := stream.drain(); err != nil {
+				return err
+			}
+			res, err := storedResponse(reserved, stream.hash.sum())
+			if err != nil {
+				return err
+			}
+			if err := ss.SetHeader(metadata.Pairs(ReplayedHeader, "true")); err != nil {
+				return err
+			}
+			return ss.SendMsg(res)
+		}
+		if err != nil {
+			return err
+		}
+
+		release := holdKey(store, reserved)
+		err = handler(srv, stream)
+		if err == nil {
+			err = stream.drain()
+		}
+		release()
+		if err != nil {
+			completeKey(store, reserved, nil)
+			return err
+		}
+		reserved.RequestHash = stream.hash.sum()
+		completeKey(store, reserved, stream.response)
+		return nil
+	}
+}
+
+// newRequest is an empty request message of the method.
+func newRequest(method string) (proto.Message, error) {
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", "."))
+	d, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a method", name)
+	}
+	t, err := protoregistry.GlobalTypes.FindMessageByName(m.Input().FullName())
+	if err != nil {
+		return nil, err
+	}
+	return t.New().Interface(), nil
+}
+
+// idempotentStream hands the first message, received by the interceptor,
+// to the handler, adds the messages the handler receives to the hash of
+// the stream and keeps the response the handler sends.
+type idempotentStream struct {
+	grpc.ServerStream
+	first    proto.Message
+	hash     *streamHash
+	response proto.Message
+}
+
+func (s *idempotentStream) RecvMsg(m any) error {
+	if s.first == nil {
+		if err := s.ServerStream.RecvMsg(m); err != nil || s.hash == nil {
+			return err
+		}
+		return s.hash.add(m.(proto.Message))
+	}
+	first := s.first
+	s.first = nil
+	msg := m.(proto.Message)
+	proto.Reset(msg)
+	proto.Merge(msg, first)
+	return nil
+}
+
+func (s *idempotentStream) SendMsg(m any) error {
+	if msg, ok := m.(proto.Message); ok {
+		s.response = msg
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// drain receives the messages left on the stream, so the hash covers all
+// of them.
+func (s *idempotentStream) drain() error {
+	for {
+		if s.first != nil {
+			s.first = nil
+			continue
+		}
+		msg := s.hash.newMessage()
+		err := s.RecvMsg(msg)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// streamHash identifies the method and every message of a client stream,
+// their keys aside.
+type streamHash struct {
+	hash.Hash
+	field   protoreflect.FieldDescriptor
+	request protoreflect.MessageType
+}
+
+func newStreamHash(method string, first proto.Message, field protoreflect.FieldDescriptor) *streamHash {
+	h := &streamHash{Hash: sha256.New(), field: field, request: first.ProtoReflect().Type()}
+	h.Write([]byte(method + "\x00"))
+	return h
+}
+
+// add hashes msg, its length first so the messages cannot run into each
+// other.
+func (h *streamHash) add(msg proto.Message) error {
+	payload := proto.Clone(msg)
+	payload.ProtoReflect().Clear(h.field)
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	h.Write(binary.AppendUvarint(nil, uint64(len(b))))
+	h.Write(b)
+	return nil
+}
+
+// newMessage is an empty message of the stream.
+func (h *streamHash) newMessage() proto.Message {
+	return h.request.New().Interface()
+}
+
+func (h *streamHash) sum() string {
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// reserveKey reserves the user's key for a call with the given hash, or
+// returns the stored key with ErrAlreadyExists.
+func reserveKey(ctx context.Context, store db.IdempotencyStore, window time.Duration, userId primitive.ObjectID, key, hash string) (db.IdempotencyKey, error) {
+	now := db.Now()
+	return store.ReserveKey(ctx, db.IdempotencyKey{
+		UserId:      userId,
+		Key:         key,
+		RequestHash: hash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(window),
+		LockedUntil: now.Add(idempotencyLock),
+	})
+}
+
+// holdKey renews the lock of the reservation k while its call runs, until
+// the returned release is called.
+func holdKey(store db.IdempotencyStore, k db.IdempotencyKey) (release func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyLock / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			k.LockedUntil = db.Now().Add(idempotencyLock)
+			err := store.RenewKey(ctx, k)
+			cancel()
+			if err != nil {
+				log.Printf("failed to renew idempotency key: %v\n", err)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// completeKey stores the response of the call of the reservation k, or
+// releases the key of a failed call, which has no response.
+func completeKey(store db.IdempotencyStore, k db.IdempotencyKey, res proto.Message) {
+	// the key outlives a call canceled by its client, which is when the
+	// client retries
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if res == nil {
+		if err := store.ReleaseKey(ctx, k); err != nil {
+			log.Printf("failed to release idempotency key: %v\n", err)
+		}
+		return
+	}
+	response, err := anypb.New(res)
+	if err == nil {
+		if k.Response, err = proto.Marshal(response); err == nil {
+			err = store.CompleteKey(ctx, k)
+		}
+	}
+	if err != nil {
+		log.Printf("failed to store the response of idempotency key: %v\n", err)
+	}
+}
+
+// idempotencyKey is the key of the request, from its field or metadata.
+func idempotencyKey(ctx context.Context, msg proto.Message, field protoreflect.FieldDescriptor) (string, error) {
+	key := msg.ProtoReflect().Get(field).String()
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(IdempotencyKeyHeader); len(values) > 0 {
+		if key != "" && key != values[0] {
+			return "", db.InvalidField("idempotency_key", fmt.Errorf("the key differs from the %s metadata", IdempotencyKeyHeader))
+		}
+		key = values[0]
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return "", db.InvalidField("idempotency_key", fmt.Errorf("key should be at most %d characters", maxIdempotencyKeyLength))
+	}
+	return key, nil
+}
+
+// requestHash identifies the method and payload of a call, its key aside.
+func requestHash(method string, msg proto.Message, field protoreflect.FieldDescriptor) (string, error) {
+	payload := proto.Clone(msg)
+	payload.ProtoReflect().Clear(field)
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(method+"\x00"), b...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// errKeyRunning answers the retry of a call that still runs.
+var errKeyRunning = status.Error(codes.Aborted, "a call with the same idempotency key is still running")
+
+// storedResponse is the response stored for the key of a call.
+func storedResponse(stored db.IdempotencyKey, hash string) (proto.Message, error) {
+	if stored.RequestHash != hash {
+		return nil, db.InvalidField("idempotency_key", fmt.Errorf("the key was used for another request"))
+	}
+	if stored.Response == nil {
+		return nil, errKeyRunning
+	}
+	response := &anypb.Any{}
+	if err := proto.Unmarshal(stored.Response, response); err != nil {
+		return nil, err
+	}
+	return response.UnmarshalNew()
+}
+
+// RunKeyPurger removes the expired idempotency keys every interval until
+// ctx is done.
+func RunKeyPurger(ctx context.Context, store db.IdempotencyStore, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if n, err := store.PurgeKeys(ctx, db.Now()); err != nil {
+			log.Printf("failed to purge idempotency keys: %v\n", err)
+		} else if n > 0 {
+			log.Printf("purged %d expired idempotency keys\n", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
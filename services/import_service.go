@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/imports"
+	"github.com/fine-track/journals-app/pb"
+)
+
+// ImportRecords
+func (s *recordsServer) ImportRecords(stream pb.RecordsService_ImportRecordsServer) error {
+	ctx := stream.Context()
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = withActor(ctx, userId)
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return db.InvalidField("mapping", fmt.Errorf("the first message should carry the mapping"))
+	}
+	if err != nil {
+		return err
+	}
+	mapping, err := csvMappingFromPb(first.Mapping)
+	if err != nil {
+		return err
+	}
+	if err := mapping.Validate(); err != nil {
+		return err
+	}
+	opts := imports.Options{UserId: userId, DryRun: first.DryRun}
+	accountId, err := parseOptionalId("mapping.account_id", first.Mapping.GetAccountId())
+	if err != nil {
+		return err
+	}
+	if !accountId.IsZero() {
+		if opts.Account, err = s.store.GetAccount(ctx, userId, accountId); err != nil {
+			return err
+		}
+	}
+	recv := func() ([]byte, error) {
+		req, err := stream.Recv()
+		return req.GetChunk(), err
+	}
+	src := imports.NewCSVSource(&chunkReader{recv: recv, chunk: first.Chunk}, mapping)
+	result, err := imports.Import(ctx, s.store, src, opts)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(pbImportResponse(result, first.DryRun))
+}
+
+// ImportStatement
+func (s *recordsServer) ImportStatement(stream pb.RecordsService_ImportStatementServer) error {
+	ctx := stream.Context()
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return err
+	}
+	ctx = withActor(ctx, userId)
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return db.InvalidField("chunk", fmt.Errorf("the statement is empty"))
+	}
+	if err != nil {
+		return err
+	}
+	statementOpts := imports.StatementOptions{Currency: first.CurrencyCode, DateOrder: first.DateOrder.String()}
+	if statementOpts.Location, err = locationFromPb("time_zone", first.TimeZone); err != nil {
+		return err
+	}
+	if err := statementOpts.Validate(); err != nil {
+		return err
+	}
+	opts := imports.Options{UserId: userId, DryRun: first.DryRun}
+	accountId, err := parseOptionalId("account_id", first.AccountId)
+	if err != nil {
+		return err
+	}
+	if !accountId.IsZero() {
+		if opts.Account, err = s.store.GetAccount(ctx, userId, accountId); err != nil {
+			return err
+		}
+	}
+	recv := func() ([]byte, error) {
+		req, err := stream.Recv()
+		return req.GetChunk(), err
+	}
+	r := &chunkReader{recv: recv, chunk: first.Chunk}
+	var src imports.Source
+	switch first.Format {
+	case pb.StatementFormat_OFX:
+		src = imports.NewOFXSource(r, statementOpts)
+	case pb.StatementFormat_QIF:
+		src = imports.NewQIFSource(r, statementOpts)
+	default:
+		return db.InvalidField("format", fmt.Errorf("unknown statement format %d", first.Format))
+	}
+	result, err := imports.Import(ctx, s.store, src, opts)
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(pbImportResponse(result, first.DryRun))
+}
+
+func csvMappingFromPb(m *pb.ImportMapping) (imports.CSVMapping, error) {
+	if m == nil {
+		return imports.CSVMapping{}, db.InvalidField("mapping", fmt.Errorf("mapping is required"))
+	}
+	mapping := imports.CSVMapping{
+		HasHeader:          m.HasHeader,
+		DateColumn:         int(m.DateColumn),
+		AmountColumn:       int(m.AmountColumn),
+		TypeColumn:         int(m.TypeColumn),
+		TitleColumn:        int(m.TitleColumn),
+		DescriptionColumn:  int(m.DescriptionColumn),
+		TagsColumn:         int(m.TagsColumn),
+		CurrencyColumn:     int(m.CurrencyColumn),
+		DateFormat:         m.DateFormat,
+		DecimalSeparator:   m.DecimalSeparator,
+		ThousandsSeparator: m.ThousandsSeparator,
+		Sign:               m.Sign.String(),
+		IncomeValues:       m.IncomeValues,
+		ExpenseValues:      m.ExpenseValues,
+		TagSeparator:       m.TagSeparator,
+		Currency:           m.CurrencyCode,
+	}
+	if m.Delimiter != "" {
+		if utf8.RuneCountInString(m.Delimiter) != 1 {
+			return mapping, db.InvalidField("mapping.delimiter", fmt.Errorf("delimiter should be a single character"))
+		}
+		mapping.Delimiter, _ = utf8.DecodeRuneInString(m.Delimiter)
+	}
+	var err error
+	mapping.Location, err = locationFromPb("mapping.time_zone", m.TimeZone)
+	return mapping, err
+}
+
+func pbImportResponse(result imports.Result, dryRun bool) *pb.ImportRecordsResponse {
+	res := &pb.ImportRecordsResponse{
+		Success:    true,
+		Rows:       int32(result.Rows),
+		Imported:   int32(result.Imported),
+		Duplicates: int32(result.Duplicates),
+		ErrorCount: int32(result.ErrorCount),
+		Message:    fmt.Sprintf("%d records imported", result.Imported),
+	}
+	if dryRun {
+		res.Message = fmt.Sprintf("%d records would be imported", result.Imported)
+	}
+	for _, e := range result.Errors {
+		res.Errors = append(res.Errors, &pb.ImportRowError{Line: int32(e.Line), Field: e.Field, Description: e.Description})
+	}
+	return res
+}
+
+// chunkReader reads the chunks of an import stream as a single file.
+type chunkReader struct {
+	recv  func() ([]byte, error)
+	chunk []byte
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		chunk, err := r.recv()
+		if err != nil {
+			return 0, err
+		}
+		r.chunk = chunk
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
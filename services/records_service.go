@@ -2,101 +2,247 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"time"
 
+	"github.com/fine-track/journals-app/auth"
 	"github.com/fine-track/journals-app/db"
 	"github.com/fine-track/journals-app/pb"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type recordsServer struct {
 	pb.UnimplementedRecordsServiceServer
+	store db.Store
 }
 
 // Create
 func (s *recordsServer) Create(ctx context.Context, req *pb.CreateRecordRequest) (*pb.UpdateRecordResponse, error) {
-	userId, err := primitive.ObjectIDFromHex(req.UserId)
+	userId, err := currentUser(ctx)
 	if err != nil {
 		return nil, err
 	}
-	record := db.Record{
-		Type:        req.Type.String(),
-		Title:       req.Title,
-		Description: req.Description,
-		Amount:      req.Amount,
-		Date:        req.Date,
-		UserId:      userId,
+	ctx = withActor(ctx, userId)
+	record, err := recordFromPb(userId, req)
+	if err != nil {
+		return nil, err
 	}
-	if err := record.New(); err != nil {
+	if err := s.store.Create(ctx, &record); err != nil {
 		return nil, err
 	} else {
 		return &pb.UpdateRecordResponse{
 			Success: true,
 			Record:  pbRecordFromRecord(record),
+			Message: "Record created",
 		}, nil
 	}
 }
 
 // Delete
 func (s *recordsServer) Delete(ctx context.Context, req *pb.DeleteRecordRequest) (*pb.DeleteRecordResponse, error) {
-	r := db.Record{}
-	if err := r.Delete(req.Id); err != nil {
+	userId, err := currentUser(ctx)
+	if err != nil {
 		return nil, err
-	} else {
-		return &pb.DeleteRecordResponse{Success: true}, nil
 	}
+	ctx = withActor(ctx, userId)
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.Delete(ctx, userId, id, req.Version); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteRecordResponse{Success: true, Message: "Record moved to the trash"}, nil
 }
 
-func (s *recordsServer) Update(ctx context.Context, req *pb.Record) (*pb.UpdateRecordResponse, error) {
-	id, err := primitive.ObjectIDFromHex(req.Id)
+// GetRecord
+func (s *recordsServer) GetRecord(ctx context.Context, req *pb.GetRecordRequest) (*pb.UpdateRecordResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.store.Get(ctx, userId, id)
 	if err != nil {
 		return nil, err
 	}
+	return &pb.UpdateRecordResponse{
+		Success: true,
+		Record:  pbRecordFromRecord(record),
+		Message: "Record found",
+	}, nil
+}
 
-	userId, err := primitive.ObjectIDFromHex(req.UserId)
+// Update
+func (s *recordsServer) Update(ctx context.Context, req *pb.UpdateRecordRequest) (*pb.UpdateRecordResponse, error) {
+	userId, err := currentUser(ctx)
 	if err != nil {
 		return nil, err
 	}
+	ctx = withActor(ctx, userId)
 
-	r := db.Record{
-		UserId:      userId,
-		ID:          id,
-		Type:        req.Type.String(),
-		Date:        req.Date,
-		Title:       req.Title,
-		Description: req.Description,
-		Amount:      req.Amount,
+	r, fields, err := recordUpdateFromPb(userId, req)
+	if err != nil {
+		return nil, err
 	}
-	if err := r.Update(); err != nil {
+	if err := s.store.Update(ctx, &r, fields); err != nil {
 		return nil, err
-	} else {
-		return &pb.UpdateRecordResponse{
-			Success: true,
-			Record:  pbRecordFromRecord(r),
-		}, nil
 	}
+	return &pb.UpdateRecordResponse{
+		Success: true,
+		Record:  pbRecordFromRecord(r),
+		Message: "Record updated",
+	}, nil
 }
 
 // GetRecords
 func (s *recordsServer) GetRecords(ctx context.Context, req *pb.GetRecordsRequest) (*pb.GetRecordsResponse, error) {
-	recordsList, err := db.GetUserRecords(req.UserId, req.Type.String(), req.Page)
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filter := db.RecordFilter{Type: req.Type.String()}
+	if req.Filter != nil {
+		if filter, err = recordFilterFromPb(req.Filter); err != nil {
+			return nil, err
+		}
+		if filter.Categories, err = s.categorySubtree(ctx, userId, req.Filter.CategoryId); err != nil {
+			return nil, err
+		}
+	}
+	query := db.RecordQuery{
+		UserId:     userId,
+		Filter:     filter,
+		Sort:       recordSortFromPb(req.Sort),
+		PageSize:   int(req.PageSize),
+		CountTotal: req.IncludeTotal,
+	}
+	if query.After, err = db.DecodePageToken(query, req.PageToken); err != nil {
+		return nil, err
+	}
+	page, err := s.store.GetUserRecords(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 
 	pbRecords := []*pb.Record{}
-	for _, record := range recordsList {
+	for _, record := range page.Records {
 		pbRecords = append(pbRecords, pbRecordFromRecord(record))
 	}
 	res := &pb.GetRecordsResponse{
-		Success:  true,
-		Records:  pbRecords,
-		NextPage: req.Page + 1,
-		Message:  "Records found",
+		Success:       true,
+		Records:       pbRecords,
+		NextPageToken: db.EncodePageToken(query, page.Next),
+		TotalCount:    page.Total,
+		Message:       "Records found",
 	}
 	return res, nil
 }
 
+// CreateTransfer
+func (s *recordsServer) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withActor(ctx, userId)
+	fromId, err := parseOptionalId("from_account_id", req.FromAccountId)
+	if err != nil {
+		return nil, err
+	}
+	toId, err := parseOptionalId("to_account_id", req.ToAccountId)
+	if err != nil {
+		return nil, err
+	}
+	date, err := timeFromPb("date", req.Date)
+	if err != nil {
+		return nil, err
+	}
+	t := db.Transfer{
+		UserId:        userId,
+		FromAccountId: fromId,
+		ToAccountId:   toId,
+		Amount:        moneyFromPb(req.Amount),
+		Date:          date,
+		Title:         req.Title,
+		Description:   req.Description,
+	}
+	debit, credit, err := s.store.CreateTransfer(ctx, &t)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateTransferResponse{
+		Success: true,
+		Debit:   pbRecordFromRecord(debit),
+		Credit:  pbRecordFromRecord(credit),
+		Message: "Transfer created",
+	}, nil
+}
+
+// GetSummary
+func (s *recordsServer) GetSummary(ctx context.Context, req *pb.GetSummaryRequest) (*pb.GetSummaryResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.SummaryQuery{
+		UserId:  userId,
+		Period:  req.Period.String(),
+		GroupBy: summaryGroupFromPb(req.GroupBy),
+	}
+	if req.Filter != nil {
+		if query.Filter, err = recordFilterFromPb(req.Filter); err != nil {
+			return nil, err
+		}
+		if query.Filter.Categories, err = s.categorySubtree(ctx, userId, req.Filter.CategoryId); err != nil {
+			return nil, err
+		}
+	}
+	if query.Location, err = locationFromPb("time_zone", req.TimeZone); err != nil {
+		return nil, err
+	}
+	rows, err := s.store.Summarize(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	pbRows := []*pb.SummaryRow{}
+	for _, row := range rows {
+		pbRows = append(pbRows, &pb.SummaryRow{
+			PeriodStart: timestamppb.New(row.Start),
+			Group:       row.Group,
+			Income:      pbMoneyFromMoney(db.Money{Units: row.Income, Currency: row.Currency}),
+			Expense:     pbMoneyFromMoney(db.Money{Units: row.Expense, Currency: row.Currency}),
+			Net:         pbMoneyFromMoney(db.Money{Units: row.Net(), Currency: row.Currency}),
+			RecordCount: row.Count,
+		})
+	}
+	return &pb.GetSummaryResponse{Success: true, Rows: pbRows, Message: "Summary computed"}, nil
+}
+
+var summaryGroups = map[pb.SummaryGroup]string{
+	pb.SummaryGroup_NO_GROUP:    "",
+	pb.SummaryGroup_BY_CATEGORY: "CATEGORY",
+	pb.SummaryGroup_BY_TAG:      "TAG",
+	pb.SummaryGroup_BY_ACCOUNT:  "ACCOUNT",
+}
+
+func summaryGroupFromPb(g pb.SummaryGroup) string {
+	group, ok := summaryGroups[g]
+	if !ok {
+		// left for the query validation to reject
+		group = g.String()
+	}
+	return group
+}
+
 func (s *recordsServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.PingResponse, error) {
 	res := &pb.PingResponse{
 		Message:  req.Message,
@@ -105,6 +251,37 @@ func (s *recordsServer) Ping(ctx context.Context, req *pb.PingRequest) (*pb.Ping
 	return res, nil
 }
 
+// categorySubtree lists the category with the given hex id and its
+// subcategories for the records filter, nil when no category is given.
+func (s *recordsServer) categorySubtree(ctx context.Context, userId primitive.ObjectID, hex string) ([]primitive.ObjectID, error) {
+	if hex == "" {
+		return nil, nil
+	}
+	id, err := parseId("filter.category_id", hex)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.store.ListCategories(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	tree := db.NewCategoryTree(categories)
+	if _, ok := tree[id]; !ok {
+		return nil, db.InvalidField("filter.category_id", fmt.Errorf("category '%s' does not exist", hex))
+	}
+	return tree.Descendants(id), nil
+}
+
+// currentUser is the user authenticated by the auth interceptors, the
+// request bodies are never trusted for it.
+func currentUser(ctx context.Context) (primitive.ObjectID, error) {
+	userId, ok := auth.UserFromContext(ctx)
+	if !ok {
+		return primitive.NilObjectID, status.Error(codes.Unauthenticated, "not authenticated")
+	}
+	return userId, nil
+}
+
 func strToEnumType(t string) pb.RecordType {
 	if t == "EXPENSE" {
 		return pb.RecordType_EXPENSE
@@ -117,17 +294,198 @@ func pbRecordFromRecord(record db.Record) *pb.Record {
 	r := &pb.Record{
 		Id:          record.ID.Hex(),
 		Type:        strToEnumType(record.Type),
-		Amount:      record.Amount,
+		Amount:      pbMoneyFromMoney(record.Amount),
 		Title:       record.Title,
-		Date:        record.Date,
+		Date:        timestamppb.New(record.Date),
 		UserId:      record.UserId.Hex(),
 		Description: record.Description,
-		CreatedAt:   record.CreatedAt,
-		UpdatedAt:   record.UpdatedAt,
+		CreatedAt:   timestamppb.New(record.CreatedAt),
+		UpdatedAt:   timestamppb.New(record.UpdatedAt),
+		Version:     record.Version,
+		Tags:        record.Tags,
+	}
+	if !record.CategoryId.IsZero() {
+		r.CategoryId = record.CategoryId.Hex()
+	}
+	if !record.AccountId.IsZero() {
+		r.AccountId = record.AccountId.Hex()
+	}
+	if record.IsTransfer() {
+		r.TransferId = record.TransferId.Hex()
+	}
+	if !record.ScheduleId.IsZero() {
+		r.ScheduleId = record.ScheduleId.Hex()
+	}
+	r.ImportId = record.ImportId
+	if !record.DeletedAt.IsZero() {
+		r.DeletedAt = timestamppb.New(record.DeletedAt)
 	}
 	return r
 }
 
-func RegisterRecordsService(s *grpc.Server) {
-	pb.RegisterRecordsServiceServer(s, &recordsServer{})
+// recordFromPb is the record a CreateRecordRequest creates.
+func recordFromPb(userId primitive.ObjectID, req *pb.CreateRecordRequest) (db.Record, error) {
+	date, err := timeFromPb("date", req.Date)
+	if err != nil {
+		return db.Record{}, err
+	}
+	categoryId, err := parseOptionalId("category_id", req.CategoryId)
+	if err != nil {
+		return db.Record{}, err
+	}
+	accountId, err := parseOptionalId("account_id", req.AccountId)
+	if err != nil {
+		return db.Record{}, err
+	}
+	return db.Record{
+		Type:        req.Type.String(),
+		Title:       req.Title,
+		Description: req.Description,
+		Amount:      moneyFromPb(req.Amount),
+		Date:        date,
+		CategoryId:  categoryId,
+		Tags:        req.Tags,
+		AccountId:   accountId,
+		UserId:      userId,
+	}, nil
+}
+
+// recordUpdateFromPb is the record and fields an UpdateRecordRequest writes.
+func recordUpdateFromPb(userId primitive.ObjectID, req *pb.UpdateRecordRequest) (db.Record, []string, error) {
+	id, err := parseId("record.id", req.Record.GetId())
+	if err != nil {
+		return db.Record{}, nil, err
+	}
+	fields, err := fieldsFromMask(req.UpdateMask, db.RecordFields)
+	if err != nil {
+		return db.Record{}, nil, err
+	}
+	date, err := timeFromPb("date", req.Record.GetDate())
+	if err != nil {
+		return db.Record{}, nil, err
+	}
+	categoryId, err := parseOptionalId("category_id", req.Record.GetCategoryId())
+	if err != nil {
+		return db.Record{}, nil, err
+	}
+	accountId, err := parseOptionalId("account_id", req.Record.GetAccountId())
+	if err != nil {
+		return db.Record{}, nil, err
+	}
+	return db.Record{
+		UserId:      userId,
+		ID:          id,
+		Type:        req.Record.GetType().String(),
+		Date:        date,
+		Title:       req.Record.GetTitle(),
+		Description: req.Record.GetDescription(),
+		Amount:      moneyFromPb(req.Record.GetAmount()),
+		Version:     req.Record.GetVersion(),
+		CategoryId:  categoryId,
+		Tags:        req.Record.GetTags(),
+		AccountId:   accountId,
+	}, fields, nil
+}
+
+// timeFromPb leaves a missing timestamp as the zero time for the store
+// validation to reject.
+func timeFromPb(field string, ts *timestamppb.Timestamp) (time.Time, error) {
+	if ts == nil {
+		return time.Time{}, nil
+	}
+	if err := ts.CheckValid(); err != nil {
+		return time.Time{}, db.InvalidField(field, err)
+	}
+	return ts.AsTime(), nil
+}
+
+// locationFromPb loads an IANA time zone, UTC when the name is empty.
+func locationFromPb(field string, name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, db.InvalidField(field, fmt.Errorf("unknown time zone '%s'", name))
+	}
+	return loc, nil
+}
+
+func recordFilterFromPb(f *pb.RecordFilter) (db.RecordFilter, error) {
+	filter := db.RecordFilter{
+		MinAmount:        f.MinAmount,
+		MaxAmount:        f.MaxAmount,
+		Currency:         f.CurrencyCode,
+		Text:             f.Text,
+		AnyTags:          f.AnyTags,
+		AllTags:          f.AllTags,
+		ExcludeTransfers: f.ExcludeTransfers,
+	}
+	if f.Type != nil {
+		filter.Type = f.Type.String()
+	}
+	var err error
+	if filter.AccountId, err = parseOptionalId("filter.account_id", f.AccountId); err != nil {
+		return filter, err
+	}
+	if filter.From, err = timeFromPb("filter.from_date", f.FromDate); err != nil {
+		return filter, err
+	}
+	if filter.To, err = timeFromPb("filter.to_date", f.ToDate); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+var sortFields = map[pb.RecordSortField]db.SortField{
+	pb.RecordSortField_SORT_BY_DATE:       db.SortByDate,
+	pb.RecordSortField_SORT_BY_AMOUNT:     db.SortByAmount,
+	pb.RecordSortField_SORT_BY_CREATED_AT: db.SortByCreatedAt,
+}
+
+func recordSortFromPb(s *pb.RecordSort) db.RecordSort {
+	field, ok := sortFields[s.GetField()]
+	if !ok {
+		// left for the query validation to reject
+		field = db.SortField(s.GetField().String())
+	}
+	return db.RecordSort{Field: field, Ascending: s.GetAscending()}
+}
+
+// fieldsFromMask checks the mask paths against the updatable fields, an
+// empty mask updates all of them.
+func fieldsFromMask(mask *fieldmaskpb.FieldMask, updatable []string) ([]string, error) {
+	fields := []string{}
+	seen := map[string]bool{}
+	for _, path := range mask.GetPaths() {
+		if !contains(updatable, path) {
+			return nil, db.InvalidField("update_mask", fmt.Errorf("'%s' is not an updatable field", path))
+		}
+		if !seen[path] {
+			seen[path] = true
+			fields = append(fields, path)
+		}
+	}
+	return fields, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func moneyFromPb(m *pb.Money) db.Money {
+	return db.Money{Units: m.GetUnits(), Currency: m.GetCurrencyCode()}
+}
+
+func pbMoneyFromMoney(m db.Money) *pb.Money {
+	return &pb.Money{Units: m.Units, CurrencyCode: m.Currency}
+}
+
+func RegisterRecordsService(s *grpc.Server, store db.Store) {
+	pb.RegisterRecordsServiceServer(s, &recordsServer{store: store})
 }
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type schedulesServer struct {
+	pb.UnimplementedSchedulesServiceServer
+	store db.Store
+}
+
+// CreateSchedule
+func (s *schedulesServer) CreateSchedule(ctx context.Context, req *pb.CreateScheduleRequest) (*pb.ScheduleResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := scheduleFromPb(req.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	sc.UserId = userId
+	if err := s.store.CreateSchedule(ctx, &sc); err != nil {
+		return nil, err
+	}
+	if sc, err = s.catchUp(ctx, sc); err != nil {
+		return nil, err
+	}
+	return &pb.ScheduleResponse{Success: true, Schedule: pbScheduleFromSchedule(sc), Message: "Schedule created"}, nil
+}
+
+// GetSchedule
+func (s *schedulesServer) GetSchedule(ctx context.Context, req *pb.GetScheduleRequest) (*pb.ScheduleResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := s.store.GetSchedule(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.ScheduleResponse{Success: true, Schedule: pbScheduleFromSchedule(sc), Message: "Schedule found"}, nil
+}
+
+// UpdateSchedule
+func (s *schedulesServer) UpdateSchedule(ctx context.Context, req *pb.UpdateScheduleRequest) (*pb.ScheduleResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("schedule.id", req.Schedule.GetId())
+	if err != nil {
+		return nil, err
+	}
+	fields, err := fieldsFromMask(req.UpdateMask, db.ScheduleFields)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := scheduleFromPb(req.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	sc.ID = id
+	sc.UserId = userId
+	if err := s.store.UpdateSchedule(ctx, &sc, fields); err != nil {
+		return nil, err
+	}
+	if sc, err = s.catchUp(ctx, sc); err != nil {
+		return nil, err
+	}
+	return &pb.ScheduleResponse{Success: true, Schedule: pbScheduleFromSchedule(sc), Message: "Schedule updated"}, nil
+}
+
+// ListSchedules
+func (s *schedulesServer) ListSchedules(ctx context.Context, req *pb.ListSchedulesRequest) (*pb.ListSchedulesResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.store.ListSchedules(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	pbSchedules := []*pb.Schedule{}
+	for _, sc := range schedules {
+		pbSchedules = append(pbSchedules, pbScheduleFromSchedule(sc))
+	}
+	return &pb.ListSchedulesResponse{Success: true, Schedules: pbSchedules, Message: "Schedules found"}, nil
+}
+
+// DeleteSchedule
+func (s *schedulesServer) DeleteSchedule(ctx context.Context, req *pb.DeleteScheduleRequest) (*pb.DeleteScheduleResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteSchedule(ctx, userId, id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteScheduleResponse{Success: true, Message: "Schedule deleted"}, nil
+}
+
+// SetOccurrence skips or changes a single occurrence of a schedule.
+func (s *schedulesServer) SetOccurrence(ctx context.Context, req *pb.SetOccurrenceRequest) (*pb.ScheduleResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("schedule_id", req.ScheduleId)
+	if err != nil {
+		return nil, err
+	}
+	e, err := occurrenceExceptionFromPb(req.Occurrence)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := s.store.GetSchedule(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := sc.SetException(e); err != nil {
+		return nil, err
+	}
+	if err := s.store.UpdateSchedule(ctx, &sc, []string{"exceptions"}); err != nil {
+		return nil, err
+	}
+	return &pb.ScheduleResponse{Success: true, Schedule: pbScheduleFromSchedule(sc), Message: "Occurrence updated"}, nil
+}
+
+// catchUp records the occurrences of a schedule already due instead of
+// waiting for the materializer, which retries whatever fails here.
+func (s *schedulesServer) catchUp(ctx context.Context, sc db.Schedule) (db.Schedule, error) {
+	if _, err := db.MaterializeSchedule(ctx, s.store, sc, db.Now()); err != nil {
+		log.Printf("failed to materialize schedule %s: %v\n", sc.ID.Hex(), err)
+	}
+	return s.store.GetSchedule(ctx, sc.UserId, sc.ID)
+}
+
+func scheduleFromPb(p *pb.Schedule) (db.Schedule, error) {
+	categoryId, err := parseOptionalId("schedule.category_id", p.GetCategoryId())
+	if err != nil {
+		return db.Schedule{}, err
+	}
+	accountId, err := parseOptionalId("schedule.account_id", p.GetAccountId())
+	if err != nil {
+		return db.Schedule{}, err
+	}
+	start, err := timeFromPb("schedule.start_date", p.GetStartDate())
+	if err != nil {
+		return db.Schedule{}, err
+	}
+	until, err := timeFromPb("schedule.until_date", p.GetUntilDate())
+	if err != nil {
+		return db.Schedule{}, err
+	}
+	return db.Schedule{
+		Type:        p.GetType().String(),
+		Title:       p.GetTitle(),
+		Description: p.GetDescription(),
+		Amount:      moneyFromPb(p.GetAmount()),
+		CategoryId:  categoryId,
+		Tags:        p.GetTags(),
+		AccountId:   accountId,
+		Frequency:   p.GetFrequency().String(),
+		Interval:    int(p.GetInterval()),
+		Start:       start,
+		Until:       until,
+		Count:       int(p.GetCount()),
+	}, nil
+}
+
+func occurrenceExceptionFromPb(p *pb.ScheduleOccurrence) (db.OccurrenceException, error) {
+	date, err := timeFromPb("occurrence.date", p.GetDate())
+	if err != nil {
+		return db.OccurrenceException{}, err
+	}
+	e := db.OccurrenceException{Date: date, Skip: p.GetSkip(), Title: p.GetTitle(), Description: p.GetDescription()}
+	if p.GetAmount() != nil {
+		amount := moneyFromPb(p.GetAmount())
+		e.Amount = &amount
+	}
+	return e, nil
+}
+
+func pbScheduleFromSchedule(sc db.Schedule) *pb.Schedule {
+	p := &pb.Schedule{
+		Id:          sc.ID.Hex(),
+		Type:        strToEnumType(sc.Type),
+		Title:       sc.Title,
+		Description: sc.Description,
+		Amount:      pbMoneyFromMoney(sc.Amount),
+		Tags:        sc.Tags,
+		Frequency:   pb.Frequency(pb.Frequency_value[sc.Frequency]),
+		Interval:    int32(sc.Interval),
+		StartDate:   timestamppb.New(sc.Start),
+		Count:       int32(sc.Count),
+		Exceptions:  []*pb.ScheduleOccurrence{},
+		UserId:      sc.UserId.Hex(),
+		CreatedAt:   timestamppb.New(sc.CreatedAt),
+		UpdatedAt:   timestamppb.New(sc.UpdatedAt),
+	}
+	if !sc.CategoryId.IsZero() {
+		p.CategoryId = sc.CategoryId.Hex()
+	}
+	if !sc.AccountId.IsZero() {
+		p.AccountId = sc.AccountId.Hex()
+	}
+	if !sc.Until.IsZero() {
+		p.UntilDate = timestamppb.New(sc.Until)
+	}
+	if !sc.NextDate.IsZero() {
+		p.NextDate = timestamppb.New(sc.NextDate)
+	}
+	for _, e := range sc.Exceptions {
+		o := &pb.ScheduleOccurrence{Date: timestamppb.New(e.Date), Skip: e.Skip, Title: e.Title, Description: e.Description}
+		if e.Amount != nil {
+			o.Amount = pbMoneyFromMoney(*e.Amount)
+		}
+		p.Exceptions = append(p.Exceptions, o)
+	}
+	return p
+}
+
+// RunMaterializer records the due occurrences of every schedule now and
+// then every interval until ctx is done, catching up on the ones missed
+// while the server was down.
+func RunMaterializer(ctx context.Context, store db.Store, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if n, err := db.Materialize(ctx, store, db.Now()); err != nil {
+			log.Printf("failed to materialize schedules: %v\n", err)
+		} else if n > 0 {
+			log.Printf("materialized %d scheduled records\n", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func RegisterSchedulesService(s *grpc.Server, store db.Store) {
+	pb.RegisterSchedulesServiceServer(s, &schedulesServer{store: store})
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc"
+)
+
+type tagsServer struct {
+	pb.UnimplementedTagsServiceServer
+	store db.TagStore
+}
+
+// ListTags
+func (s *tagsServer) ListTags(ctx context.Context, req *pb.ListTagsRequest) (*pb.ListTagsResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.store.ListTags(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	tags := []*pb.Tag{}
+	for _, c := range counts {
+		tags = append(tags, &pb.Tag{Name: c.Tag, RecordCount: c.Records})
+	}
+	return &pb.ListTagsResponse{Success: true, Tags: tags, Message: "Tags found"}, nil
+}
+
+// RenameTag
+func (s *tagsServer) RenameTag(ctx context.Context, req *pb.RenameTagRequest) (*pb.RenameTagResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.store.RenameTag(withActor(ctx, userId), userId, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.RenameTagResponse{Success: true, RecordsUpdated: changed, Message: "Tag renamed"}, nil
+}
+
+func RegisterTagsService(s *grpc.Server, store db.TagStore) {
+	pb.RegisterTagsServiceServer(s, &tagsServer{store: store})
+}
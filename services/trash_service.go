@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+)
+
+// ListTrash
+func (s *recordsServer) ListTrash(ctx context.Context, req *pb.ListTrashRequest) (*pb.ListTrashResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	query := db.RecordQuery{
+		UserId:   userId,
+		Sort:     db.TrashSort,
+		PageSize: int(req.PageSize),
+	}
+	if query.After, err = db.DecodePageToken(query, req.PageToken); err != nil {
+		return nil, err
+	}
+	page, err := s.store.ListTrash(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	pbRecords := []*pb.Record{}
+	for _, record := range page.Records {
+		pbRecords = append(pbRecords, pbRecordFromRecord(record))
+	}
+	return &pb.ListTrashResponse{
+		Success:       true,
+		Records:       pbRecords,
+		NextPageToken: db.EncodePageToken(query, page.Next),
+		Message:       "Records found",
+	}, nil
+}
+
+// RestoreRecord
+func (s *recordsServer) RestoreRecord(ctx context.Context, req *pb.RestoreRecordRequest) (*pb.UpdateRecordResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = withActor(ctx, userId)
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	record, err := s.store.RestoreRecord(ctx, userId, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateRecordResponse{
+		Success: true,
+		Record:  pbRecordFromRecord(record),
+		Message: "Record restored",
+	}, nil
+}
+
+// PurgeRecord
+func (s *recordsServer) PurgeRecord(ctx context.Context, req *pb.PurgeRecordRequest) (*pb.DeleteRecordResponse, error) {
+	userId, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := parseId("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.PurgeRecord(ctx, userId, id); err != nil {
+		return nil, err
+	}
+	return &pb.DeleteRecordResponse{Success: true, Message: "Record purged"}, nil
+}
+
+// RunTrashPurger removes the records deleted longer than retention ago
+// every interval until ctx is done.
+func RunTrashPurger(ctx context.Context, store db.TrashStore, retention, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		if n, err := store.PurgeTrash(ctx, db.Now().Add(-retention)); err != nil {
+			log.Printf("failed to purge the trash: %v\n", err)
+		} else if n > 0 {
+			log.Printf("purged %d records from the trash\n", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
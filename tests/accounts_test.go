@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func createAccount(t *testing.T, accountsService pb.AccountsServiceClient, payload *pb.CreateAccountRequest) *pb.Account {
+	t.Helper()
+	result, err := accountsService.CreateAccount(context.TODO(), payload)
+	if err != nil {
+		t.Fatalf("unable to create account\npayload: %v\n%v\n", payload, err)
+	}
+	return result.Account
+}
+
+func balanceUnits(balances []*pb.AccountBalance) map[string]int64 {
+	units := map[string]int64{}
+	for _, b := range balances {
+		units[b.AccountId] = b.Balance.Units
+	}
+	return units
+}
+
+// Tests creating, updating and deleting accounts
+func TestAccounts(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		accountsService := pb.NewAccountsServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		wallet := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: " Wallet ", Kind: pb.AccountKind_CASH, CurrencyCode: "EUR", OpeningBalance: 5000})
+		if wallet.Name != "Wallet" || wallet.Kind != pb.AccountKind_CASH || wallet.OpeningBalance != 5000 {
+			t.Errorf("unexpected account %v", wallet)
+		}
+		createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "USD"})
+
+		invalid := []struct {
+			payload *pb.CreateAccountRequest
+			field   string
+		}{
+			{&pb.CreateAccountRequest{Name: " ", CurrencyCode: "EUR"}, "name"},
+			{&pb.CreateAccountRequest{Name: "Card", CurrencyCode: "euro"}, "currency_code"},
+		}
+		for _, c := range invalid {
+			_, err := accountsService.CreateAccount(context.TODO(), c.payload)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s creating %v, got %v", c.field, c.payload, err)
+			}
+		}
+
+		list, err := accountsService.ListAccounts(context.TODO(), &pb.ListAccountsRequest{})
+		if err != nil {
+			t.Fatalf("unable to list accounts\n%v\n", err)
+		}
+		if len(list.Accounts) != 2 || list.Accounts[0].Name != "Checking" {
+			t.Errorf("expected the accounts ordered by name, got %v", list.Accounts)
+		}
+
+		_, err = journalsService.Create(context.TODO(), &pb.CreateRecordRequest{
+			Type: pb.RecordType_EXPENSE, Title: "Coffee", Amount: &pb.Money{Units: 300, CurrencyCode: "USD"}, Date: day(1), AccountId: wallet.Id,
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"account_id"}) {
+			t.Errorf("expected InvalidArgument on account_id for a currency mismatch, got %v", err)
+		}
+		result, err := journalsService.Create(context.TODO(), &pb.CreateRecordRequest{
+			Type: pb.RecordType_EXPENSE, Title: "Coffee", Amount: &pb.Money{Units: 300, CurrencyCode: "EUR"}, Date: day(1), AccountId: wallet.Id,
+		})
+		if err != nil {
+			t.Fatalf("unable to create record\n%v\n", err)
+		}
+		if result.Record.AccountId != wallet.Id {
+			t.Errorf("expected the record on %s, got %v", wallet.Id, result.Record)
+		}
+
+		_, err = accountsService.UpdateAccount(context.TODO(), &pb.UpdateAccountRequest{
+			Account:    &pb.Account{Id: wallet.Id, CurrencyCode: "USD"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"currency_code"}},
+		})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition changing the currency of an account with records, got %v", err)
+		}
+		renamed, err := accountsService.UpdateAccount(context.TODO(), &pb.UpdateAccountRequest{
+			Account:    &pb.Account{Id: wallet.Id, Name: "Pocket"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to rename account\n%v\n", err)
+		}
+		if renamed.Account.Name != "Pocket" || renamed.Account.CurrencyCode != "EUR" || renamed.Account.OpeningBalance != 5000 {
+			t.Errorf("unexpected renamed account %v", renamed.Account)
+		}
+
+		_, err = accountsService.DeleteAccount(context.TODO(), &pb.DeleteAccountRequest{Id: wallet.Id})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition deleting an account with records, got %v", err)
+		}
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: result.Record.Id}); err != nil {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		if _, err := accountsService.DeleteAccount(context.TODO(), &pb.DeleteAccountRequest{Id: wallet.Id}); err != nil {
+			t.Fatalf("unable to delete account\n%v\n", err)
+		}
+		_, err = accountsService.GetAccount(context.TODO(), &pb.GetAccountRequest{Id: wallet.Id})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound for a deleted account, got %v", err)
+		}
+	})
+}
+
+// Tests current and historical balances of accounts
+func TestAccountBalances(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		accountsService := pb.NewAccountsServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		wallet := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Wallet", Kind: pb.AccountKind_CASH, CurrencyCode: "EUR", OpeningBalance: 5000})
+		savings := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Savings", Kind: pb.AccountKind_SAVINGS, CurrencyCode: "EUR"})
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_INCOME, Title: "Salary", Amount: &pb.Money{Units: 100000, CurrencyCode: "EUR"}, Date: day(1), AccountId: savings.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Rent", Amount: &pb.Money{Units: 60000, CurrencyCode: "EUR"}, Date: day(3), AccountId: savings.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Lunch", Amount: &pb.Money{Units: 1200, CurrencyCode: "EUR"}, Date: day(2), AccountId: wallet.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Unbooked", Amount: &pb.Money{Units: 999, CurrencyCode: "EUR"}, Date: day(2)},
+		)
+		other := pb.NewAccountsServiceClient(conn.As(t, OTHER_USER_ID))
+		createAccount(t, other, &pb.CreateAccountRequest{Name: "Theirs", CurrencyCode: "EUR"})
+
+		current, err := accountsService.GetBalances(context.TODO(), &pb.GetBalancesRequest{})
+		if err != nil {
+			t.Fatalf("unable to get balances\n%v\n", err)
+		}
+		if got := balanceUnits(current.Balances); len(got) != 2 || got[wallet.Id] != 3800 || got[savings.Id] != 40000 {
+			t.Errorf("unexpected current balances %v", got)
+		}
+
+		past, err := accountsService.GetBalances(context.TODO(), &pb.GetBalancesRequest{At: day(3), AccountIds: []string{savings.Id}})
+		if err != nil {
+			t.Fatalf("unable to get balances\n%v\n", err)
+		}
+		if len(past.Balances) != 1 || past.Balances[0].Balance.Units != 100000 || past.Balances[0].Expense.Units != 0 {
+			t.Errorf("expected the savings balance before the rent, got %v", past.Balances)
+		}
+
+		_, err = accountsService.GetBalances(context.TODO(), &pb.GetBalancesRequest{AccountIds: []string{USER_ID}})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound for an unknown account, got %v", err)
+		}
+		_, err = pb.NewRecordsServiceClient(conn.As(t, OTHER_USER_ID)).Create(context.TODO(), &pb.CreateRecordRequest{
+			Type: pb.RecordType_EXPENSE, Title: "Borrowed", Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, Date: day(1), AccountId: wallet.Id,
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"account_id"}) {
+			t.Errorf("expected InvalidArgument on account_id for another user's account, got %v", err)
+		}
+
+		records, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{AccountId: savings.Id}})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if got := titles(records.Records); !equalTitles(got, []string{"Rent", "Salary"}) {
+			t.Errorf("expected the savings records, got %v", got)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/auth"
+	"github.com/fine-track/journals-app/pb"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const OTHER_USER_ID = "64d1b7e92b3de19c6a478937"
+
+// Tests that calls need a token and only see the caller's records
+func TestAuthentication(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		anonymous := pb.NewRecordsServiceClient(conn.Anonymous(t))
+		if _, err := anonymous.GetRecords(context.TODO(), &pb.GetRecordsRequest{}); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("expected Unauthenticated without a token, got %v", err)
+		}
+		if _, err := anonymous.Ping(context.TODO(), &pb.PingRequest{Message: "hi"}); err != nil {
+			t.Errorf("expected ping to work without a token, got %v", err)
+		}
+
+		createRecord(t, pb.NewRecordsServiceClient(conn), pb.RecordType_EXPENSE, "Mine")
+		other := pb.NewRecordsServiceClient(conn.As(t, OTHER_USER_ID))
+		result, err := other.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{}})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if len(result.Records) != 0 {
+			t.Errorf("expected no records for another user, got %v", result.Records)
+		}
+	})
+}
+
+func TestVerifier(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	// the RSA key is served through a key set, the EC key as a PEM file
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "rsa-1", "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+	}}})
+	os.WriteFile(filepath.Join(dir, "jwks.json"), jwks, 0o600)
+	der, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	os.WriteFile(filepath.Join(dir, "ec.pem"), pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600)
+
+	verifier, err := auth.NewVerifier(auth.Config{
+		HMACSecret:    testSecret,
+		PublicKeyFile: filepath.Join(dir, "ec.pem"),
+		JWKSFile:      filepath.Join(dir, "jwks.json"),
+		Issuer:        "finetrack",
+	})
+	if err != nil {
+		t.Fatalf("unable to create verifier\n%v\n", err)
+	}
+
+	claims := func(sub string, exp time.Duration) jwt.RegisteredClaims {
+		return jwt.RegisteredClaims{Subject: sub, Issuer: "finetrack", ExpiresAt: jwt.NewNumericDate(time.Now().Add(exp))}
+	}
+	sign := func(method jwt.SigningMethod, kid string, c jwt.Claims, key any) string {
+		token := jwt.NewWithClaims(method, c)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("unable to sign token\n%v\n", err)
+		}
+		return s
+	}
+
+	valid := map[string]string{
+		"hmac":  sign(jwt.SigningMethodHS256, "", claims(USER_ID, time.Hour), testSecret),
+		"rsa":   sign(jwt.SigningMethodRS256, "rsa-1", claims(USER_ID, time.Hour), rsaKey),
+		"ecdsa": sign(jwt.SigningMethodES256, "", claims(USER_ID, time.Hour), ecKey),
+	}
+	for name, token := range valid {
+		userId, err := verifier.Verify(token)
+		if err != nil || userId.Hex() != USER_ID {
+			t.Errorf("%s: expected user %s, got %s (%v)", name, USER_ID, userId.Hex(), err)
+		}
+	}
+
+	otherRSA, _ := rsa.GenerateKey(rand.Reader, 2048)
+	invalid := map[string]string{
+		"expired":       sign(jwt.SigningMethodHS256, "", claims(USER_ID, -time.Hour), testSecret),
+		"no expiry":     sign(jwt.SigningMethodHS256, "", jwt.RegisteredClaims{Subject: USER_ID, Issuer: "finetrack"}, testSecret),
+		"wrong secret":  sign(jwt.SigningMethodHS256, "", claims(USER_ID, time.Hour), []byte("guess")),
+		"wrong issuer":  sign(jwt.SigningMethodHS256, "", jwt.RegisteredClaims{Subject: USER_ID, Issuer: "evil", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}, testSecret),
+		"unknown kid":   sign(jwt.SigningMethodRS256, "rsa-2", claims(USER_ID, time.Hour), rsaKey),
+		"wrong key":     sign(jwt.SigningMethodRS256, "rsa-1", claims(USER_ID, time.Hour), otherRSA),
+		"kid for hmac":  sign(jwt.SigningMethodHS256, "rsa-1", claims(USER_ID, time.Hour), testSecret),
+		"bad subject":   sign(jwt.SigningMethodHS256, "", claims("someone", time.Hour), testSecret),
+		"not a jwt":     "abc.def.ghi",
+		"unsigned none": sign(jwt.SigningMethodNone, "", claims(USER_ID, time.Hour), jwt.UnsafeAllowNoneSignatureType),
+	}
+	for name, token := range invalid {
+		if _, err := verifier.Verify(token); err == nil {
+			t.Errorf("%s: expected the token to be rejected", name)
+		}
+	}
+}
+
+// Tests that records of other users cannot be read, changed or deleted
+func TestOwnership(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Mine")
+		other := pb.NewRecordsServiceClient(conn.As(t, OTHER_USER_ID))
+
+		hijacked := proto.Clone(record).(*pb.Record)
+		hijacked.Title = "Hijacked"
+		hijacked.UserId = OTHER_USER_ID
+		if _, err := other.Update(context.TODO(), &pb.UpdateRecordRequest{Record: hijacked}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound updating another user's record, got %v", err)
+		}
+		if _, err := other.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound deleting another user's record, got %v", err)
+		}
+
+		// the owner cannot hand the record over either
+		result, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{Record: hijacked})
+		if err != nil {
+			t.Fatalf("unable to update record\n%v\n", err)
+		}
+		if result.Record.UserId != USER_ID {
+			t.Errorf("record owner changed to %s", result.Record.UserId)
+		}
+
+		deleted, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id})
+		if err != nil || !deleted.Success {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound deleting a record twice, got %v", err)
+		}
+	})
+}
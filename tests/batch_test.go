@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// batchCodes lists the code of every result of a batch.
+func batchCodes(res *pb.BatchRecordsResponse) []codes.Code {
+	list := []codes.Code{}
+	for _, r := range res.Results {
+		list = append(list, codes.Code(r.Code))
+	}
+	return list
+}
+
+func equalCodes(a, b []codes.Code) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Tests creating records in a batch, as a whole and on a best effort
+func TestBatchCreateRecords(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		valid := func(title string) *pb.CreateRecordRequest {
+			return &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: title, Amount: &pb.Money{Units: 500, CurrencyCode: "EUR"}, Date: day(1)}
+		}
+		invalid := &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Free", Amount: &pb.Money{Units: -5, CurrencyCode: "EUR"}, Date: day(1)}
+
+		_, err := journalsService.BatchCreateRecords(context.TODO(), &pb.BatchCreateRecordsRequest{
+			Records: []*pb.CreateRecordRequest{valid("Lunch"), invalid},
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"records[1].amount.units"}) {
+			t.Errorf("expected InvalidArgument on records[1].amount.units, got %v", err)
+		}
+		if list := allRecords(t, journalsService); len(list.Records) != 0 {
+			t.Errorf("expected nothing created by a failed batch, got %v", titles(list.Records))
+		}
+
+		res, err := journalsService.BatchCreateRecords(context.TODO(), &pb.BatchCreateRecordsRequest{
+			Records: []*pb.CreateRecordRequest{valid("Lunch"), valid("Dinner")},
+		})
+		if err != nil {
+			t.Fatalf("unable to create the batch\n%v\n", err)
+		}
+		if !res.Success || res.Written != 2 || len(res.Results) != 2 || res.Results[1].Record.GetTitle() != "Dinner" || res.Results[1].Record.GetVersion() != 1 {
+			t.Errorf("unexpected batch result %v", res)
+		}
+
+		res, err = journalsService.BatchCreateRecords(context.TODO(), &pb.BatchCreateRecordsRequest{
+			Records:    []*pb.CreateRecordRequest{valid("Coffee"), invalid, {Title: "Bad category", CategoryId: "nope"}},
+			BestEffort: true,
+		})
+		if err != nil {
+			t.Fatalf("unable to create the batch on a best effort\n%v\n", err)
+		}
+		if res.Success || res.Written != 1 || !equalCodes(batchCodes(res), []codes.Code{codes.OK, codes.InvalidArgument, codes.InvalidArgument}) {
+			t.Errorf("unexpected best effort result %v", res)
+		}
+		if v := res.Results[1].Violations; len(v) != 1 || v[0].Field != "amount.units" {
+			t.Errorf("expected the violation of the invalid record, got %v", v)
+		}
+		if list := allRecords(t, journalsService); !equalTitles(titles(list.Records), []string{"Lunch", "Dinner", "Coffee"}) {
+			t.Errorf("unexpected records after the batches %v", titles(list.Records))
+		}
+
+		tooMany := make([]*pb.CreateRecordRequest, 101)
+		for i := range tooMany {
+			tooMany[i] = valid("Snack")
+		}
+		for _, records := range [][]*pb.CreateRecordRequest{nil, tooMany} {
+			_, err = journalsService.BatchCreateRecords(context.TODO(), &pb.BatchCreateRecordsRequest{Records: records, BestEffort: true})
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"records"}) {
+				t.Errorf("expected InvalidArgument on records for a batch of %d, got %v", len(records), err)
+			}
+		}
+	})
+}
+
+// Tests updating and deleting records in batches, transfers included
+func TestBatchUpdateAndDeleteRecords(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		accountsService := pb.NewAccountsServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		checking := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "EUR"})
+		savings := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Savings", Kind: pb.AccountKind_SAVINGS, CurrencyCode: "EUR"})
+		rent := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Rent")
+		food := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Food")
+		transfer, err := journalsService.CreateTransfer(context.TODO(), &pb.CreateTransferRequest{
+			FromAccountId: checking.Id, ToAccountId: savings.Id, Amount: &pb.Money{Units: 3000, CurrencyCode: "EUR"}, Date: day(2), Title: "Save",
+		})
+		if err != nil {
+			t.Fatalf("unable to create transfer\n%v\n", err)
+		}
+		retitle := func(id string, version int64, title string) *pb.UpdateRecordRequest {
+			return &pb.UpdateRecordRequest{
+				Record:     &pb.Record{Id: id, Version: version, Title: title},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+			}
+		}
+
+		// a stale version fails the whole batch
+		_, err = journalsService.BatchUpdateRecords(context.TODO(), &pb.BatchUpdateRecordsRequest{
+			Records: []*pb.UpdateRecordRequest{retitle(rent.Id, rent.Version, "Housing"), retitle(food.Id, food.Version+1, "Groceries")},
+		})
+		if status.Code(err) != codes.Aborted {
+			t.Errorf("expected Aborted for a stale version, got %v", err)
+		}
+		_, err = journalsService.BatchUpdateRecords(context.TODO(), &pb.BatchUpdateRecordsRequest{
+			Records: []*pb.UpdateRecordRequest{retitle(transfer.Debit.Id, 0, "Saving"), retitle(transfer.Credit.Id, 0, "Saved")},
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"records[1].id"}) {
+			t.Errorf("expected InvalidArgument on records[1].id updating both sides of a transfer, got %v", err)
+		}
+		if got, _ := journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: rent.Id}); got.GetRecord().GetTitle() != "Rent" {
+			t.Errorf("expected nothing updated by a failed batch, got %v", got)
+		}
+
+		res, err := journalsService.BatchUpdateRecords(context.TODO(), &pb.BatchUpdateRecordsRequest{
+			Records: []*pb.UpdateRecordRequest{retitle(rent.Id, rent.Version, "Housing"), retitle(transfer.Debit.Id, 0, "Saving")},
+		})
+		if err != nil {
+			t.Fatalf("unable to update the batch\n%v\n", err)
+		}
+		if !res.Success || res.Results[0].Record.GetTitle() != "Housing" || res.Results[0].Record.GetVersion() != rent.Version+1 || res.Results[1].Record.GetTitle() != "Saving" {
+			t.Errorf("unexpected batch result %v", res)
+		}
+		if got, _ := journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: transfer.Credit.Id}); got.GetRecord().GetTitle() != "Saving" {
+			t.Errorf("expected the title on the other side of the transfer, got %v", got)
+		}
+
+		res, err = journalsService.BatchUpdateRecords(context.TODO(), &pb.BatchUpdateRecordsRequest{
+			Records:    []*pb.UpdateRecordRequest{retitle(food.Id, food.Version+1, "Groceries"), retitle(food.Id, 0, "Groceries"), retitle(USER_ID, 0, "Nothing")},
+			BestEffort: true,
+		})
+		if err != nil {
+			t.Fatalf("unable to update the batch on a best effort\n%v\n", err)
+		}
+		if res.Success || res.Written != 1 || !equalCodes(batchCodes(res), []codes.Code{codes.Aborted, codes.OK, codes.NotFound}) {
+			t.Errorf("unexpected best effort result %v", res)
+		}
+
+		_, err = journalsService.BatchDeleteRecords(context.TODO(), &pb.BatchDeleteRecordsRequest{
+			Records: []*pb.DeleteRecordRequest{{Id: rent.Id}, {Id: USER_ID}},
+		})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound deleting a missing record, got %v", err)
+		}
+		if list := allRecords(t, journalsService); len(list.Records) != 4 {
+			t.Errorf("expected nothing deleted by a failed batch, got %v", titles(list.Records))
+		}
+		res, err = journalsService.BatchDeleteRecords(context.TODO(), &pb.BatchDeleteRecordsRequest{
+			Records: []*pb.DeleteRecordRequest{{Id: rent.Id}, {Id: transfer.Credit.Id}},
+		})
+		if err != nil || !res.Success || res.Written != 2 {
+			t.Fatalf("unable to delete the batch\n%v %v\n", res, err)
+		}
+		res, err = journalsService.BatchDeleteRecords(context.TODO(), &pb.BatchDeleteRecordsRequest{
+			Records:    []*pb.DeleteRecordRequest{{Id: rent.Id}, {Id: food.Id, Version: 1}, {Id: food.Id}, {Id: "nope"}},
+			BestEffort: true,
+		})
+		if err != nil {
+			t.Fatalf("unable to delete the batch on a best effort\n%v\n", err)
+		}
+		if res.Written != 1 || !equalCodes(batchCodes(res), []codes.Code{codes.NotFound, codes.Aborted, codes.OK, codes.InvalidArgument}) {
+			t.Errorf("unexpected best effort result %v", res)
+		}
+		if list := allRecords(t, journalsService); len(list.Records) != 0 {
+			t.Errorf("expected every record deleted, got %v", titles(list.Records))
+		}
+	})
+}
+
+// cancelingStore cancels the call of the server once it created after records.
+type cancelingStore struct {
+	db.Store
+	cancel  context.CancelFunc
+	after   int
+	created int
+}
+
+func (s *cancelingStore) Create(ctx context.Context, r *db.Record) error {
+	err := s.Store.Create(ctx, r)
+	if s.created++; s.created == s.after {
+		s.cancel()
+	}
+	return err
+}
+
+// Tests a canceled best effort batch reports the records written before
+func TestCanceledBestEffortBatch(t *testing.T) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			store := &cancelingStore{Store: s.open(t), after: 2}
+			conn := startServer(t, store, func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				ctx, store.cancel = context.WithCancel(ctx)
+				defer store.cancel()
+				return handler(ctx, req)
+			})
+			journalsService := pb.NewRecordsServiceClient(conn)
+			records := []*pb.CreateRecordRequest{}
+			for _, title := range []string{"Lunch", "Dinner", "Snack"} {
+				records = append(records, &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: title, Amount: &pb.Money{Units: 1200, CurrencyCode: "EUR"}, Date: day(1)})
+			}
+			res, err := journalsService.BatchCreateRecords(context.TODO(), &pb.BatchCreateRecordsRequest{Records: records, BestEffort: true})
+			if err != nil {
+				t.Fatalf("unable to create the batch on a best effort\n%v\n", err)
+			}
+			if res.Success || res.Written != 2 || !equalCodes(batchCodes(res), []codes.Code{codes.OK, codes.OK, codes.Canceled}) {
+				t.Errorf("expected the records written before the cancellation reported, got %v", res)
+			}
+			if list := allRecords(t, journalsService); !equalTitles(titles(list.Records), []string{"Lunch", "Dinner"}) {
+				t.Errorf("unexpected records after the canceled batch %v", titles(list.Records))
+			}
+		})
+	}
+}
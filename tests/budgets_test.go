@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func createBudget(t *testing.T, budgetsService pb.BudgetsServiceClient, budget *pb.Budget) *pb.Budget {
+	t.Helper()
+	res, err := budgetsService.CreateBudget(context.TODO(), &pb.CreateBudgetRequest{Budget: budget})
+	if err != nil {
+		t.Fatalf("unable to create budget\npayload: %v\n%v\n", budget, err)
+	}
+	return res.Budget
+}
+
+// Tests creating, updating and deleting budgets
+func TestBudgets(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		budgetsService := pb.NewBudgetsServiceClient(conn)
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		tagsService := pb.NewTagsServiceClient(conn)
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food"})
+		salary := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Salary", Type: recordType(pb.RecordType_INCOME)})
+		eur := func(units int64) *pb.Money { return &pb.Money{Units: units, CurrencyCode: "EUR"} }
+
+		groceries := createBudget(t, budgetsService, &pb.Budget{Name: " Groceries ", CategoryId: food.Id, Limit: eur(50000)})
+		if groceries.Name != "Groceries" || groceries.Period != pb.SummaryPeriod_MONTH || groceries.StartDate == nil {
+			t.Errorf("unexpected budget %v", groceries)
+		}
+		trips := createBudget(t, budgetsService, &pb.Budget{Name: "Trips", Tag: " Travel ", Limit: eur(100000), Period: pb.SummaryPeriod_YEAR})
+		if trips.Tag != "travel" {
+			t.Errorf("expected a normalized tag, got %q", trips.Tag)
+		}
+
+		invalid := []struct {
+			budget *pb.Budget
+			field  string
+		}{
+			{&pb.Budget{Name: "Nothing", Limit: eur(100)}, "category_id"},
+			{&pb.Budget{Name: "Both", CategoryId: food.Id, Tag: "food", Limit: eur(100)}, "tag"},
+			{&pb.Budget{Name: "Income", CategoryId: salary.Id, Limit: eur(100)}, "category_id"},
+			{&pb.Budget{Name: "Free", Tag: "food", Limit: eur(0)}, "limit.units"},
+			{&pb.Budget{Name: "", Tag: "food", Limit: eur(100)}, "name"},
+		}
+		for _, c := range invalid {
+			_, err := budgetsService.CreateBudget(context.TODO(), &pb.CreateBudgetRequest{Budget: c.budget})
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s creating %v, got %v", c.field, c.budget, err)
+			}
+		}
+
+		// switching from a tag to a category clears the tag in the same update
+		updated, err := budgetsService.UpdateBudget(context.TODO(), &pb.UpdateBudgetRequest{
+			Budget:     &pb.Budget{Id: trips.Id, CategoryId: food.Id, Rollover: true},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"category_id", "tag", "rollover"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to update budget\n%v\n", err)
+		}
+		if updated.Budget.CategoryId != food.Id || updated.Budget.Tag != "" || !updated.Budget.Rollover || updated.Budget.Limit.Units != 100000 {
+			t.Errorf("unexpected updated budget %v", updated.Budget)
+		}
+		_, err = budgetsService.UpdateBudget(context.TODO(), &pb.UpdateBudgetRequest{
+			Budget:     &pb.Budget{Id: trips.Id, Tag: "travel"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"tag"}},
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"tag"}) {
+			t.Errorf("expected InvalidArgument on tag, got %v", err)
+		}
+
+		// budgets follow renamed tags and go away with their category
+		tagged := createBudget(t, budgetsService, &pb.Budget{Name: "Fun", Tag: "fun", Limit: eur(1000)})
+		if _, err := tagsService.RenameTag(context.TODO(), &pb.RenameTagRequest{From: "fun", To: "leisure"}); err != nil {
+			t.Fatalf("unable to rename tag\n%v\n", err)
+		}
+		res, err := budgetsService.GetBudget(context.TODO(), &pb.GetBudgetRequest{Id: tagged.Id})
+		if err != nil || res.Budget.Tag != "leisure" {
+			t.Errorf("expected the budget on the renamed tag, got %v %v", res, err)
+		}
+		if _, err := categoriesService.DeleteCategory(context.TODO(), &pb.DeleteCategoryRequest{Id: food.Id}); err != nil {
+			t.Fatalf("unable to delete category\n%v\n", err)
+		}
+		list, err := budgetsService.ListBudgets(context.TODO(), &pb.ListBudgetsRequest{})
+		if err != nil || len(list.Budgets) != 1 || list.Budgets[0].Id != tagged.Id {
+			t.Errorf("expected the budgets of the category deleted, got %v %v", list, err)
+		}
+
+		if _, err := budgetsService.DeleteBudget(context.TODO(), &pb.DeleteBudgetRequest{Id: tagged.Id}); err != nil {
+			t.Fatalf("unable to delete budget\n%v\n", err)
+		}
+		if _, err := budgetsService.GetBudget(context.TODO(), &pb.GetBudgetRequest{Id: tagged.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound after delete, got %v", err)
+		}
+	})
+}
+
+// Tests spent, remaining, rollover and projections of budgets
+func TestBudgetStatus(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		budgetsService := pb.NewBudgetsServiceClient(conn)
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food"})
+		restaurants := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Restaurants", ParentId: food.Id})
+		date := func(month time.Month, day int) *timestamppb.Timestamp {
+			return timestamppb.New(time.Date(2023, month, day, 12, 0, 0, 0, time.UTC))
+		}
+		eur := func(units int64) *pb.Money { return &pb.Money{Units: units, CurrencyCode: "EUR"} }
+		expense := func(title string, units int64, month time.Month, day int, categoryId string, tags ...string) *pb.CreateRecordRequest {
+			return &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: title, Amount: eur(units), Date: date(month, day), CategoryId: categoryId, Tags: tags}
+		}
+		seedRecords(t, journalsService,
+			expense("Before the budget", 10000, time.July, 20, food.Id),
+			expense("Groceries", 20000, time.August, 3, food.Id),
+			expense("Dinner", 10000, time.August, 20, restaurants.Id),
+			expense("Groceries", 30000, time.September, 5, food.Id),
+			expense("Train", 10000, time.September, 2, "", "trip"),
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Abroad", Amount: &pb.Money{Units: 99999, CurrencyCode: "USD"}, Date: date(time.September, 6), CategoryId: food.Id},
+		)
+		rollover := createBudget(t, budgetsService, &pb.Budget{Name: "Food", CategoryId: food.Id, Limit: eur(50000), StartDate: date(time.August, 1), Rollover: true})
+		strict := createBudget(t, budgetsService, &pb.Budget{Name: "Food strict", CategoryId: food.Id, Limit: eur(25000), StartDate: date(time.August, 1)})
+		trips := createBudget(t, budgetsService, &pb.Budget{Name: "Trips", Tag: "trip", Limit: eur(100000), StartDate: date(time.August, 1)})
+
+		res, err := budgetsService.GetBudgetStatus(context.TODO(), &pb.GetBudgetStatusRequest{At: timestamppb.New(time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC))})
+		if err != nil {
+			t.Fatalf("unable to get budget status\n%v\n", err)
+		}
+		want := map[string]struct {
+			available, rollover, spent, remaining, projected int64
+			state                                            pb.BudgetState
+		}{
+			// August left 20000 unspent, September is at a third of the month
+			rollover.Id: {70000, 20000, 30000, 40000, 94737, pb.BudgetState_AT_RISK},
+			strict.Id:   {25000, 0, 30000, -5000, 94737, pb.BudgetState_OVER},
+			trips.Id:    {100000, 0, 10000, 90000, 31579, pb.BudgetState_ON_TRACK},
+		}
+		if len(res.Statuses) != len(want) {
+			t.Fatalf("expected %d statuses, got %v", len(want), res.Statuses)
+		}
+		for _, s := range res.Statuses {
+			w := want[s.BudgetId]
+			got := [...]int64{s.Available.Units, s.Rollover.Units, s.Spent.Units, s.Remaining.Units, s.Projected.Units}
+			if got != [...]int64{w.available, w.rollover, w.spent, w.remaining, w.projected} || s.State != w.state {
+				t.Errorf("unexpected status of budget %s: %v", s.BudgetId, s)
+			}
+			if !s.PeriodStart.AsTime().Equal(time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)) || !s.PeriodEnd.AsTime().Equal(time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("unexpected period of budget %s: %v", s.BudgetId, s)
+			}
+		}
+
+		selected, err := budgetsService.GetBudgetStatus(context.TODO(), &pb.GetBudgetStatusRequest{At: date(time.August, 31), BudgetIds: []string{trips.Id}})
+		if err != nil || len(selected.Statuses) != 1 || selected.Statuses[0].Spent.Units != 0 {
+			t.Errorf("expected the status of the trips budget in August, got %v %v", selected, err)
+		}
+		_, err = budgetsService.GetBudgetStatus(context.TODO(), &pb.GetBudgetStatusRequest{TimeZone: "Nowhere/Land"})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument on an unknown time zone, got %v", err)
+		}
+	})
+}
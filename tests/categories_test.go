@@ -0,0 +1,242 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func createCategory(t *testing.T, categoriesService pb.CategoriesServiceClient, payload *pb.CreateCategoryRequest) *pb.Category {
+	t.Helper()
+	result, err := categoriesService.CreateCategory(context.TODO(), payload)
+	if err != nil {
+		t.Fatalf("unable to create category\npayload: %v\n%v\n", payload, err)
+	}
+	return result.Category
+}
+
+func recordType(t pb.RecordType) *pb.RecordType {
+	return &t
+}
+
+func categoryNames(categories []*pb.Category) []string {
+	names := []string{}
+	for _, c := range categories {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// Tests creating, nesting and updating categories
+func TestCategories(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food", Color: "#1e90ff", Type: recordType(pb.RecordType_EXPENSE)})
+		groceries := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: " Groceries ", Icon: "cart", ParentId: food.Id})
+		if groceries.Name != "Groceries" || groceries.ParentId != food.Id {
+			t.Errorf("unexpected subcategory %v", groceries)
+		}
+		if groceries.GetType() != pb.RecordType_EXPENSE {
+			t.Errorf("expected the subcategory to inherit the EXPENSE restriction, got %v", groceries.Type)
+		}
+
+		invalid := []struct {
+			payload *pb.CreateCategoryRequest
+			field   string
+		}{
+			{&pb.CreateCategoryRequest{Name: "  "}, "name"},
+			{&pb.CreateCategoryRequest{Name: "Bad color", Color: "blue"}, "color"},
+			{&pb.CreateCategoryRequest{Name: "Orphan", ParentId: USER_ID}, "parent_id"},
+			{&pb.CreateCategoryRequest{Name: "Bonus", ParentId: food.Id, Type: recordType(pb.RecordType_INCOME)}, "type"},
+		}
+		for _, c := range invalid {
+			_, err := categoriesService.CreateCategory(context.TODO(), c.payload)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s creating %v, got %v", c.field, c.payload, err)
+			}
+		}
+
+		// rename only
+		renamed, err := categoriesService.UpdateCategory(context.TODO(), &pb.UpdateCategoryRequest{
+			Category:   &pb.Category{Id: food.Id, Name: "Food & Drinks"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to rename category\n%v\n", err)
+		}
+		if renamed.Category.Name != "Food & Drinks" || renamed.Category.Color != "#1e90ff" || renamed.Category.GetType() != pb.RecordType_EXPENSE {
+			t.Errorf("unexpected renamed category %v", renamed.Category)
+		}
+
+		_, err = categoriesService.UpdateCategory(context.TODO(), &pb.UpdateCategoryRequest{
+			Category:   &pb.Category{Id: food.Id, ParentId: groceries.Id},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"parent_id"}},
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"parent_id"}) {
+			t.Errorf("expected InvalidArgument on parent_id moving a category below itself, got %v", err)
+		}
+
+		// the subcategory still only accepts expenses
+		_, err = categoriesService.UpdateCategory(context.TODO(), &pb.UpdateCategoryRequest{
+			Category:   &pb.Category{Id: food.Id, Type: recordType(pb.RecordType_INCOME)},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"type"}},
+		})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition restricting a category its subcategories disagree with, got %v", err)
+		}
+
+		list, err := categoriesService.ListCategories(context.TODO(), &pb.ListCategoriesRequest{})
+		if err != nil {
+			t.Fatalf("unable to list categories\n%v\n", err)
+		}
+		if names := categoryNames(list.Categories); !equalTitles(names, []string{"Food & Drinks", "Groceries"}) {
+			t.Errorf("unexpected categories %v", names)
+		}
+
+		other := pb.NewCategoriesServiceClient(conn.As(t, OTHER_USER_ID))
+		if _, err := other.GetCategory(context.TODO(), &pb.GetCategoryRequest{Id: food.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound getting another user's category, got %v", err)
+		}
+		otherList, err := other.ListCategories(context.TODO(), &pb.ListCategoriesRequest{})
+		if err != nil || len(otherList.Categories) != 0 {
+			t.Errorf("expected no categories for another user, got %v %v", otherList, err)
+		}
+	})
+}
+
+// Tests the category of records is validated and filtered on
+func TestRecordCategories(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food", Type: recordType(pb.RecordType_EXPENSE)})
+		groceries := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Groceries", ParentId: food.Id})
+		otherCategory := createCategory(t, pb.NewCategoriesServiceClient(conn.As(t, OTHER_USER_ID)), &pb.CreateCategoryRequest{Name: "Theirs"})
+
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Market", Amount: &pb.Money{Units: 2000, CurrencyCode: "EUR"}, Date: day(1), CategoryId: groceries.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Dinner", Amount: &pb.Money{Units: 5000, CurrencyCode: "EUR"}, Date: day(2), CategoryId: food.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Bus", Amount: &pb.Money{Units: 200, CurrencyCode: "EUR"}, Date: day(3)},
+		)
+
+		for _, c := range []struct {
+			name    string
+			payload *pb.CreateRecordRequest
+		}{
+			{"wrong type", &pb.CreateRecordRequest{Type: pb.RecordType_INCOME, CategoryId: groceries.Id}},
+			{"unknown", &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, CategoryId: USER_ID}},
+			{"other user's", &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, CategoryId: otherCategory.Id}},
+		} {
+			c.payload.Title = c.name
+			c.payload.Amount = &pb.Money{Units: 100, CurrencyCode: "EUR"}
+			c.payload.Date = day(4)
+			_, err := journalsService.Create(context.TODO(), c.payload)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"category_id"}) {
+				t.Errorf("expected InvalidArgument on category_id for a %s category, got %v", c.name, err)
+			}
+		}
+
+		list, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{CategoryId: food.Id}})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if got := titles(list.Records); !equalTitles(got, []string{"Dinner", "Market"}) {
+			t.Errorf("expected the records of the category and its subcategories, got %v", got)
+		}
+		market := list.Records[1]
+
+		// changing only the type is checked against the stored category
+		_, err = journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: market.Id, Type: pb.RecordType_INCOME},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"type"}},
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"category_id"}) {
+			t.Errorf("expected InvalidArgument on category_id changing the type, got %v", err)
+		}
+
+		uncategorized, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: market.Id},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"category_id"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to clear the category\n%v\n", err)
+		}
+		if uncategorized.Record.CategoryId != "" || uncategorized.Record.Title != "Market" {
+			t.Errorf("unexpected record after clearing its category %v", uncategorized.Record)
+		}
+
+		// a category cannot be restricted to a type its records do not have
+		_, err = categoriesService.UpdateCategory(context.TODO(), &pb.UpdateCategoryRequest{
+			Category:   &pb.Category{Id: food.Id, Type: recordType(pb.RecordType_INCOME)},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"type"}},
+		})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition restricting a category with other records, got %v", err)
+		}
+	})
+}
+
+// Tests deleting and merging categories moves their records
+func TestDeleteCategories(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food"})
+		groceries := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Groceries", ParentId: food.Id})
+		market := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Market", ParentId: groceries.Id})
+		eatingOut := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Eating out"})
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Shop", Amount: &pb.Money{Units: 2000, CurrencyCode: "EUR"}, Date: day(1), CategoryId: groceries.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Pizza", Amount: &pb.Money{Units: 1500, CurrencyCode: "EUR"}, Date: day(2), CategoryId: eatingOut.Id},
+		)
+
+		_, err := categoriesService.DeleteCategory(context.TODO(), &pb.DeleteCategoryRequest{Id: food.Id, MoveTo: market.Id})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"move_to"}) {
+			t.Errorf("expected InvalidArgument on move_to moving into a subcategory, got %v", err)
+		}
+
+		// records become uncategorized and subcategories move up
+		if _, err := categoriesService.DeleteCategory(context.TODO(), &pb.DeleteCategoryRequest{Id: groceries.Id}); err != nil {
+			t.Fatalf("unable to delete category\n%v\n", err)
+		}
+		moved, err := categoriesService.GetCategory(context.TODO(), &pb.GetCategoryRequest{Id: market.Id})
+		if err != nil {
+			t.Fatalf("unable to get category\n%v\n", err)
+		}
+		if moved.Category.ParentId != food.Id {
+			t.Errorf("expected the subcategory to move up to %s, got %s", food.Id, moved.Category.ParentId)
+		}
+		list, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Sort: &pb.RecordSort{Ascending: true}})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if list.Records[0].CategoryId != "" {
+			t.Errorf("expected the record to be uncategorized, got %s", list.Records[0].CategoryId)
+		}
+
+		merged, err := categoriesService.MergeCategories(context.TODO(), &pb.MergeCategoriesRequest{SourceId: eatingOut.Id, TargetId: food.Id})
+		if err != nil {
+			t.Fatalf("unable to merge categories\n%v\n", err)
+		}
+		if merged.Category.Id != food.Id {
+			t.Errorf("expected the merge target, got %v", merged.Category)
+		}
+		list, err = journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{CategoryId: food.Id}})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if got := titles(list.Records); !equalTitles(got, []string{"Pizza"}) {
+			t.Errorf("expected the merged records in the target, got %v", got)
+		}
+		if _, err := categoriesService.GetCategory(context.TODO(), &pb.GetCategoryRequest{Id: eatingOut.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound for the merged category, got %v", err)
+		}
+		if _, err := categoriesService.DeleteCategory(context.TODO(), &pb.DeleteCategoryRequest{Id: eatingOut.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound deleting a deleted category, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// violatedFields lists the fields of the BadRequest details of err.
+func violatedFields(err error) []string {
+	fields := []string{}
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.FieldViolations {
+				fields = append(fields, v.Field)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Tests the status codes and details returned for failing calls
+func TestErrorStatuses(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+
+		_, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: "not-an-id"})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"id"}) {
+			t.Errorf("expected InvalidArgument on id for a malformed id, got %v", err)
+		}
+
+		_, err = journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: primitive.NewObjectID().Hex()})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound for a missing record, got %v", err)
+		}
+
+		_, err = journalsService.Create(context.TODO(), &pb.CreateRecordRequest{
+			Type:   pb.RecordType(7),
+			Title:  "Invalid",
+			Amount: &pb.Money{Units: -1, CurrencyCode: "??"},
+		})
+		want := []string{"amount.currency_code", "amount.units", "date", "type"}
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), want) {
+			t.Errorf("expected InvalidArgument on %v, got %v (%v)", want, violatedFields(err), err)
+		}
+
+		_, err = journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{PageToken: "garbage", PageSize: -1})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"page_token"}) {
+			t.Errorf("expected InvalidArgument on page_token, got %v (%v)", violatedFields(err), err)
+		}
+
+		_, err = journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{PageSize: -1})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"page_size"}) {
+			t.Errorf("expected InvalidArgument on page_size, got %v (%v)", violatedFields(err), err)
+		}
+
+		// successful responses are filled in the same way
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Fine")
+		updated, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{Record: record})
+		if err != nil || !updated.Success || updated.Message == "" {
+			t.Errorf("expected a successful update with a message, got %v (%v)", updated, err)
+		}
+		deleted, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id})
+		if err != nil || !deleted.Success || deleted.Message == "" {
+			t.Errorf("expected a successful delete with a message, got %v (%v)", deleted, err)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// exportRecords concatenates the chunks of an export, stopping after
+// maxChunks when positive.
+func exportRecords(journalsService pb.RecordsServiceClient, req *pb.ExportRecordsRequest, maxChunks int) (string, []*pb.ExportRecordsResponse, error) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	stream, err := journalsService.ExportRecords(ctx, req)
+	if err != nil {
+		return "", nil, err
+	}
+	file := strings.Builder{}
+	chunks := []*pb.ExportRecordsResponse{}
+	for maxChunks <= 0 || len(chunks) < maxChunks {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return file.String(), chunks, err
+		}
+		file.Write(chunk.Chunk)
+		chunks = append(chunks, chunk)
+	}
+	return file.String(), chunks, nil
+}
+
+// Tests exporting records as CSV and resuming an interrupted export
+func TestExportRecordsCSV(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		accountsService := pb.NewAccountsServiceClient(conn)
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		wallet := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Wallet", Kind: pb.AccountKind_CASH, CurrencyCode: "EUR"})
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food"})
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Lunch, with \"team\"", Amount: &pb.Money{Units: 1250, CurrencyCode: "EUR"}, Date: day(2),
+				CategoryId: food.Id, Tags: []string{"work", "food"}, AccountId: wallet.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_INCOME, Title: "Salary", Amount: &pb.Money{Units: 300000, CurrencyCode: "EUR"}, Date: day(1)},
+		)
+		lines := []string{"date,amount"}
+		for i := 0; i < 230; i++ {
+			lines = append(lines, fmt.Sprintf("2023-09-%02d,-%d.00", i%28+1, i+1))
+		}
+		if _, err := importCSV(journalsService, &pb.ImportMapping{HasHeader: true, DateColumn: 1, AmountColumn: 2}, false, strings.Join(lines, "\n")); err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+
+		file, chunks, err := exportRecords(journalsService, &pb.ExportRecordsRequest{}, 0)
+		if err != nil {
+			t.Fatalf("unable to export\n%v\n", err)
+		}
+		if len(chunks) != 3 || chunks[0].RecordCount != 100 || chunks[2].RecordCount != 32 {
+			t.Errorf("expected the records in chunks of 100, got %d chunks", len(chunks))
+		}
+		rows, err := csv.NewReader(strings.NewReader(file)).ReadAll()
+		if err != nil {
+			t.Fatalf("unable to read the export\n%v\n", err)
+		}
+		want := [][]string{
+			{"date", "type", "title", "description", "signed_amount", "currency_code", "category", "tags", "account"},
+			{"2023-08-01", "INCOME", "Salary", "", "3000.00", "EUR", "", "", ""},
+			{"2023-08-02", "EXPENSE", "Lunch, with \"team\"", "", "-12.50", "EUR", "Food", "food;work", "Wallet"},
+		}
+		if len(rows) != 233 || fmt.Sprint(rows[:3]) != fmt.Sprint(want) {
+			t.Errorf("unexpected export of %d rows, starting with %v", len(rows), rows[:3])
+		}
+
+		partial, _, err := exportRecords(journalsService, &pb.ExportRecordsRequest{}, 1)
+		if err != nil {
+			t.Fatalf("unable to export\n%v\n", err)
+		}
+		rest, _, err := exportRecords(journalsService, &pb.ExportRecordsRequest{ResumeToken: chunks[0].ResumeToken}, 0)
+		if err != nil {
+			t.Fatalf("unable to resume the export\n%v\n", err)
+		}
+		if partial+rest != file {
+			t.Errorf("expected the resumed export to complete the file")
+		}
+
+		columns := &pb.ExportRecordsRequest{
+			Filter:    &pb.RecordFilter{AccountId: wallet.Id},
+			Columns:   []string{"id", "timestamp", "amount", "category_id"},
+			Delimiter: ";",
+			TimeZone:  "Asia/Tokyo",
+		}
+		file, _, err = exportRecords(journalsService, columns, 0)
+		if err != nil {
+			t.Fatalf("unable to export\n%v\n", err)
+		}
+		if !strings.HasSuffix(file, ";2023-08-02T21:00:00+09:00;12.50;"+food.Id+"\n") || strings.Count(file, "\n") != 2 {
+			t.Errorf("unexpected export %q", file)
+		}
+
+		invalid := []struct {
+			req   *pb.ExportRecordsRequest
+			field string
+		}{
+			{&pb.ExportRecordsRequest{Columns: []string{"date", "balance"}}, "columns"},
+			{&pb.ExportRecordsRequest{Delimiter: "ab"}, "delimiter"},
+			{&pb.ExportRecordsRequest{ResumeToken: chunks[0].ResumeToken, Filter: &pb.RecordFilter{CurrencyCode: "EUR"}}, "resume_token"},
+			{&pb.ExportRecordsRequest{Format: pb.ExportFormat_EXPORT_OFX}, "filter.currency_code"},
+			{&pb.ExportRecordsRequest{Format: 9}, "format"},
+		}
+		for _, c := range invalid {
+			_, _, err := exportRecords(journalsService, c.req, 0)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s exporting with %v, got %v", c.field, c.req, err)
+			}
+		}
+	})
+}
+
+// Tests exporting records as NDJSON and as an OFX statement imported back
+func TestExportRecordsNDJSONAndOFX(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		accountsService := pb.NewAccountsServiceClient(conn)
+		checking := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "EUR"})
+		copied := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Copy", Kind: pb.AccountKind_BANK, CurrencyCode: "EUR"})
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Books & <Maps>", Description: "Holiday", Amount: &pb.Money{Units: 2599, CurrencyCode: "EUR"}, Date: day(3), AccountId: checking.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_INCOME, Title: "Refund", Amount: &pb.Money{Units: 500, CurrencyCode: "EUR"}, Date: day(4), AccountId: checking.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Coffee", Amount: &pb.Money{Units: 300, CurrencyCode: "USD"}, Date: day(5)},
+		)
+
+		file, _, err := exportRecords(journalsService, &pb.ExportRecordsRequest{Format: pb.ExportFormat_EXPORT_NDJSON}, 0)
+		if err != nil {
+			t.Fatalf("unable to export\n%v\n", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(file, "\n"), "\n")
+		if len(lines) != 3 {
+			t.Fatalf("expected a line per record, got %q", file)
+		}
+		var first map[string]any
+		if err := json.Unmarshal([]byte(lines[0]), &first); err != nil || first["title"] != "Books & <Maps>" || first["account_id"] != checking.Id {
+			t.Errorf("unexpected line %s\n%v", lines[0], err)
+		}
+
+		ofx, _, err := exportRecords(journalsService, &pb.ExportRecordsRequest{Format: pb.ExportFormat_EXPORT_OFX, Filter: &pb.RecordFilter{AccountId: checking.Id}}, 0)
+		if err != nil {
+			t.Fatalf("unable to export\n%v\n", err)
+		}
+		for i := 0; i < 2; i++ {
+			result, err := importStatement(journalsService, &pb.ImportStatementRequest{Format: pb.StatementFormat_OFX, AccountId: copied.Id}, ofx)
+			if err != nil {
+				t.Fatalf("unable to import the export\n%v\n", err)
+			}
+			if result.Imported+result.Duplicates != 2 || result.ErrorCount != 0 || (i == 0) != (result.Imported == 2) {
+				t.Errorf("unexpected import %d of the export %v", i, result)
+			}
+		}
+		result, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{AccountId: copied.Id}, Sort: &pb.RecordSort{Ascending: true}})
+		if err != nil {
+			t.Fatalf("unable to list records\n%v\n", err)
+		}
+		if !equalTitles(titles(result.Records), []string{"Books & <Maps>", "Refund"}) || result.Records[0].Amount.Units != 2599 ||
+			result.Records[0].Description != "Holiday" || result.Records[1].Type != pb.RecordType_INCOME {
+			t.Errorf("unexpected records %v", result.Records)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func day(d int) *timestamppb.Timestamp {
+	return timestamppb.New(time.Date(2023, 8, d, 12, 0, 0, 0, time.UTC))
+}
+
+func seedRecords(t *testing.T, journalsService pb.RecordsServiceClient, payloads ...*pb.CreateRecordRequest) {
+	t.Helper()
+	for _, payload := range payloads {
+		if _, err := journalsService.Create(context.TODO(), payload); err != nil {
+			t.Fatalf("unable to create record\npayload: %v\n%v\n", payload, err)
+		}
+	}
+}
+
+func titles(records []*pb.Record) []string {
+	list := []string{}
+	for _, r := range records {
+		list = append(list, r.Title)
+	}
+	return list
+}
+
+func equalTitles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Tests filtering and sorting records
+func TestGetRecordsFilters(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Groceries", Description: "weekly shop", Amount: &pb.Money{Units: 4500, CurrencyCode: "EUR"}, Date: day(3)},
+			&pb.CreateRecordRequest{Type: pb.RecordType_INCOME, Title: "Salary", Amount: &pb.Money{Units: 300000, CurrencyCode: "EUR"}, Date: day(1)},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Rent", Amount: &pb.Money{Units: 90000, CurrencyCode: "EUR"}, Date: day(2)},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Coffee 100%", Description: "GROCERIES run", Amount: &pb.Money{Units: 350, CurrencyCode: "USD"}, Date: day(5)},
+		)
+
+		cases := []struct {
+			name   string
+			filter *pb.RecordFilter
+			sort   *pb.RecordSort
+			want   []string
+		}{
+			{"all newest first", &pb.RecordFilter{}, nil, []string{"Coffee 100%", "Groceries", "Rent", "Salary"}},
+			{"type", &pb.RecordFilter{Type: pb.RecordType_INCOME.Enum()}, nil, []string{"Salary"}},
+			{"date range", &pb.RecordFilter{FromDate: day(2), ToDate: day(5)}, nil, []string{"Groceries", "Rent"}},
+			{"amount range", &pb.RecordFilter{MinAmount: proto.Int64(350), MaxAmount: proto.Int64(4500)}, nil, []string{"Coffee 100%", "Groceries"}},
+			{"currency", &pb.RecordFilter{CurrencyCode: "USD"}, nil, []string{"Coffee 100%"}},
+			{"text", &pb.RecordFilter{Text: "groceries"}, nil, []string{"Coffee 100%", "Groceries"}},
+			{"text escaping", &pb.RecordFilter{Text: "0%"}, nil, []string{"Coffee 100%"}},
+			{"amount ascending", &pb.RecordFilter{Type: pb.RecordType_EXPENSE.Enum()}, &pb.RecordSort{Field: pb.RecordSortField_SORT_BY_AMOUNT, Ascending: true}, []string{"Coffee 100%", "Groceries", "Rent"}},
+			{"created descending", &pb.RecordFilter{}, &pb.RecordSort{Field: pb.RecordSortField_SORT_BY_CREATED_AT}, []string{"Coffee 100%", "Rent", "Salary", "Groceries"}},
+		}
+		for _, c := range cases {
+			result, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: c.filter, Sort: c.sort})
+			if err != nil {
+				t.Errorf("%s: unable to get records\n%v\n", c.name, err)
+				continue
+			}
+			if got := titles(result.Records); !equalTitles(got, c.want) {
+				t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+			}
+		}
+
+		for _, invalid := range []*pb.RecordFilter{
+			{FromDate: day(5), ToDate: day(2)},
+			{MinAmount: proto.Int64(10), MaxAmount: proto.Int64(5)},
+			{CurrencyCode: "EURO"},
+		} {
+			if _, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: invalid}); err == nil {
+				t.Errorf("expected an error for filter %v", invalid)
+			}
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func revisionActions(revisions []*pb.Revision) []string {
+	actions := []string{}
+	for _, rev := range revisions {
+		actions = append(actions, rev.Action.String())
+	}
+	return actions
+}
+
+func changeLines(changes []*pb.FieldChange) []string {
+	lines := []string{}
+	for _, c := range changes {
+		lines = append(lines, fmt.Sprintf("%s: %q -> %q", c.Field, c.OldValue, c.NewValue))
+	}
+	return lines
+}
+
+// Tests every write of a record appends a revision to its history
+func TestRecordHistory(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Lunch")
+		_, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: record.Id, Title: "Dinner", Amount: &pb.Money{Units: 2500, CurrencyCode: "EUR"}, Tags: []string{"Food"}},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title", "amount", "tags"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to update record\n%v\n", err)
+		}
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id}); err != nil {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		if _, err := journalsService.RestoreRecord(context.TODO(), &pb.RestoreRecordRequest{Id: record.Id}); err != nil {
+			t.Fatalf("unable to restore record\n%v\n", err)
+		}
+
+		res, err := journalsService.GetRecordHistory(context.TODO(), &pb.GetRecordHistoryRequest{Id: record.Id})
+		if err != nil {
+			t.Fatalf("unable to get history\n%v\n", err)
+		}
+		revisions := res.Revisions
+		if !equalTitles(revisionActions(revisions), []string{"REVISION_CREATED", "REVISION_UPDATED", "REVISION_DELETED", "REVISION_RESTORED"}) {
+			t.Fatalf("unexpected revisions %v", revisionActions(revisions))
+		}
+		created, updated, deleted := revisions[0], revisions[1], revisions[2]
+		if created.ActorId != USER_ID || created.UserAgent == "" || created.Address == "" || created.Record.Title != "Lunch" || len(created.Changes) == 0 {
+			t.Errorf("unexpected created revision %v", created)
+		}
+		expected := []string{`title: "Lunch" -> "Dinner"`, `amount: "11.00 EUR" -> "25.00 EUR"`, `tags: "" -> "food"`}
+		if !equalTitles(changeLines(updated.Changes), expected) {
+			t.Errorf("expected the changed fields %v, got %v", expected, changeLines(updated.Changes))
+		}
+		if updated.Record.Title != "Dinner" || updated.Record.Version != 2 {
+			t.Errorf("expected the record as updated, got %v", updated.Record)
+		}
+		if len(deleted.Changes) != 0 || deleted.Record.Title != "Dinner" || deleted.Record.DeletedAt == nil {
+			t.Errorf("expected the record as deleted, got %v", deleted)
+		}
+
+		other := pb.NewRecordsServiceClient(conn.As(t, "64d1b7e92b3de19c6a478937"))
+		if _, err := other.GetRecordHistory(context.TODO(), &pb.GetRecordHistoryRequest{Id: record.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound for the history of another user's record, got %v", err)
+		}
+
+		// a failed batch leaves no revision
+		_, err = journalsService.BatchUpdateRecords(context.TODO(), &pb.BatchUpdateRecordsRequest{
+			Records: []*pb.UpdateRecordRequest{
+				{Record: &pb.Record{Id: record.Id, Title: "Supper"}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}}},
+				{Record: &pb.Record{Id: record.Id, Title: "Brunch", Version: 1}, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}}},
+			},
+		})
+		if err == nil {
+			t.Fatalf("expected the batch to fail")
+		}
+		if res, err := journalsService.GetRecordHistory(context.TODO(), &pb.GetRecordHistoryRequest{Id: record.Id}); err != nil || len(res.Revisions) != 4 {
+			t.Errorf("expected no revision of the failed batch, got %v %v", res, err)
+		}
+	})
+}
+
+// Tests reverting a record to a previous revision
+func TestRevertRecord(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Lunch")
+		updated, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: record.Id, Title: "Dinner", Amount: &pb.Money{Units: 2500, CurrencyCode: "EUR"}},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title", "amount"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to update record\n%v\n", err)
+		}
+		history, err := journalsService.GetRecordHistory(context.TODO(), &pb.GetRecordHistoryRequest{Id: record.Id})
+		if err != nil {
+			t.Fatalf("unable to get history\n%v\n", err)
+		}
+		first := history.Revisions[0]
+
+		_, err = journalsService.RevertRecord(context.TODO(), &pb.RevertRecordRequest{Id: record.Id, RevisionId: first.Id, Version: record.Version})
+		if status.Code(err) != codes.Aborted {
+			t.Errorf("expected Aborted reverting a stale version, got %v", err)
+		}
+		_, err = journalsService.RevertRecord(context.TODO(), &pb.RevertRecordRequest{Id: record.Id, RevisionId: record.Id})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound reverting to an unknown revision, got %v", err)
+		}
+
+		reverted, err := journalsService.RevertRecord(context.TODO(), &pb.RevertRecordRequest{Id: record.Id, RevisionId: first.Id, Version: updated.Record.Version})
+		if err != nil {
+			t.Fatalf("unable to revert record\n%v\n", err)
+		}
+		if reverted.Record.Title != "Lunch" || reverted.Record.Amount.Units != 1100 || reverted.Record.Version != 3 {
+			t.Errorf("expected the record back to its first revision, got %v", reverted.Record)
+		}
+		history, err = journalsService.GetRecordHistory(context.TODO(), &pb.GetRecordHistoryRequest{Id: record.Id})
+		if err != nil {
+			t.Fatalf("unable to get history\n%v\n", err)
+		}
+		last := history.Revisions[len(history.Revisions)-1]
+		expected := []string{`title: "Dinner" -> "Lunch"`, `amount: "25.00 EUR" -> "11.00 EUR"`}
+		if len(history.Revisions) != 3 || !equalTitles(changeLines(last.Changes), expected) {
+			t.Errorf("expected the revert in the history, got %v", changeLines(last.Changes))
+		}
+
+		// a deleted record is restored before it is reverted
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id}); err != nil {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		_, err = journalsService.RevertRecord(context.TODO(), &pb.RevertRecordRequest{Id: record.Id, RevisionId: first.Id})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound reverting a deleted record, got %v", err)
+		}
+	})
+}
+
+// Tests renaming a tag and deleting a category append a revision to every
+// record they change
+func TestBulkRevisions(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		tagsService := pb.NewTagsServiceClient(conn)
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food"})
+		created, err := journalsService.Create(context.TODO(), &pb.CreateRecordRequest{
+			Type: pb.RecordType_EXPENSE, Title: "Lunch", Amount: &pb.Money{Units: 1200, CurrencyCode: "EUR"}, Date: day(1), CategoryId: food.Id, Tags: []string{"work"},
+		})
+		if err != nil {
+			t.Fatalf("unable to create record\n%v\n", err)
+		}
+		record := created.Record
+		if _, err := tagsService.RenameTag(context.TODO(), &pb.RenameTagRequest{From: "work", To: "office"}); err != nil {
+			t.Fatalf("unable to rename tag\n%v\n", err)
+		}
+		if _, err := categoriesService.DeleteCategory(context.TODO(), &pb.DeleteCategoryRequest{Id: food.Id}); err != nil {
+			t.Fatalf("unable to delete category\n%v\n", err)
+		}
+
+		res, err := journalsService.GetRecordHistory(context.TODO(), &pb.GetRecordHistoryRequest{Id: record.Id})
+		if err != nil {
+			t.Fatalf("unable to get history\n%v\n", err)
+		}
+		revisions := res.Revisions
+		if !equalTitles(revisionActions(revisions), []string{"REVISION_CREATED", "REVISION_UPDATED", "REVISION_UPDATED"}) {
+			t.Fatalf("unexpected revisions %v", revisionActions(revisions))
+		}
+		renamed, moved := revisions[1], revisions[2]
+		if expected := []string{`tags: "work" -> "office"`}; !equalTitles(changeLines(renamed.Changes), expected) || renamed.ActorId != USER_ID {
+			t.Errorf("expected the renamed tag by the user, got %v", renamed)
+		}
+		if expected := []string{`category_id: "` + food.Id + `" -> ""`}; !equalTitles(changeLines(moved.Changes), expected) || moved.Record.Version != 3 {
+			t.Errorf("expected the uncategorized record, got %v", moved)
+		}
+		if current, err := journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: record.Id}); err != nil || current.Record.Version != moved.Record.Version {
+			t.Errorf("expected the last revision at the version of the record, got %v %v", current, err)
+		}
+	})
+}
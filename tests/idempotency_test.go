@@ -0,0 +1,214 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"github.com/fine-track/journals-app/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Tests retried calls with an idempotency key return the first response
+func TestIdempotencyKeys(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		lunch := &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Lunch", Amount: &pb.Money{Units: 1200, CurrencyCode: "EUR"}, Date: day(1), IdempotencyKey: "lunch-1"}
+
+		first, err := journalsService.Create(context.TODO(), lunch)
+		if err != nil {
+			t.Fatalf("unable to create record\n%v\n", err)
+		}
+		header := metadata.MD{}
+		retry, err := journalsService.Create(context.TODO(), lunch, grpc.Header(&header))
+		if err != nil {
+			t.Fatalf("unable to retry the call\n%v\n", err)
+		}
+		if retry.Record.Id != first.Record.Id || len(header.Get(services.ReplayedHeader)) == 0 {
+			t.Errorf("expected the response of the first call replayed, got %v with header %v", retry, header)
+		}
+
+		// the metadata key is the same key as the field
+		lunch.IdempotencyKey = ""
+		ctx := metadata.AppendToOutgoingContext(context.TODO(), services.IdempotencyKeyHeader, "lunch-1")
+		if retry, err = journalsService.Create(ctx, lunch); err != nil || retry.Record.Id != first.Record.Id {
+			t.Errorf("expected the metadata key to replay the first call, got %v %v", retry, err)
+		}
+		ctx = metadata.AppendToOutgoingContext(context.TODO(), services.IdempotencyKeyHeader, "other")
+		_, err = journalsService.Create(ctx, &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Lunch", Amount: &pb.Money{Units: 1200, CurrencyCode: "EUR"}, Date: day(1), IdempotencyKey: "lunch-1"})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"idempotency_key"}) {
+			t.Errorf("expected InvalidArgument on idempotency_key for different keys, got %v", err)
+		}
+
+		// a key reused for another payload or method
+		_, err = journalsService.Create(context.TODO(), &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Dinner", Amount: &pb.Money{Units: 1200, CurrencyCode: "EUR"}, Date: day(1), IdempotencyKey: "lunch-1"})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"idempotency_key"}) {
+			t.Errorf("expected InvalidArgument on idempotency_key for another payload, got %v", err)
+		}
+		_, err = journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: first.Record.Id, IdempotencyKey: "lunch-1"})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument reusing the key for another method, got %v", err)
+		}
+
+		// keys belong to a user
+		other := pb.NewRecordsServiceClient(conn.As(t, "64d1b7e92b3de19c6a478937"))
+		lunch.IdempotencyKey = "lunch-1"
+		if res, err := other.Create(context.TODO(), lunch); err != nil || res.Record.Id == first.Record.Id {
+			t.Errorf("expected another user to create a record with the same key, got %v %v", res, err)
+		}
+
+		// a failed call does not keep its key
+		invalid := &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Snack", Amount: &pb.Money{Units: -1, CurrencyCode: "EUR"}, Date: day(2), IdempotencyKey: "snack"}
+		for i := 0; i < 2; i++ {
+			_, err = journalsService.Create(context.TODO(), invalid)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"amount.units"}) {
+				t.Errorf("expected the failed call to run again, got %v", err)
+			}
+		}
+		invalid.Amount.Units = 300
+		if _, err := journalsService.Create(context.TODO(), invalid); err != nil {
+			t.Errorf("expected the key of a failed call to be free\n%v\n", err)
+		}
+
+		if list := allRecords(t, journalsService); !equalTitles(titles(list.Records), []string{"Lunch", "Snack"}) {
+			t.Errorf("expected a record per key, got %v", titles(list.Records))
+		}
+	})
+}
+
+// Tests keys are free again once expired, or when their call never completed
+func TestIdempotencyKeyWindow(t *testing.T) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			store := s.open(t)
+			ctx := context.TODO()
+			now := db.Now()
+			key := db.IdempotencyKey{
+				UserId: primitive.NewObjectID(), Key: "k", RequestHash: "a",
+				CreatedAt: now, ExpiresAt: now.Add(time.Hour), LockedUntil: now.Add(time.Minute),
+			}
+			if _, err := store.ReserveKey(ctx, key); err != nil {
+				t.Fatalf("unable to reserve key\n%v\n", err)
+			}
+			if stored, err := store.ReserveKey(ctx, key); !errors.Is(err, db.ErrAlreadyExists) || stored.RequestHash != "a" || stored.Response != nil {
+				t.Errorf("expected the running key returned, got %v %v", stored, err)
+			}
+
+			// a running call keeps its key by renewing the lock
+			renewed := key
+			renewed.LockedUntil = now.Add(3 * time.Minute)
+			if err := store.RenewKey(ctx, renewed); err != nil {
+				t.Fatalf("unable to renew key\n%v\n", err)
+			}
+			later := key
+			later.RequestHash = "b"
+			later.CreatedAt = now.Add(2 * time.Minute)
+			later.LockedUntil = later.CreatedAt.Add(time.Minute)
+			if _, err := store.ReserveKey(ctx, later); !errors.Is(err, db.ErrAlreadyExists) {
+				t.Errorf("expected the renewed key kept, got %v", err)
+			}
+
+			// a call that never completed gives up its key, and can no
+			// longer complete, renew or release the later reservation
+			later.CreatedAt = now.Add(4 * time.Minute)
+			later.LockedUntil = later.CreatedAt.Add(time.Minute)
+			if _, err := store.ReserveKey(ctx, later); err != nil {
+				t.Errorf("expected the abandoned key reserved again\n%v\n", err)
+			}
+			stale := key
+			stale.Response = []byte("stale")
+			if err := store.CompleteKey(ctx, stale); !errors.Is(err, db.ErrNotFound) {
+				t.Errorf("expected ErrNotFound completing a replaced reservation, got %v", err)
+			}
+			if err := store.RenewKey(ctx, renewed); !errors.Is(err, db.ErrNotFound) {
+				t.Errorf("expected ErrNotFound renewing a replaced reservation, got %v", err)
+			}
+			if err := store.ReleaseKey(ctx, key); err != nil {
+				t.Fatalf("unable to release key\n%v\n", err)
+			}
+			completed := later
+			completed.RequestHash = "c"
+			completed.Response = []byte("response")
+			if err := store.CompleteKey(ctx, completed); err != nil {
+				t.Fatalf("unable to complete key\n%v\n", err)
+			}
+			if err := store.RenewKey(ctx, later); !errors.Is(err, db.ErrNotFound) {
+				t.Errorf("expected ErrNotFound renewing a completed key, got %v", err)
+			}
+			later.CreatedAt = now.Add(30 * time.Minute)
+			if stored, err := store.ReserveKey(ctx, later); !errors.Is(err, db.ErrAlreadyExists) || string(stored.Response) != "response" || stored.RequestHash != "c" {
+				t.Errorf("expected the completed key kept, got %v %v", stored, err)
+			}
+
+			if n, err := store.PurgeKeys(ctx, now.Add(30*time.Minute)); err != nil || n != 0 {
+				t.Errorf("expected no key purged within the window, got %d %v", n, err)
+			}
+			if n, err := store.PurgeKeys(ctx, now.Add(2*time.Hour)); err != nil || n != 1 {
+				t.Errorf("expected the expired key purged, got %d %v", n, err)
+			}
+			later.CreatedAt = now.Add(2 * time.Hour)
+			if _, err := store.ReserveKey(ctx, later); err != nil {
+				t.Errorf("expected the expired key reserved again\n%v\n", err)
+			}
+		})
+	}
+}
+
+// Tests a retried import with an idempotency key imports the file once
+func TestIdempotentImport(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		mapping := &pb.ImportMapping{HasHeader: true, DateColumn: 1, AmountColumn: 2, TitleColumn: 3, CurrencyCode: "EUR"}
+		// the first message holds the mapping and the header of the file,
+		// the same for every file
+		importFile := func(ctx context.Context, chunks ...string) (*pb.ImportRecordsResponse, metadata.MD, error) {
+			header := metadata.MD{}
+			stream, err := journalsService.ImportRecords(ctx, grpc.Header(&header))
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := stream.Send(&pb.ImportRecordsRequest{Mapping: mapping, Chunk: []byte("Date,Amount,Title\n")}); err != nil {
+				return nil, nil, err
+			}
+			for _, chunk := range chunks {
+				if err := stream.Send(&pb.ImportRecordsRequest{Chunk: []byte(chunk)}); err != nil {
+					break
+				}
+			}
+			res, err := stream.CloseAndRecv()
+			return res, header, err
+		}
+		ctx := metadata.AppendToOutgoingContext(context.TODO(), services.IdempotencyKeyHeader, "august")
+		first, _, err := importFile(ctx, "2023-08-05,-12.00,Lunch\n", "2023-08-06,-25.00,Dinner\n")
+		if err != nil || first.Imported != 2 {
+			t.Fatalf("unable to import records\n%v %v\n", first, err)
+		}
+		retry, header, err := importFile(ctx, "2023-08-05,-12.00,Lunch\n", "2023-08-06,-25.00,Dinner\n")
+		if err != nil || retry.Imported != 2 || len(header.Get(services.ReplayedHeader)) == 0 {
+			t.Errorf("expected the response of the first import replayed, got %v %v with header %v", retry, err, header)
+		}
+		if list := allRecords(t, journalsService); !equalTitles(titles(list.Records), []string{"Lunch", "Dinner"}) {
+			t.Errorf("expected the file imported once, got %v", titles(list.Records))
+		}
+
+		_, _, err = importFile(ctx, "2023-08-07,-8.00,Snack\n")
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"idempotency_key"}) {
+			t.Errorf("expected InvalidArgument on idempotency_key for another file, got %v", err)
+		}
+		// a failed import does not keep its key
+		mapping.DateColumn = 0
+		ctx = metadata.AppendToOutgoingContext(context.TODO(), services.IdempotencyKeyHeader, "september")
+		for i := 0; i < 2; i++ {
+			if _, _, err := importFile(ctx); status.Code(err) != codes.InvalidArgument {
+				t.Errorf("expected the failed import to run again, got %v", err)
+			}
+		}
+	})
+}
@@ -0,0 +1,393 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// importCSV streams the file in small chunks so rows span several messages.
+func importCSV(journalsService pb.RecordsServiceClient, mapping *pb.ImportMapping, dryRun bool, file string) (*pb.ImportRecordsResponse, error) {
+	stream, err := journalsService.ImportRecords(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	req := &pb.ImportRecordsRequest{Mapping: mapping, DryRun: dryRun}
+	for {
+		n := len(file)
+		if n > 7 {
+			n = 7
+		}
+		req.Chunk = []byte(file[:n])
+		if err := stream.Send(req); err != nil {
+			break
+		}
+		file = file[n:]
+		if file == "" {
+			break
+		}
+		req = &pb.ImportRecordsRequest{}
+	}
+	return stream.CloseAndRecv()
+}
+
+// importErrors renders errors as "line field".
+func importErrors(errors []*pb.ImportRowError) []string {
+	lines := []string{}
+	for _, e := range errors {
+		lines = append(lines, fmt.Sprintf("%d %s", e.Line, e.Field))
+	}
+	return lines
+}
+
+func allRecords(t *testing.T, journalsService pb.RecordsServiceClient) *pb.GetRecordsResponse {
+	t.Helper()
+	result, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{
+		Filter: &pb.RecordFilter{}, Sort: &pb.RecordSort{Ascending: true}, PageSize: 100, IncludeTotal: true,
+	})
+	if err != nil {
+		t.Fatalf("unable to list records\n%v\n", err)
+	}
+	return result
+}
+
+// Tests importing a localized CSV file, dry run first
+func TestImportRecords(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		mapping := &pb.ImportMapping{
+			Delimiter: ";", HasHeader: true, DateColumn: 1, AmountColumn: 2, TitleColumn: 3, TagsColumn: 4,
+			DateFormat: "DD.MM.YYYY", DecimalSeparator: ",", ThousandsSeparator: ".", TagSeparator: "|", CurrencyCode: "EUR",
+		}
+		file := "\ufeffDate;Amount;Title;Tags\n" +
+			"05.08.2023;-1.234,50;Rent;home|Bills\n" +
+			"06.08.2023;2.000,00;\"Salary; August\";\n" +
+			"31.02.2023;-3,00;Bad date;\n" +
+			"07.08.2023;abc;Bad amount;\n" +
+			"08.08.2023;(12,00);Fee\n"
+
+		dryRun, err := importCSV(journalsService, mapping, true, file)
+		if err != nil {
+			t.Fatalf("unable to dry run the import\n%v\n", err)
+		}
+		if dryRun.Rows != 5 || dryRun.Imported != 3 || dryRun.ErrorCount != 2 || !equalTitles(importErrors(dryRun.Errors), []string{"4 date", "5 amount"}) {
+			t.Errorf("unexpected dry run result %v", dryRun)
+		}
+		if total := allRecords(t, journalsService).TotalCount; total != 0 {
+			t.Errorf("expected a dry run to create no record, got %d", total)
+		}
+
+		result, err := importCSV(journalsService, mapping, false, file)
+		if err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+		if result.Imported != 3 || result.ErrorCount != 2 {
+			t.Errorf("unexpected import result %v", result)
+		}
+		records := allRecords(t, journalsService).Records
+		if !equalTitles(titles(records), []string{"Rent", "Salary; August", "Fee"}) {
+			t.Fatalf("unexpected records %v", titles(records))
+		}
+		rent, salary, fee := records[0], records[1], records[2]
+		if rent.Type != pb.RecordType_EXPENSE || rent.Amount.Units != 123450 || rent.Amount.CurrencyCode != "EUR" || !equalTitles(rent.Tags, []string{"bills", "home"}) {
+			t.Errorf("unexpected record %v", rent)
+		}
+		if salary.Type != pb.RecordType_INCOME || salary.Amount.Units != 200000 || rent.Date.AsTime().Day() != 5 {
+			t.Errorf("unexpected record %v", salary)
+		}
+		if fee.Type != pb.RecordType_EXPENSE || fee.Amount.Units != 1200 {
+			t.Errorf("unexpected record %v", fee)
+		}
+
+		lines := []string{"date,amount"}
+		for i := 0; i < 250; i++ {
+			lines = append(lines, fmt.Sprintf("2023-08-%02d,-%d.00", i%28+1, i+1))
+		}
+		result, err = importCSV(journalsService, &pb.ImportMapping{HasHeader: true, DateColumn: 1, AmountColumn: 2}, false, strings.Join(lines, "\n"))
+		if err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+		if result.Imported != 250 || result.ErrorCount != 0 {
+			t.Errorf("expected 250 records imported in batches, got %v", result)
+		}
+		if total := allRecords(t, journalsService).TotalCount; total != 253 {
+			t.Errorf("expected 253 records, got %d", total)
+		}
+	})
+}
+
+// Tests type columns, accounts and invalid mappings
+func TestImportRecordsMapping(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		accountsService := pb.NewAccountsServiceClient(conn)
+		checking := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "USD"})
+
+		file := "2023-08-01,50.00,debit,Groceries,USD\n" +
+			"2023-08-02,-20.00,CR,Cashback,usd\n" +
+			"2023-08-03,5.00,CR,Refund,EUR\n" +
+			"2023-08-04,5.00,transfer,Odd,USD\n" +
+			"2023-08-05\n"
+		mapping := &pb.ImportMapping{DateColumn: 1, AmountColumn: 2, TypeColumn: 3, TitleColumn: 4, CurrencyColumn: 5, Sign: pb.AmountSign_UNSIGNED, AccountId: checking.Id}
+		result, err := importCSV(journalsService, mapping, false, file)
+		if err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+		if result.Imported != 2 || result.ErrorCount != 3 {
+			t.Errorf("unexpected import result %v", result)
+		}
+		want := []string{"3 amount.currency_code", "4 type"}
+		if got := importErrors(result.Errors); len(got) < 2 || !equalTitles(got[:2], want) {
+			t.Errorf("expected errors %v, got %v", want, got)
+		}
+		records := allRecords(t, journalsService).Records
+		if !equalTitles(titles(records), []string{"Groceries", "Cashback"}) {
+			t.Fatalf("unexpected records %v", titles(records))
+		}
+		for _, r := range records {
+			if r.AccountId != checking.Id {
+				t.Errorf("expected the record on %s, got %v", checking.Id, r)
+			}
+		}
+		if records[1].Type != pb.RecordType_INCOME || records[1].Amount.Units != 2000 {
+			t.Errorf("unexpected record %v", records[1])
+		}
+
+		invalid := []struct {
+			mapping *pb.ImportMapping
+			field   string
+		}{
+			{nil, "mapping"},
+			{&pb.ImportMapping{DateColumn: 1}, "mapping.amount_column"},
+			{&pb.ImportMapping{DateColumn: 1, AmountColumn: 2, Sign: pb.AmountSign_UNSIGNED}, "mapping.type_column"},
+			{&pb.ImportMapping{DateColumn: 1, AmountColumn: 2, DateFormat: "DD/MM/YYYY at HH"}, "mapping.date_format"},
+			{&pb.ImportMapping{DateColumn: 1, AmountColumn: 2, DecimalSeparator: ",", ThousandsSeparator: ","}, "mapping.thousands_separator"},
+			{&pb.ImportMapping{DateColumn: 1, AmountColumn: 2, Delimiter: "||"}, "mapping.delimiter"},
+			{&pb.ImportMapping{DateColumn: 1, AmountColumn: 2, TimeZone: "Mars/Olympus"}, "mapping.time_zone"},
+		}
+		for _, c := range invalid {
+			_, err := importCSV(journalsService, c.mapping, true, file)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s importing with %v, got %v", c.field, c.mapping, err)
+			}
+		}
+	})
+}
+
+// importStatement streams the statement in small chunks.
+func importStatement(journalsService pb.RecordsServiceClient, req *pb.ImportStatementRequest, file string) (*pb.ImportRecordsResponse, error) {
+	stream, err := journalsService.ImportStatement(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	for {
+		n := len(file)
+		if n > 11 {
+			n = 11
+		}
+		req.Chunk = []byte(file[:n])
+		if err := stream.Send(req); err != nil {
+			break
+		}
+		file = file[n:]
+		if file == "" {
+			break
+		}
+		req = &pb.ImportStatementRequest{}
+	}
+	return stream.CloseAndRecv()
+}
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+CHARSET:1252
+
+<OFX>
+<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0<SEVERITY>INFO</STATUS><DTSERVER>20230810</SONRS></SIGNONMSGSRSV1>
+<BANKMSGSRSV1><STMTTRNRS><TRNUID>1<STMTRS>
+<CURDEF>EUR
+<BANKACCTFROM><BANKID>1<ACCTID>2<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST><DTSTART>20230801<DTEND>20230810
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20230805120000.000[-5:EST]
+<TRNAMT>-45.10
+<FITID>T1
+<NAME>Grocer &amp; Sons
+<MEMO>Card 1234
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20230801
+<TRNAMT>3000,00
+<FITID>T2
+<NAME>ACME PAYROLL
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>2023-08-07
+<TRNAMT>-5.00
+<FITID>T3
+<NAME>Bad date
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+const xmlStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="211" SECURITY="NONE"?>
+<OFX><BANKMSGSRSV1><STMTTRNRS><STMTRS><CURDEF>EUR</CURDEF><BANKTRANLIST>
+<STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>20230801</DTPOSTED><TRNAMT>3000.00</TRNAMT><FITID>T2</FITID><NAME>ACME PAYROLL</NAME></STMTTRN>
+<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20230809</DTPOSTED><TRNAMT>-12.00</TRNAMT><FITID>T4</FITID><NAME>Cinema</NAME><MEMO>Cinema</MEMO></STMTTRN>
+</BANKTRANLIST></STMTRS></STMTTRNRS></BANKMSGSRSV1></OFX>
+`
+
+// Tests importing OFX statements twice, transactions deduplicated per account
+func TestImportOFXStatement(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		accountsService := pb.NewAccountsServiceClient(conn)
+		checking := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "EUR"})
+		card := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Card", Kind: pb.AccountKind_CARD, CurrencyCode: "EUR"})
+
+		result, err := importStatement(journalsService, &pb.ImportStatementRequest{Format: pb.StatementFormat_OFX, AccountId: checking.Id}, sgmlStatement)
+		if err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+		if result.Rows != 3 || result.Imported != 2 || result.Duplicates != 0 || !equalTitles(importErrors(result.Errors), []string{"27 date"}) {
+			t.Errorf("unexpected import result %v", result)
+		}
+		records := allRecords(t, journalsService).Records
+		if !equalTitles(titles(records), []string{"ACME PAYROLL", "Grocer & Sons"}) {
+			t.Fatalf("unexpected records %v", titles(records))
+		}
+		payroll, grocer := records[0], records[1]
+		if payroll.Type != pb.RecordType_INCOME || payroll.Amount.Units != 300000 || payroll.Amount.CurrencyCode != "EUR" || payroll.ImportId != "T2" || payroll.AccountId != checking.Id {
+			t.Errorf("unexpected record %v", payroll)
+		}
+		if grocer.Type != pb.RecordType_EXPENSE || grocer.Amount.Units != 4510 || grocer.Description != "Card 1234" || grocer.Date.AsTime().Hour() != 17 {
+			t.Errorf("unexpected record %v", grocer)
+		}
+
+		result, err = importStatement(journalsService, &pb.ImportStatementRequest{Format: pb.StatementFormat_OFX, AccountId: checking.Id}, sgmlStatement)
+		if err != nil {
+			t.Fatalf("unable to import again\n%v\n", err)
+		}
+		if result.Imported != 0 || result.Duplicates != 2 || result.ErrorCount != 1 {
+			t.Errorf("expected the statement imported once, got %v", result)
+		}
+		result, err = importStatement(journalsService, &pb.ImportStatementRequest{Format: pb.StatementFormat_OFX, AccountId: checking.Id, DryRun: true}, xmlStatement)
+		if err != nil {
+			t.Fatalf("unable to dry run the import\n%v\n", err)
+		}
+		if result.Imported != 1 || result.Duplicates != 1 {
+			t.Errorf("unexpected dry run result %v", result)
+		}
+		result, err = importStatement(journalsService, &pb.ImportStatementRequest{Format: pb.StatementFormat_OFX, AccountId: checking.Id}, xmlStatement)
+		if err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+		if result.Imported != 1 || result.Duplicates != 1 || result.ErrorCount != 0 {
+			t.Errorf("unexpected import result %v", result)
+		}
+		result, err = importStatement(journalsService, &pb.ImportStatementRequest{Format: pb.StatementFormat_OFX, AccountId: card.Id}, xmlStatement)
+		if err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+		if result.Imported != 2 || result.Duplicates != 0 {
+			t.Errorf("expected transaction ids scoped to the account, got %v", result)
+		}
+		records = allRecords(t, journalsService).Records
+		if len(records) != 5 || records[4].Title != "Cinema" || records[4].Description != "" {
+			t.Errorf("unexpected records %v", records)
+		}
+	})
+}
+
+const qifStatement = `!Type:Bank
+D05/08'23
+T-1.234,50
+PLandlord
+MAugust rent
+^
+D06/08/2023
+T2,000.00
+PSalary
+^
+D06/08/2023
+T-3.50
+PCoffee
+^
+D06/08/2023
+T-3.50
+PCoffee
+^
+D31/02/2023
+T-1.00
+PBad
+^
+!Type:Invst
+D01/08/2023
+NBuy
+T100.00
+^
+`
+
+// Tests importing QIF statements twice and invalid options
+func TestImportQIFStatement(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		req := func() *pb.ImportStatementRequest {
+			return &pb.ImportStatementRequest{Format: pb.StatementFormat_QIF, DateOrder: pb.DateOrder_DMY, CurrencyCode: "GBP"}
+		}
+		result, err := importStatement(journalsService, req(), qifStatement)
+		if err != nil {
+			t.Fatalf("unable to import\n%v\n", err)
+		}
+		if result.Rows != 5 || result.Imported != 4 || !equalTitles(importErrors(result.Errors), []string{"19 date"}) {
+			t.Errorf("unexpected import result %v", result)
+		}
+		records := allRecords(t, journalsService).Records
+		if !equalTitles(titles(records), []string{"Landlord", "Salary", "Coffee", "Coffee"}) {
+			t.Fatalf("unexpected records %v", titles(records))
+		}
+		rent, salary := records[0], records[1]
+		if rent.Type != pb.RecordType_EXPENSE || rent.Amount.Units != 123450 || rent.Amount.CurrencyCode != "GBP" || rent.Description != "August rent" || rent.Date.AsTime().Month() != 8 {
+			t.Errorf("unexpected record %v", rent)
+		}
+		if salary.Type != pb.RecordType_INCOME || salary.Amount.Units != 200000 {
+			t.Errorf("unexpected record %v", salary)
+		}
+
+		result, err = importStatement(journalsService, req(), qifStatement)
+		if err != nil {
+			t.Fatalf("unable to import again\n%v\n", err)
+		}
+		if result.Imported != 0 || result.Duplicates != 4 {
+			t.Errorf("expected the statement imported once, got %v", result)
+		}
+
+		invalid := []struct {
+			req   *pb.ImportStatementRequest
+			field string
+		}{
+			{&pb.ImportStatementRequest{Format: 7}, "format"},
+			{&pb.ImportStatementRequest{DateOrder: 7}, "date_order"},
+			{&pb.ImportStatementRequest{CurrencyCode: "euro"}, "currency_code"},
+			{&pb.ImportStatementRequest{TimeZone: "Mars/Olympus"}, "time_zone"},
+		}
+		for _, c := range invalid {
+			_, err := importStatement(journalsService, c.req, qifStatement)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s importing with %v, got %v", c.field, c.req, err)
+			}
+		}
+	})
+}
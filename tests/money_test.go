@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/fine-track/journals-app/db"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := []struct {
+		amount   string
+		currency string
+		units    int64
+		str      string
+	}{
+		{"12.34", "USD", 1234, "12.34 USD"},
+		{"-0.5", "EUR", -50, "-0.50 EUR"},
+		{"1500", "JPY", 1500, "1500 JPY"},
+		{"1.234", "KWD", 1234, "1.234 KWD"},
+		{"30000000", "USD", 3000000000, "30000000.00 USD"},
+	}
+	for _, c := range cases {
+		m, err := db.ParseMoney(c.amount, c.currency)
+		if err != nil {
+			t.Errorf("unable to parse '%s %s'\n%v\n", c.amount, c.currency, err)
+			continue
+		}
+		if m.Units != c.units || m.String() != c.str {
+			t.Errorf("parsed '%s %s' as %d (%s), expected %d (%s)", c.amount, c.currency, m.Units, m, c.units, c.str)
+		}
+	}
+
+	for _, invalid := range [][2]string{{"1.5", "JPY"}, {"12.345", "USD"}, {"abc", "USD"}, {"10", "ABC"}, {"", "USD"}} {
+		if _, err := db.ParseMoney(invalid[0], invalid[1]); err == nil {
+			t.Errorf("expected an error parsing '%s %s'", invalid[0], invalid[1])
+		}
+	}
+}
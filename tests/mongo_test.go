@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// mockMongo runs fn against a MongoStore on a mocked deployment, which
+// answers the commands with the responses added to mt, in order.
+func mockMongo(t *testing.T, name string, fn func(mt *mtest.T, store *db.MongoStore)) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run(name, func(mt *mtest.T) {
+		fn(mt, db.NewMongoStore(mt.DB))
+	})
+}
+
+// sentCommands lists the name and collection of the commands sent, those
+// of a transaction marked with a "tx" prefix.
+func sentCommands(mt *mtest.T) []string {
+	commands := []string{}
+	for e := mt.GetStartedEvent(); e != nil; e = mt.GetStartedEvent() {
+		command := e.CommandName
+		if collection, ok := e.Command.Lookup(e.CommandName).StringValueOK(); ok {
+			command += " " + collection
+		}
+		if _, err := e.Command.LookupErr("autocommit"); err == nil {
+			command = "tx " + command
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+// mongoDoc is v as the document a server returns.
+func mongoDoc(t *testing.T, v any) bson.D {
+	t.Helper()
+	b, err := bson.Marshal(v)
+	if err != nil {
+		t.Fatalf("unable to marshal document\n%v\n", err)
+	}
+	doc := bson.D{}
+	if err := bson.Unmarshal(b, &doc); err != nil {
+		t.Fatalf("unable to unmarshal document\n%v\n", err)
+	}
+	return doc
+}
+
+func mongoRecord() db.Record {
+	return db.Record{
+		ID: primitive.NewObjectID(), UserId: primitive.NewObjectID(), Type: "EXPENSE", Title: "Lunch",
+		Amount: db.Money{Units: 1200, Currency: "EUR"}, Date: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Version: 1,
+	}
+}
+
+// Tests a record moves to the trash and back in a transaction
+func TestMongoTrash(t *testing.T) {
+	mockMongo(t, "delete", func(mt *mtest.T, store *db.MongoStore) {
+		r := mongoRecord()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch, mongoDoc(t, r)),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(),
+		)
+		if err := store.Delete(context.TODO(), r.UserId, r.ID, 1); err != nil {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		expected := []string{"find records", "tx delete records", "tx insert trash", "tx insert record_history", "tx commitTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the commands %v, got %v", expected, commands)
+		}
+	})
+
+	mockMongo(t, "delete concurrently", func(mt *mtest.T, store *db.MongoStore) {
+		r := mongoRecord()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch, mongoDoc(t, r)),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch),
+		)
+		if err := store.Delete(context.TODO(), r.UserId, r.ID, 0); !errors.Is(err, db.ErrNotFound) {
+			t.Errorf("expected ErrNotFound deleting a record deleted concurrently, got %v", err)
+		}
+		expected := []string{"find records", "tx delete records", "tx abortTransaction", "aggregate records"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the commands %v, got %v", expected, commands)
+		}
+	})
+
+	mockMongo(t, "failed delete", func(mt *mtest.T, store *db.MongoStore) {
+		r := mongoRecord()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch, mongoDoc(t, r)),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}),
+			mtest.CreateSuccessResponse(),
+		)
+		if err := store.Delete(context.TODO(), r.UserId, r.ID, 1); !errors.Is(err, db.ErrAlreadyExists) {
+			t.Errorf("expected the failed insert returned, got %v", err)
+		}
+		expected := []string{"find records", "tx delete records", "tx insert trash", "tx abortTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the delete rolled back with the insert, got %v", commands)
+		}
+	})
+
+	mockMongo(t, "restore", func(mt *mtest.T, store *db.MongoStore) {
+		r := mongoRecord()
+		r.DeletedAt = time.Date(2023, 8, 2, 0, 0, 0, 0, time.UTC)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.trash", mtest.FirstBatch, mongoDoc(t, r)),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(),
+		)
+		restored, err := store.RestoreRecord(context.TODO(), r.UserId, r.ID)
+		if err != nil {
+			t.Fatalf("unable to restore record\n%v\n", err)
+		}
+		if restored.ID != r.ID || !restored.DeletedAt.IsZero() || restored.Version != r.Version {
+			t.Errorf("unexpected restored record %v", restored)
+		}
+		expected := []string{"find trash", "tx delete trash", "tx insert records", "tx insert record_history", "tx commitTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the commands %v, got %v", expected, commands)
+		}
+	})
+}
+
+// Tests the revision of a write shares its transaction
+func TestMongoHistory(t *testing.T) {
+	mockMongo(t, "create", func(mt *mtest.T, store *db.MongoStore) {
+		r := mongoRecord()
+		r.ID = primitive.NilObjectID
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(),
+		)
+		if err := store.Create(context.TODO(), &r); err != nil {
+			t.Fatalf("unable to create record\n%v\n", err)
+		}
+		if r.ID.IsZero() || r.Version != 1 {
+			t.Errorf("unexpected created record %v", r)
+		}
+		expected := []string{"tx insert records", "tx insert record_history", "tx commitTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the commands %v, got %v", expected, commands)
+		}
+	})
+
+	mockMongo(t, "failed revision", func(mt *mtest.T, store *db.MongoStore) {
+		r := mongoRecord()
+		r.ID = primitive.NilObjectID
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}),
+			mtest.CreateSuccessResponse(),
+		)
+		if err := store.Create(context.TODO(), &r); err == nil {
+			t.Errorf("expected the failed revision returned")
+		}
+		if !r.ID.IsZero() {
+			t.Errorf("expected the record left as is, got %v", r)
+		}
+		expected := []string{"tx insert records", "tx insert record_history", "tx abortTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the insert rolled back with the revision, got %v", commands)
+		}
+	})
+
+	mockMongo(t, "update", func(mt *mtest.T, store *db.MongoStore) {
+		stored := mongoRecord()
+		written := stored
+		written.Title, written.Version = "Dinner", 2
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch, mongoDoc(t, stored)),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: mongoDoc(t, written)}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(),
+		)
+		r := db.Record{ID: stored.ID, UserId: stored.UserId, Title: "Dinner", Version: 1}
+		if err := store.Update(context.TODO(), &r, []string{"title"}); err != nil {
+			t.Fatalf("unable to update record\n%v\n", err)
+		}
+		if r.Title != "Dinner" || r.Version != 2 {
+			t.Errorf("unexpected updated record %v", r)
+		}
+		expected := []string{"find records", "tx findAndModify records", "tx insert record_history", "tx commitTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the commands %v, got %v", expected, commands)
+		}
+	})
+
+	mockMongo(t, "update concurrently", func(mt *mtest.T, store *db.MongoStore) {
+		stored := mongoRecord()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch, mongoDoc(t, stored)),
+			mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}),
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateSuccessResponse(),
+		)
+		r := db.Record{ID: stored.ID, UserId: stored.UserId, Title: "Dinner", Version: 1}
+		if err := store.Update(context.TODO(), &r, []string{"title"}); !errors.Is(err, db.ErrVersionMismatch) {
+			t.Errorf("expected ErrVersionMismatch updating a record changed concurrently, got %v", err)
+		}
+		expected := []string{"find records", "tx findAndModify records", "tx aggregate records", "tx abortTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected no revision of the failed update, got %v", commands)
+		}
+	})
+
+	mockMongo(t, "rename tag", func(mt *mtest.T, store *db.MongoStore) {
+		r := mongoRecord()
+		r.Tags = []string{"work"}
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateCursorResponse(0, "test.records", mtest.FirstBatch, mongoDoc(t, r)),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			mtest.CreateSuccessResponse(),
+		)
+		changed, err := store.RenameTag(context.TODO(), r.UserId, "work", "office")
+		if err != nil {
+			t.Fatalf("unable to rename tag\n%v\n", err)
+		}
+		if changed != 1 {
+			t.Errorf("expected 1 record changed, got %d", changed)
+		}
+		expected := []string{"tx update budgets", "tx find records", "tx update records", "tx insert record_history", "tx commitTransaction"}
+		if commands := sentCommands(mt); !equalTitles(commands, expected) {
+			t.Errorf("expected the commands %v, got %v", expected, commands)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+)
+
+// Tests paging through records with page tokens
+func TestGetRecordsPagination(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		for i := 0; i < 25; i++ {
+			// several records share a date so the id breaks ties
+			seedRecords(t, journalsService, &pb.CreateRecordRequest{
+				Type:   pb.RecordType_EXPENSE,
+				Title:  fmt.Sprintf("Record %d", i),
+				Amount: &pb.Money{Units: int64(100 * i), CurrencyCode: "USD"},
+				Date:   day(1 + i/3),
+			})
+		}
+
+		seen := map[string]bool{}
+		req := &pb.GetRecordsRequest{Filter: &pb.RecordFilter{}, PageSize: 10, IncludeTotal: true}
+		pages := 0
+		for {
+			result, err := journalsService.GetRecords(context.TODO(), req)
+			if err != nil {
+				t.Fatalf("unable to get page %d\n%v\n", pages, err)
+			}
+			pages++
+			if result.TotalCount != 25 && pages == 1 {
+				t.Errorf("expected a total of 25, got %d", result.TotalCount)
+			}
+			for _, r := range result.Records {
+				if seen[r.Id] {
+					t.Errorf("record %s returned twice", r.Title)
+				}
+				seen[r.Id] = true
+			}
+			if pages == 1 {
+				// records inserted while paging must not shift the following pages
+				seedRecords(t, journalsService, &pb.CreateRecordRequest{
+					Type: pb.RecordType_EXPENSE, Title: "Late", Amount: &pb.Money{Units: 1, CurrencyCode: "USD"}, Date: day(28),
+				})
+			}
+			if result.NextPageToken == "" {
+				break
+			}
+			req.PageToken = result.NextPageToken
+		}
+		if pages != 3 || len(seen) != 25 {
+			t.Errorf("expected 25 records in 3 pages, got %d in %d", len(seen), pages)
+		}
+
+		// an exact multiple of the page size has no empty trailing page
+		result, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{}, PageSize: 26})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if len(result.Records) != 26 || result.NextPageToken != "" {
+			t.Errorf("expected all 26 records and no next page, got %d and '%s'", len(result.Records), result.NextPageToken)
+		}
+
+		// tokens are bound to the query they came from
+		first, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{}, PageSize: 5})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		for _, invalid := range []*pb.GetRecordsRequest{
+			{Filter: &pb.RecordFilter{Text: "Record"}, PageToken: first.NextPageToken},
+			{Filter: &pb.RecordFilter{}, Sort: &pb.RecordSort{Ascending: true}, PageToken: first.NextPageToken},
+			{Filter: &pb.RecordFilter{}, PageToken: "not-a-token"},
+		} {
+			if _, err := journalsService.GetRecords(context.TODO(), invalid); err == nil {
+				t.Errorf("expected an error for page token with %v", invalid)
+			}
+		}
+
+		big, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{}, PageSize: 1000})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if len(big.Records) != 26 {
+			t.Errorf("expected 26 records, got %d", len(big.Records))
+		}
+	})
+}
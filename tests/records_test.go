@@ -7,98 +7,321 @@ import (
 	"time"
 
 	"github.com/fine-track/journals-app/pb"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-const (
-	ADDRESS = "localhost:8082"
-	USER_ID = "64d1b7e92b3de19c6a478936"
-)
-
-// Tests creating a new record on the db
-func TestCreateRecord(t *testing.T) {
-	conn, err := grpc.Dial(ADDRESS, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		t.Errorf("unable to connect to the service\n%v\n", err)
-	}
-	t.Cleanup(func() { conn.Close() })
-	journalsService := pb.NewRecordsServiceClient(conn)
+const USER_ID = "64d1b7e92b3de19c6a478936"
 
-	// now create a new journal in the db
+func createRecord(t *testing.T, journalsService pb.RecordsServiceClient, recordType pb.RecordType, title string) *pb.Record {
+	t.Helper()
 	payload := &pb.CreateRecordRequest{
-		UserId:      USER_ID,
-		Type:        pb.RecordType_EXPENSE,
-		Amount:      1100,
-		Title:       "Testing Records",
-		Description: fmt.Sprintf("Testing records on %s", time.Now().Format("DD-MM-YYYY")),
-		Date:        time.Now().Format("YYYY-MM-DD"),
-		CreatedAt:   time.Now().String(),
-		UpdatedAt:   time.Now().String(),
+		Type:        recordType,
+		Amount:      &pb.Money{Units: 1100, CurrencyCode: "EUR"},
+		Title:       title,
+		Description: fmt.Sprintf("Testing records on %s", time.Now().Format("02-01-2006")),
+		Date:        timestamppb.Now(),
 	}
 	result, err := journalsService.Create(context.TODO(), payload)
 	if err != nil {
-		t.Errorf("unable to create record\npayload: %v\n%v\n", payload, err)
+		t.Fatalf("unable to create record\npayload: %v\n%v\n", payload, err)
 	}
 	if !result.Success {
-		t.Errorf("unable to create record\npayload: %v\n%v\n", payload, result.Message)
+		t.Fatalf("unable to create record\npayload: %v\n%v\n", payload, result.Message)
 	}
+	return result.Record
+}
+
+// Tests creating a new record on the db
+func TestCreateRecord(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+
+		// now create a new journal in the db
+		payload := &pb.CreateRecordRequest{
+			Type:        pb.RecordType_EXPENSE,
+			Amount:      &pb.Money{Units: 1100, CurrencyCode: "EUR"},
+			Title:       "Testing Records",
+			Description: fmt.Sprintf("Testing records on %s", time.Now().Format("02-01-2006")),
+			Date:        timestamppb.Now(),
+		}
+		result, err := journalsService.Create(context.TODO(), payload)
+		if err != nil {
+			t.Fatalf("unable to create record\npayload: %v\n%v\n", payload, err)
+		}
+		if !result.Success {
+			t.Errorf("unable to create record\npayload: %v\n%v\n", payload, result.Message)
+		}
+		if result.Record.Id == "" {
+			t.Errorf("created record has no id")
+		}
+	})
 }
 
 // Tests getting records from the db
 func TestGetRecords(t *testing.T) {
-	conn, err := grpc.Dial(ADDRESS, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
-		t.Errorf("unable to connect to the service\n%v\n", err)
-	}
-	t.Cleanup(func() { conn.Close() })
-	journalsService := pb.NewRecordsServiceClient(conn)
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		createRecord(t, journalsService, pb.RecordType_EXPENSE, "Groceries")
+		createRecord(t, journalsService, pb.RecordType_INCOME, "Salary")
 
-	expensesPayload := &pb.GetRecordsRequest{
-		UserId: USER_ID,
-		Type:   pb.RecordType_EXPENSE,
-		Page:   0,
-	}
-	expensesResult, err := journalsService.GetRecords(context.TODO(), expensesPayload)
-	if err != nil {
-		t.Errorf("unable to get record\npayload: %v\n%v\n", expensesPayload, err)
-	}
-	if !expensesResult.Success {
-		t.Errorf("unable to get record\npayload: %v\n%v\n", expensesPayload, expensesResult.Message)
-	}
-	// verify the records have correct types
-	for _, r := range expensesResult.Records {
-		if r.Type.String() != expensesPayload.Type.String() {
-			t.Errorf("type mismatch, requested '%s' got '%s'", expensesPayload.Type.String(), r.Type.String())
+		expensesPayload := &pb.GetRecordsRequest{
+			Type: pb.RecordType_EXPENSE,
+		}
+		expensesResult, err := journalsService.GetRecords(context.TODO(), expensesPayload)
+		if err != nil {
+			t.Fatalf("unable to get record\npayload: %v\n%v\n", expensesPayload, err)
+		}
+		if !expensesResult.Success {
+			t.Errorf("unable to get record\npayload: %v\n%v\n", expensesPayload, expensesResult.Message)
+		}
+		if len(expensesResult.Records) != 1 {
+			t.Errorf("expected 1 record, got %d", len(expensesResult.Records))
+		}
+		// verify the records have correct types
+		for _, r := range expensesResult.Records {
+			if r.Type.String() != expensesPayload.Type.String() {
+				t.Errorf("type mismatch, requested '%s' got '%s'", expensesPayload.Type.String(), r.Type.String())
+			}
 		}
-	}
 
-	incomesPayload := &pb.GetRecordsRequest{
-		UserId: USER_ID,
-		Type:   pb.RecordType_EXPENSE,
-		Page:   0,
-	}
-	incomesResult, err := journalsService.GetRecords(context.TODO(), incomesPayload)
-	if err != nil {
-		t.Errorf("unable to get record\npayload: %v\n%v\n", incomesPayload, err)
-	}
-	if !incomesResult.Success {
-		t.Errorf("unable to get record\npayload: %v\n%v\n", incomesPayload, incomesResult.Message)
-	}
-	// verify the records have correct types
-	for _, r := range incomesResult.Records {
-		if r.Type.String() != incomesPayload.Type.String() {
-			t.Errorf("type mismatch, requested '%s' got '%s'", incomesPayload.Type.String(), r.Type.String())
+		incomesPayload := &pb.GetRecordsRequest{
+			Type: pb.RecordType_INCOME,
 		}
-	}
+		incomesResult, err := journalsService.GetRecords(context.TODO(), incomesPayload)
+		if err != nil {
+			t.Fatalf("unable to get record\npayload: %v\n%v\n", incomesPayload, err)
+		}
+		if len(incomesResult.Records) != 1 {
+			t.Errorf("expected 1 record, got %d", len(incomesResult.Records))
+		}
+		// verify the records have correct types
+		for _, r := range incomesResult.Records {
+			if r.Type.String() != incomesPayload.Type.String() {
+				t.Errorf("type mismatch, requested '%s' got '%s'", incomesPayload.Type.String(), r.Type.String())
+			}
+		}
+	})
 }
 
 func TestUpdateARecord(t *testing.T) {
-	// TODO
-	t.Fail()
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Rent")
+
+		record.Title = "Rent (August)"
+		record.Amount = &pb.Money{Units: 1200, CurrencyCode: "EUR"}
+		result, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{Record: record})
+		if err != nil {
+			t.Fatalf("unable to update record\npayload: %v\n%v\n", record, err)
+		}
+		if !result.Success {
+			t.Errorf("unable to update record\npayload: %v\n%v\n", record, result.Message)
+		}
+
+		list, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if len(list.Records) != 1 || list.Records[0].Title != "Rent (August)" || list.Records[0].Amount.Units != 1200 {
+			t.Errorf("record was not updated, got %v", list.Records)
+		}
+	})
 }
 
 func TestDeleteARecord(t *testing.T) {
-	// TODO
-	t.Fail()
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Coffee")
+
+		result, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id})
+		if err != nil {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		if !result.Success {
+			t.Errorf("unable to delete record\n%v\n", result.Message)
+		}
+
+		list, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if len(list.Records) != 0 {
+			t.Errorf("record was not deleted, got %v", list.Records)
+		}
+	})
+}
+
+// Tests that amounts without a valid currency are rejected
+func TestCreateRecordInvalidCurrency(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		for _, amount := range []*pb.Money{nil, {Units: 100, CurrencyCode: "XYZ"}, {Units: -100, CurrencyCode: "USD"}} {
+			payload := &pb.CreateRecordRequest{
+				Type:   pb.RecordType_EXPENSE,
+				Amount: amount,
+				Title:  "Invalid",
+				Date:   timestamppb.Now(),
+			}
+			if _, err := journalsService.Create(context.TODO(), payload); err == nil {
+				t.Errorf("expected an error creating a record with amount %v", amount)
+			}
+		}
+	})
+}
+
+// Tests that dates are required and created/updated times round-trip
+func TestRecordTimestamps(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		for _, date := range []*timestamppb.Timestamp{nil, {Seconds: -1e11}, timestamppb.New(time.Date(2300, 1, 1, 0, 0, 0, 0, time.UTC))} {
+			payload := &pb.CreateRecordRequest{
+				Type:   pb.RecordType_EXPENSE,
+				Amount: &pb.Money{Units: 100, CurrencyCode: "USD"},
+				Title:  "Invalid date",
+				Date:   date,
+			}
+			if _, err := journalsService.Create(context.TODO(), payload); err == nil {
+				t.Errorf("expected an error creating a record dated %v", date)
+			}
+		}
+
+		record := createRecord(t, journalsService, pb.RecordType_INCOME, "Salary")
+		if record.CreatedAt.AsTime().IsZero() || !record.CreatedAt.AsTime().Equal(record.UpdatedAt.AsTime()) {
+			t.Errorf("expected equal created and updated times on create, got %v and %v", record.CreatedAt, record.UpdatedAt)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+		date := time.Date(2023, 8, 1, 9, 30, 0, 0, time.UTC)
+		record.Date = timestamppb.New(date)
+		result, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{Record: record})
+		if err != nil {
+			t.Fatalf("unable to update record\n%v\n", err)
+		}
+		updated := result.Record
+		if !updated.Date.AsTime().Equal(date) {
+			t.Errorf("expected date %v, got %v", date, updated.Date.AsTime())
+		}
+		if !updated.CreatedAt.AsTime().Equal(record.CreatedAt.AsTime()) {
+			t.Errorf("created time changed on update from %v to %v", record.CreatedAt.AsTime(), updated.CreatedAt.AsTime())
+		}
+		if !updated.UpdatedAt.AsTime().After(record.UpdatedAt.AsTime()) {
+			t.Errorf("updated time did not move forward, got %v", updated.UpdatedAt.AsTime())
+		}
+	})
+}
+
+// Tests fetching a single record
+func TestGetRecord(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Books")
+
+		result, err := journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: record.Id})
+		if err != nil {
+			t.Fatalf("unable to get record\n%v\n", err)
+		}
+		if !proto.Equal(result.Record, record) {
+			t.Errorf("expected record %v, got %v", record, result.Record)
+		}
+
+		other := pb.NewRecordsServiceClient(conn.As(t, OTHER_USER_ID))
+		if _, err := other.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: record.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound getting another user's record, got %v", err)
+		}
+	})
+}
+
+// Tests that updates with a field mask only change the listed fields
+func TestPartialUpdate(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Taxi")
+
+		payload := &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: record.Id, Title: "Taxi home"},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}},
+		}
+		result, err := journalsService.Update(context.TODO(), payload)
+		if err != nil {
+			t.Fatalf("unable to update record\npayload: %v\n%v\n", payload, err)
+		}
+		updated := result.Record
+		if updated.Title != "Taxi home" {
+			t.Errorf("expected title 'Taxi home', got '%s'", updated.Title)
+		}
+		if updated.Description != record.Description || !proto.Equal(updated.Amount, record.Amount) || !proto.Equal(updated.Date, record.Date) {
+			t.Errorf("fields outside the mask changed from %v to %v", record, updated)
+		}
+
+		// only the masked fields are validated
+		payload = &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: record.Id, Amount: &pb.Money{Units: 100, CurrencyCode: "XYZ"}},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"description", "amount"}},
+		}
+		_, err = journalsService.Update(context.TODO(), payload)
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"amount.currency_code"}) {
+			t.Errorf("expected InvalidArgument on amount.currency_code, got %v", err)
+		}
+
+		for _, path := range []string{"id", "user_id", "created_at", "amount.units", "unknown"} {
+			payload := &pb.UpdateRecordRequest{
+				Record:     &pb.Record{Id: record.Id},
+				UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{path}},
+			}
+			_, err := journalsService.Update(context.TODO(), payload)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"update_mask"}) {
+				t.Errorf("expected InvalidArgument on update_mask for path '%s', got %v", path, err)
+			}
+		}
+	})
+}
+
+// Tests that writes against a stale version are refused
+func TestRecordVersions(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		record := createRecord(t, journalsService, pb.RecordType_EXPENSE, "Lunch")
+		if record.Version != 1 {
+			t.Errorf("expected version 1 on create, got %d", record.Version)
+		}
+
+		first := proto.Clone(record).(*pb.Record)
+		first.Title = "Lunch with Ana"
+		result, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{Record: first})
+		if err != nil {
+			t.Fatalf("unable to update record\n%v\n", err)
+		}
+		if result.Record.Version != 2 {
+			t.Errorf("expected version 2 after an update, got %d", result.Record.Version)
+		}
+
+		// a second device still holding version 1
+		second := proto.Clone(record).(*pb.Record)
+		second.Title = "Lunch alone"
+		if _, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{Record: second}); status.Code(err) != codes.Aborted {
+			t.Errorf("expected Aborted updating a stale version, got %v", err)
+		}
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id, Version: 1}); status.Code(err) != codes.Aborted {
+			t.Errorf("expected Aborted deleting a stale version, got %v", err)
+		}
+
+		stored, err := journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: record.Id})
+		if err != nil {
+			t.Fatalf("unable to get record\n%v\n", err)
+		}
+		if stored.Record.Title != "Lunch with Ana" {
+			t.Errorf("stale update overwrote the record, got '%s'", stored.Record.Title)
+		}
+
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id, Version: 2}); err != nil {
+			t.Errorf("unable to delete record at its current version\n%v\n", err)
+		}
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: record.Id, Version: 2}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound deleting a deleted record, got %v", err)
+		}
+	})
 }
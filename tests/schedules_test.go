@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func createSchedule(t *testing.T, schedulesService pb.SchedulesServiceClient, schedule *pb.Schedule) *pb.Schedule {
+	t.Helper()
+	res, err := schedulesService.CreateSchedule(context.TODO(), &pb.CreateScheduleRequest{Schedule: schedule})
+	if err != nil {
+		t.Fatalf("unable to create schedule\npayload: %v\n%v\n", schedule, err)
+	}
+	return res.Schedule
+}
+
+// scheduledRecords lists the records created by a schedule by date.
+func scheduledRecords(t *testing.T, journalsService pb.RecordsServiceClient, scheduleId string) []*pb.Record {
+	t.Helper()
+	res, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Sort: &pb.RecordSort{Field: pb.RecordSortField_SORT_BY_DATE, Ascending: true}})
+	if err != nil {
+		t.Fatalf("unable to get records\n%v\n", err)
+	}
+	records := []*pb.Record{}
+	for _, r := range res.Records {
+		if r.ScheduleId == scheduleId {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+func recordDates(records []*pb.Record) []string {
+	dates := []string{}
+	for _, r := range records {
+		dates = append(dates, r.Date.AsTime().Format(time.DateOnly))
+	}
+	return dates
+}
+
+// Tests schedules record their past occurrences right away
+func TestSchedules(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		schedulesService := pb.NewSchedulesServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		rent := createSchedule(t, schedulesService, &pb.Schedule{
+			Type: pb.RecordType_EXPENSE, Title: "Rent", Amount: &pb.Money{Units: 90000, CurrencyCode: "EUR"}, Tags: []string{"Home"},
+			Frequency: pb.Frequency_MONTHLY, StartDate: timestamppb.New(time.Date(2023, 1, 31, 9, 0, 0, 0, time.UTC)), Count: 4,
+		})
+		if rent.Interval != 1 || rent.NextDate != nil {
+			t.Errorf("expected an ended schedule of interval 1, got %v", rent)
+		}
+		records := scheduledRecords(t, journalsService, rent.Id)
+		if got := recordDates(records); !equalTitles(got, []string{"2023-01-31", "2023-02-28", "2023-03-31", "2023-04-30"}) {
+			t.Errorf("unexpected occurrences %v", got)
+		}
+		if records[0].Amount.Units != 90000 || !equalTitles(records[0].Tags, []string{"home"}) {
+			t.Errorf("unexpected scheduled record %v", records[0])
+		}
+
+		invalid := []struct {
+			schedule *pb.Schedule
+			field    string
+		}{
+			{&pb.Schedule{Type: pb.RecordType_EXPENSE, Title: "Gym", Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, StartDate: day(1), Interval: -1}, "interval"},
+			{&pb.Schedule{Type: pb.RecordType_EXPENSE, Title: "Gym", Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}}, "start_date"},
+			{&pb.Schedule{Type: pb.RecordType_EXPENSE, Title: "Gym", Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, StartDate: day(2), UntilDate: day(1)}, "until_date"},
+			{&pb.Schedule{Type: pb.RecordType_EXPENSE, Title: "Gym", Amount: &pb.Money{Units: -100, CurrencyCode: "EUR"}, StartDate: day(1)}, "amount.units"},
+		}
+		for _, c := range invalid {
+			_, err := schedulesService.CreateSchedule(context.TODO(), &pb.CreateScheduleRequest{Schedule: c.schedule})
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s creating %v, got %v", c.field, c.schedule, err)
+			}
+		}
+
+		// extending the schedule records the new occurrences only
+		updated, err := schedulesService.UpdateSchedule(context.TODO(), &pb.UpdateScheduleRequest{
+			Schedule:   &pb.Schedule{Id: rent.Id, Count: 6},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"count"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to update schedule\n%v\n", err)
+		}
+		if updated.Schedule.Title != "Rent" || updated.Schedule.Count != 6 {
+			t.Errorf("unexpected updated schedule %v", updated.Schedule)
+		}
+		if got := recordDates(scheduledRecords(t, journalsService, rent.Id)); len(got) != 6 || got[5] != "2023-06-30" {
+			t.Errorf("expected six occurrences, got %v", got)
+		}
+
+		_, err = schedulesService.SetOccurrence(context.TODO(), &pb.SetOccurrenceRequest{
+			ScheduleId: rent.Id, Occurrence: &pb.ScheduleOccurrence{Date: records[1].Date, Skip: true},
+		})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition skipping a recorded occurrence, got %v", err)
+		}
+		_, err = schedulesService.SetOccurrence(context.TODO(), &pb.SetOccurrenceRequest{
+			ScheduleId: rent.Id, Occurrence: &pb.ScheduleOccurrence{Date: day(1), Skip: true},
+		})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"occurrence.date"}) {
+			t.Errorf("expected InvalidArgument on occurrence.date, got %v", err)
+		}
+
+		list, err := schedulesService.ListSchedules(context.TODO(), &pb.ListSchedulesRequest{})
+		if err != nil || len(list.Schedules) != 1 {
+			t.Fatalf("expected a single schedule, got %v %v", list, err)
+		}
+		if _, err := schedulesService.DeleteSchedule(context.TODO(), &pb.DeleteScheduleRequest{Id: rent.Id}); err != nil {
+			t.Fatalf("unable to delete schedule\n%v\n", err)
+		}
+		if _, err := schedulesService.GetSchedule(context.TODO(), &pb.GetScheduleRequest{Id: rent.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound after delete, got %v", err)
+		}
+		if got := scheduledRecords(t, journalsService, rent.Id); len(got) != 6 {
+			t.Errorf("expected the records kept, got %v", recordDates(got))
+		}
+	})
+}
+
+// Tests the materializer records due occurrences once, with their exceptions
+func TestMaterializeSchedules(t *testing.T) {
+	for _, s := range stores {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			store := s.open(t)
+			conn := startServer(t, store)
+			schedulesService := pb.NewSchedulesServiceClient(conn)
+			journalsService := pb.NewRecordsServiceClient(conn)
+
+			start := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+			week := func(n int) time.Time { return start.AddDate(0, 0, 7*n) }
+			gym := createSchedule(t, schedulesService, &pb.Schedule{
+				Type: pb.RecordType_EXPENSE, Title: "Gym", Amount: &pb.Money{Units: 1500, CurrencyCode: "EUR"},
+				Frequency: pb.Frequency_WEEKLY, StartDate: timestamppb.New(start), UntilDate: timestamppb.New(week(10)),
+			})
+			if !gym.NextDate.AsTime().Equal(start) || len(scheduledRecords(t, journalsService, gym.Id)) != 0 {
+				t.Fatalf("expected no occurrence recorded yet, got %v", gym)
+			}
+
+			occurrences := []*pb.ScheduleOccurrence{
+				{Date: timestamppb.New(week(1)), Skip: true},
+				{Date: timestamppb.New(week(2)), Amount: &pb.Money{Units: 3000, CurrencyCode: "EUR"}, Title: "Gym and sauna"},
+			}
+			for _, o := range occurrences {
+				if _, err := schedulesService.SetOccurrence(context.TODO(), &pb.SetOccurrenceRequest{ScheduleId: gym.Id, Occurrence: o}); err != nil {
+					t.Fatalf("unable to set occurrence %v\n%v\n", o, err)
+				}
+			}
+			_, err := schedulesService.SetOccurrence(context.TODO(), &pb.SetOccurrenceRequest{
+				ScheduleId: gym.Id, Occurrence: &pb.ScheduleOccurrence{Date: timestamppb.New(week(3)), Amount: &pb.Money{Units: 3000, CurrencyCode: "USD"}},
+			})
+			if status.Code(err) != codes.InvalidArgument {
+				t.Errorf("expected InvalidArgument changing the currency of an occurrence, got %v", err)
+			}
+
+			// a server down for three weeks catches up at once, and only once
+			for pass, want := range []int{3, 0} {
+				n, err := db.Materialize(context.TODO(), store, week(3))
+				if err != nil || n != want {
+					t.Errorf("pass %d: expected %d records, got %d %v", pass, want, n, err)
+				}
+			}
+			records := scheduledRecords(t, journalsService, gym.Id)
+			if got := titles(records); !equalTitles(got, []string{"Gym", "Gym and sauna", "Gym"}) {
+				t.Fatalf("unexpected scheduled records %v", got)
+			}
+			if records[1].Amount.Units != 3000 || !records[1].Date.AsTime().Equal(week(2)) {
+				t.Errorf("expected the changed occurrence, got %v", records[1])
+			}
+			res, err := schedulesService.GetSchedule(context.TODO(), &pb.GetScheduleRequest{Id: gym.Id})
+			if err != nil {
+				t.Fatalf("unable to get schedule\n%v\n", err)
+			}
+			if !res.Schedule.NextDate.AsTime().Equal(week(4)) || len(res.Schedule.Exceptions) != 2 {
+				t.Errorf("unexpected schedule progress %v", res.Schedule)
+			}
+
+			// the occurrences after until_date are never recorded
+			if n, err := db.Materialize(context.TODO(), store, week(20)); err != nil || n != 7 {
+				t.Errorf("expected the remaining 7 records, got %d %v", n, err)
+			}
+			if due, err := store.DueSchedules(context.TODO(), week(30)); err != nil || len(due) != 0 {
+				t.Errorf("expected the schedule ended, got %v %v", due, err)
+			}
+		})
+	}
+}
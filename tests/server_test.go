@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/auth"
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/db/memstore"
+	"github.com/fine-track/journals-app/db/sqlstore"
+	"github.com/fine-track/journals-app/services"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testSecret = []byte("records-test-secret")
+
+// stores lists the backends every service test runs against.
+var stores = []struct {
+	name string
+	open func(t *testing.T) db.Store
+}{
+	{"memory", func(t *testing.T) db.Store { return memstore.New() }},
+	{"sqlite", func(t *testing.T) db.Store {
+		store, err := sqlstore.Open(context.Background(), sqlstore.SQLite, filepath.Join(t.TempDir(), "records.db"))
+		if err != nil {
+			t.Fatalf("unable to open sqlite store\n%v\n", err)
+		}
+		t.Cleanup(func() { store.Close() })
+		return store
+	}},
+}
+
+// testConn is a client connection authenticated as USER_ID that can open
+// connections for other users of the same server.
+type testConn struct {
+	*grpc.ClientConn
+	listener *bufconn.Listener
+}
+
+// eachStore runs fn as a subtest against a fresh server for every backend.
+func eachStore(t *testing.T, fn func(t *testing.T, conn *testConn)) {
+	for _, s := range stores {
+		s := s
+		t.Run(s.name, func(t *testing.T) {
+			fn(t, startServer(t, s.open(t)))
+		})
+	}
+}
+
+// startServer runs the services on an in-memory listener and returns a
+// client connection to it, closed when the test ends. The interceptors
+// given run last, around the services.
+func startServer(t *testing.T, store db.Store, interceptors ...grpc.UnaryServerInterceptor) *testConn {
+	t.Helper()
+	verifier, err := auth.NewVerifier(auth.Config{HMACSecret: testSecret})
+	if err != nil {
+		t.Fatalf("unable to create verifier\n%v\n", err)
+	}
+	listener := bufconn.Listen(1024 * 1024)
+	unary := append([]grpc.UnaryServerInterceptor{services.ErrorInterceptor(), verifier.UnaryInterceptor(), services.IdempotencyInterceptor(store, time.Hour)}, interceptors...)
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(services.StreamErrorInterceptor(), verifier.StreamInterceptor(), services.StreamIdempotencyInterceptor(store, time.Hour)),
+	)
+	services.RegisterRecordsService(s, store)
+	services.RegisterCategoriesService(s, store)
+	services.RegisterTagsService(s, store)
+	services.RegisterAccountsService(s, store)
+	services.RegisterSchedulesService(s, store)
+	services.RegisterBudgetsService(s, store)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn := &testConn{listener: listener}
+	conn.ClientConn = conn.dial(t, grpc.WithPerRPCCredentials(bearer(signToken(t, USER_ID))))
+	return conn
+}
+
+// As returns a connection authenticated as another user.
+func (c *testConn) As(t *testing.T, userId string) *grpc.ClientConn {
+	return c.dial(t, grpc.WithPerRPCCredentials(bearer(signToken(t, userId))))
+}
+
+// Anonymous returns a connection sending no token.
+func (c *testConn) Anonymous(t *testing.T) *grpc.ClientConn {
+	return c.dial(t)
+}
+
+func (c *testConn) dial(t *testing.T, opts ...grpc.DialOption) *grpc.ClientConn {
+	t.Helper()
+	dialer := func(ctx context.Context, _ string) (net.Conn, error) { return c.listener.DialContext(ctx) }
+	opts = append(opts, grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.Dial("bufnet", opts...)
+	if err != nil {
+		t.Fatalf("unable to connect to the service\n%v\n", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func signToken(t *testing.T, userId string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   userId,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString(testSecret)
+	if err != nil {
+		t.Fatalf("unable to sign token\n%v\n", err)
+	}
+	return token
+}
+
+// bearer sends a token as per-RPC credentials over the insecure test transport.
+type bearer string
+
+func (b bearer) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(b)}, nil
+}
+
+func (b bearer) RequireTransportSecurity() bool {
+	return false
+}
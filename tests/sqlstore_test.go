@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/db/sqlstore"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Tests that reopening an existing database keeps its data and skips applied migrations
+func TestSQLiteReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.db")
+	userId, _ := primitive.ObjectIDFromHex(USER_ID)
+
+	store, err := sqlstore.Open(context.Background(), sqlstore.SQLite, path)
+	if err != nil {
+		t.Fatalf("unable to open sqlite store\n%v\n", err)
+	}
+	record := db.Record{UserId: userId, Type: "INCOME", Title: "Salary", Amount: db.Money{Units: 5000, Currency: "USD"}, Date: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)}
+	if err := store.Create(context.Background(), &record); err != nil {
+		t.Fatalf("unable to create record\n%v\n", err)
+	}
+	store.Close()
+
+	store, err = sqlstore.Open(context.Background(), sqlstore.SQLite, path)
+	if err != nil {
+		t.Fatalf("unable to reopen sqlite store\n%v\n", err)
+	}
+	defer store.Close()
+	got, err := store.Get(context.Background(), userId, record.ID)
+	if err != nil {
+		t.Fatalf("unable to get record after reopening\n%v\n", err)
+	}
+	if got.Title != "Salary" || got.Amount.Units != 5000 {
+		t.Errorf("record changed after reopening, got %v", got)
+	}
+}
+
+// Tests that string dates written before migration 3 are converted
+func TestSQLiteMigrateLegacyTimes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records.db")
+	legacy, err := sql.Open(sqlstore.SQLite, path)
+	if err != nil {
+		t.Fatalf("unable to open sqlite database\n%v\n", err)
+	}
+	id := primitive.NewObjectID()
+	userId, _ := primitive.ObjectIDFromHex(USER_ID)
+	for _, stmt := range []string{
+		`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY, applied_at TEXT NOT NULL)`,
+		`INSERT INTO schema_migrations VALUES (1, ''), (2, '')`,
+		`CREATE TABLE records (id TEXT PRIMARY KEY, user_id TEXT NOT NULL, type TEXT NOT NULL, date TEXT NOT NULL,
+			title TEXT NOT NULL, description TEXT NOT NULL, amount BIGINT NOT NULL, currency TEXT NOT NULL,
+			created_at TEXT NOT NULL, updated_at TEXT NOT NULL)`,
+		`INSERT INTO records VALUES ('` + id.Hex() + `', '` + USER_ID + `', 'EXPENSE', '2023-08-05', 'Legacy', '', 1100, 'USD',
+			'2023-08-06 10:11:12.123456789 +0000 UTC', 'not a date')`,
+	} {
+		if _, err := legacy.Exec(stmt); err != nil {
+			t.Fatalf("unable to prepare legacy database\n%v\n", err)
+		}
+	}
+	legacy.Close()
+
+	store, err := sqlstore.Open(context.Background(), sqlstore.SQLite, path)
+	if err != nil {
+		t.Fatalf("unable to migrate sqlite store\n%v\n", err)
+	}
+	defer store.Close()
+	got, err := store.Get(context.Background(), userId, id)
+	if err != nil {
+		t.Fatalf("unable to get migrated record\n%v\n", err)
+	}
+	createdAt := time.Date(2023, 8, 6, 10, 11, 12, 123000000, time.UTC)
+	if !got.Date.Equal(time.Date(2023, 8, 5, 0, 0, 0, 0, time.UTC)) || !got.CreatedAt.Equal(createdAt) || !got.UpdatedAt.Equal(createdAt) {
+		t.Errorf("unexpected migrated times, got date %v created %v updated %v", got.Date, got.CreatedAt, got.UpdatedAt)
+	}
+	if got.Version != 1 {
+		t.Errorf("expected migrated records at version 1, got %d", got.Version)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// summaryLines renders rows as "start group currency income expense net count".
+func summaryLines(rows []*pb.SummaryRow) []string {
+	lines := []string{}
+	for _, r := range rows {
+		lines = append(lines, fmt.Sprintf("%s %s %s %d %d %d %d", r.PeriodStart.AsTime().Format(time.RFC3339), r.Group, r.Income.CurrencyCode,
+			r.Income.Units, r.Expense.Units, r.Net.Units, r.RecordCount))
+	}
+	return lines
+}
+
+// Tests totals per period, group and currency, transfers left out
+func TestGetSummary(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		accountsService := pb.NewAccountsServiceClient(conn)
+		checking := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "EUR"})
+		savings := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Savings", Kind: pb.AccountKind_SAVINGS, CurrencyCode: "EUR"})
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_INCOME, Title: "Salary", Amount: &pb.Money{Units: 300000, CurrencyCode: "EUR"}, Date: day(1), Tags: []string{"salary"}, AccountId: checking.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Rent", Amount: &pb.Money{Units: 90000, CurrencyCode: "EUR"}, Date: day(2), Tags: []string{"home"}, AccountId: checking.Id},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Groceries", Amount: &pb.Money{Units: 4500, CurrencyCode: "EUR"}, Tags: []string{"food", "home"},
+				Date: timestamppb.New(time.Date(2023, 8, 31, 23, 30, 0, 0, time.UTC))},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Coffee", Amount: &pb.Money{Units: 350, CurrencyCode: "USD"},
+				Date: timestamppb.New(time.Date(2023, 9, 5, 8, 0, 0, 0, time.UTC))},
+		)
+		_, err := journalsService.CreateTransfer(context.TODO(), &pb.CreateTransferRequest{
+			FromAccountId: checking.Id, ToAccountId: savings.Id, Amount: &pb.Money{Units: 50000, CurrencyCode: "EUR"}, Date: day(10),
+		})
+		if err != nil {
+			t.Fatalf("unable to create transfer\n%v\n", err)
+		}
+
+		cases := []struct {
+			name    string
+			request *pb.GetSummaryRequest
+			want    []string
+		}{
+			{"monthly", &pb.GetSummaryRequest{}, []string{
+				"2023-08-01T00:00:00Z  EUR 300000 94500 205500 3",
+				"2023-09-01T00:00:00Z  USD 0 350 -350 1",
+			}},
+			{"time zone", &pb.GetSummaryRequest{TimeZone: "Europe/Berlin"}, []string{
+				"2023-07-31T22:00:00Z  EUR 300000 90000 210000 2",
+				"2023-08-31T22:00:00Z  EUR 0 4500 -4500 1",
+				"2023-08-31T22:00:00Z  USD 0 350 -350 1",
+			}},
+			{"weekly", &pb.GetSummaryRequest{Period: pb.SummaryPeriod_WEEK, Filter: &pb.RecordFilter{ToDate: day(31)}}, []string{
+				"2023-07-31T00:00:00Z  EUR 300000 90000 210000 2",
+			}},
+			{"yearly by tag", &pb.GetSummaryRequest{Period: pb.SummaryPeriod_YEAR, GroupBy: pb.SummaryGroup_BY_TAG}, []string{
+				"2023-01-01T00:00:00Z  USD 0 350 -350 1",
+				"2023-01-01T00:00:00Z food EUR 0 4500 -4500 1",
+				"2023-01-01T00:00:00Z home EUR 0 94500 -94500 2",
+				"2023-01-01T00:00:00Z salary EUR 300000 0 300000 1",
+			}},
+			{"by account", &pb.GetSummaryRequest{GroupBy: pb.SummaryGroup_BY_ACCOUNT, Filter: &pb.RecordFilter{CurrencyCode: "EUR"}}, []string{
+				"2023-08-01T00:00:00Z  EUR 0 4500 -4500 1",
+				"2023-08-01T00:00:00Z " + checking.Id + " EUR 300000 90000 210000 2",
+			}},
+		}
+		for _, c := range cases {
+			res, err := journalsService.GetSummary(context.TODO(), c.request)
+			if err != nil {
+				t.Fatalf("%s: unable to get summary\n%v\n", c.name, err)
+			}
+			if got := summaryLines(res.Rows); !equalTitles(got, c.want) {
+				t.Errorf("%s: expected %q, got %q", c.name, c.want, got)
+			}
+		}
+
+		invalid := []struct {
+			request *pb.GetSummaryRequest
+			field   string
+		}{
+			{&pb.GetSummaryRequest{TimeZone: "Mars/Olympus"}, "time_zone"},
+			{&pb.GetSummaryRequest{Period: pb.SummaryPeriod(42)}, "period"},
+			{&pb.GetSummaryRequest{Filter: &pb.RecordFilter{FromDate: day(5), ToDate: day(1)}}, "filter.to_date"},
+		}
+		for _, c := range invalid {
+			_, err := journalsService.GetSummary(context.TODO(), c.request)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s for %v, got %v", c.field, c.request, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+func tagCounts(tags []*pb.Tag) map[string]int64 {
+	counts := map[string]int64{}
+	for _, t := range tags {
+		counts[t.Name] = t.RecordCount
+	}
+	return counts
+}
+
+// Tests tags are normalized and can be filtered on
+func TestRecordTags(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		result, err := journalsService.Create(context.TODO(), &pb.CreateRecordRequest{
+			Type: pb.RecordType_EXPENSE, Title: "Hotel", Amount: &pb.Money{Units: 20000, CurrencyCode: "EUR"}, Date: day(1),
+			Tags: []string{" Vacation-2026", "reimbursable", "VACATION-2026"},
+		})
+		if err != nil {
+			t.Fatalf("unable to create record\n%v\n", err)
+		}
+		if !equalTitles(result.Record.Tags, []string{"reimbursable", "vacation-2026"}) {
+			t.Errorf("expected normalized tags, got %v", result.Record.Tags)
+		}
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Flight", Amount: &pb.Money{Units: 30000, CurrencyCode: "EUR"}, Date: day(2), Tags: []string{"vacation-2026"}},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Dinner", Amount: &pb.Money{Units: 6000, CurrencyCode: "EUR"}, Date: day(3), Tags: []string{"Shared"}},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Bus", Amount: &pb.Money{Units: 200, CurrencyCode: "EUR"}, Date: day(4)},
+		)
+
+		for _, tags := range [][]string{{""}, {"a,b"}} {
+			_, err := journalsService.Create(context.TODO(), &pb.CreateRecordRequest{
+				Type: pb.RecordType_EXPENSE, Title: "Invalid", Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, Date: day(5), Tags: tags,
+			})
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"tags"}) {
+				t.Errorf("expected InvalidArgument on tags for %q, got %v", tags, err)
+			}
+		}
+
+		cases := []struct {
+			name   string
+			filter *pb.RecordFilter
+			want   []string
+		}{
+			{"any", &pb.RecordFilter{AnyTags: []string{"SHARED", "reimbursable"}}, []string{"Dinner", "Hotel"}},
+			{"all", &pb.RecordFilter{AllTags: []string{"vacation-2026", "Reimbursable"}}, []string{"Hotel"}},
+			{"any and all", &pb.RecordFilter{AnyTags: []string{"shared", "vacation-2026"}, AllTags: []string{"vacation-2026"}}, []string{"Flight", "Hotel"}},
+		}
+		for _, c := range cases {
+			list, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: c.filter})
+			if err != nil {
+				t.Fatalf("unable to get records\npayload: %v\n%v\n", c.filter, err)
+			}
+			if got := titles(list.Records); !equalTitles(got, c.want) {
+				t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+			}
+		}
+
+		// tags are replaced by a masked update
+		updated, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: result.Record.Id, Tags: []string{"work"}},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"tags"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to update tags\n%v\n", err)
+		}
+		if !equalTitles(updated.Record.Tags, []string{"work"}) || updated.Record.Title != "Hotel" {
+			t.Errorf("unexpected record after updating its tags %v", updated.Record)
+		}
+	})
+}
+
+// Tests listing tags with their counts and renaming them
+func TestTags(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		tagsService := pb.NewTagsServiceClient(conn)
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Hotel", Amount: &pb.Money{Units: 20000, CurrencyCode: "EUR"}, Date: day(1), Tags: []string{"trip", "holiday"}},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Flight", Amount: &pb.Money{Units: 30000, CurrencyCode: "EUR"}, Date: day(2), Tags: []string{"trip"}},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Dinner", Amount: &pb.Money{Units: 6000, CurrencyCode: "EUR"}, Date: day(3), Tags: []string{"shared"}},
+		)
+		other := pb.NewRecordsServiceClient(conn.As(t, OTHER_USER_ID))
+		seedRecords(t, other, &pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Theirs", Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, Date: day(1), Tags: []string{"trip"}})
+
+		list, err := tagsService.ListTags(context.TODO(), &pb.ListTagsRequest{})
+		if err != nil {
+			t.Fatalf("unable to list tags\n%v\n", err)
+		}
+		if len(list.Tags) != 3 || list.Tags[0].Name != "trip" || list.Tags[0].RecordCount != 2 {
+			t.Errorf("expected trip first with 2 records, got %v", list.Tags)
+		}
+
+		renamed, err := tagsService.RenameTag(context.TODO(), &pb.RenameTagRequest{From: "Trip", To: "holiday"})
+		if err != nil {
+			t.Fatalf("unable to rename tag\n%v\n", err)
+		}
+		if renamed.RecordsUpdated != 2 {
+			t.Errorf("expected 2 records updated, got %d", renamed.RecordsUpdated)
+		}
+		list, err = tagsService.ListTags(context.TODO(), &pb.ListTagsRequest{})
+		if err != nil {
+			t.Fatalf("unable to list tags\n%v\n", err)
+		}
+		counts := tagCounts(list.Tags)
+		if len(counts) != 2 || counts["holiday"] != 2 || counts["shared"] != 1 {
+			t.Errorf("unexpected tags after renaming, got %v", counts)
+		}
+
+		records, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{AnyTags: []string{"holiday"}}})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		for _, r := range records.Records {
+			if !equalTitles(r.Tags, []string{"holiday"}) || r.Version != 2 {
+				t.Errorf("expected a single merged tag on a new version, got %v", r)
+			}
+		}
+
+		theirs, err := pb.NewTagsServiceClient(conn.As(t, OTHER_USER_ID)).ListTags(context.TODO(), &pb.ListTagsRequest{})
+		if err != nil {
+			t.Fatalf("unable to list tags\n%v\n", err)
+		}
+		if counts := tagCounts(theirs.Tags); len(counts) != 1 || counts["trip"] != 1 {
+			t.Errorf("renaming changed another user's tags, got %v", counts)
+		}
+
+		_, err = tagsService.RenameTag(context.TODO(), &pb.RenameTagRequest{From: "holiday", To: " "})
+		if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{"to"}) {
+			t.Errorf("expected InvalidArgument on to for an empty tag, got %v", err)
+		}
+	})
+}
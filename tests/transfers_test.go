@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fine-track/journals-app/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Tests transfers are written as linked records left out of income and expenses
+func TestTransfers(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		accountsService := pb.NewAccountsServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		checking := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "EUR", OpeningBalance: 100000})
+		savings := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Savings", Kind: pb.AccountKind_SAVINGS, CurrencyCode: "EUR"})
+		dollars := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Dollars", Kind: pb.AccountKind_BANK, CurrencyCode: "USD"})
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_INCOME, Title: "Salary", Amount: &pb.Money{Units: 5000, CurrencyCode: "EUR"}, Date: day(1), AccountId: checking.Id},
+		)
+
+		transfer, err := journalsService.CreateTransfer(context.TODO(), &pb.CreateTransferRequest{
+			FromAccountId: checking.Id, ToAccountId: savings.Id, Amount: &pb.Money{Units: 30000, CurrencyCode: "EUR"}, Date: day(2), Title: "Save",
+		})
+		if err != nil {
+			t.Fatalf("unable to create transfer\n%v\n", err)
+		}
+		debit, credit := transfer.Debit, transfer.Credit
+		if debit.Type != pb.RecordType_EXPENSE || debit.AccountId != checking.Id || credit.Type != pb.RecordType_INCOME || credit.AccountId != savings.Id {
+			t.Errorf("unexpected transfer records %v and %v", debit, credit)
+		}
+		if debit.TransferId == "" || debit.TransferId != credit.TransferId {
+			t.Errorf("expected both records linked to the transfer, got %q and %q", debit.TransferId, credit.TransferId)
+		}
+
+		invalid := []struct {
+			payload *pb.CreateTransferRequest
+			field   string
+		}{
+			{&pb.CreateTransferRequest{FromAccountId: checking.Id, ToAccountId: checking.Id, Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, Date: day(3)}, "to_account_id"},
+			{&pb.CreateTransferRequest{FromAccountId: checking.Id, ToAccountId: dollars.Id, Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, Date: day(3)}, "to_account_id"},
+			{&pb.CreateTransferRequest{ToAccountId: savings.Id, Amount: &pb.Money{Units: 100, CurrencyCode: "EUR"}, Date: day(3)}, "from_account_id"},
+			{&pb.CreateTransferRequest{FromAccountId: checking.Id, ToAccountId: savings.Id, Amount: &pb.Money{CurrencyCode: "EUR"}, Date: day(3)}, "amount.units"},
+		}
+		for _, c := range invalid {
+			_, err := journalsService.CreateTransfer(context.TODO(), c.payload)
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s creating %v, got %v", c.field, c.payload, err)
+			}
+		}
+
+		balances, err := accountsService.GetBalances(context.TODO(), &pb.GetBalancesRequest{AccountIds: []string{checking.Id, savings.Id}})
+		if err != nil {
+			t.Fatalf("unable to get balances\n%v\n", err)
+		}
+		from, to := balances.Balances[0], balances.Balances[1]
+		if from.Balance.Units != 75000 || from.Income.Units != 5000 || from.Expense.Units != 0 || from.TransfersOut.Units != 30000 {
+			t.Errorf("unexpected balance of the from account %v", from)
+		}
+		if to.Balance.Units != 30000 || to.Income.Units != 0 || to.TransfersIn.Units != 30000 {
+			t.Errorf("unexpected balance of the to account %v", to)
+		}
+
+		list, err := journalsService.GetRecords(context.TODO(), &pb.GetRecordsRequest{Filter: &pb.RecordFilter{ExcludeTransfers: true}})
+		if err != nil {
+			t.Fatalf("unable to get records\n%v\n", err)
+		}
+		if got := titles(list.Records); !equalTitles(got, []string{"Salary"}) {
+			t.Errorf("expected the transfer left out, got %v", got)
+		}
+
+		// the shared fields follow on the other side
+		updated, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{
+			Record:     &pb.Record{Id: debit.Id, Amount: &pb.Money{Units: 20000, CurrencyCode: "EUR"}, Title: "Monthly saving", Tags: []string{"plan"}},
+			UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"amount", "title", "tags"}},
+		})
+		if err != nil {
+			t.Fatalf("unable to update transfer record\n%v\n", err)
+		}
+		if updated.Record.Amount.Units != 20000 || updated.Record.Version != 2 {
+			t.Errorf("unexpected updated record %v", updated.Record)
+		}
+		other, err := journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: credit.Id})
+		if err != nil {
+			t.Fatalf("unable to get record\n%v\n", err)
+		}
+		if other.Record.Amount.Units != 20000 || other.Record.Title != "Monthly saving" || len(other.Record.Tags) != 0 || other.Record.Version != 2 {
+			t.Errorf("expected the amount and title on the other side, got %v", other.Record)
+		}
+
+		refused := []struct {
+			record *pb.Record
+			path   string
+			field  string
+		}{
+			{&pb.Record{Id: debit.Id, Type: pb.RecordType_INCOME}, "type", "type"},
+			{&pb.Record{Id: debit.Id, AccountId: savings.Id}, "account_id", "account_id"},
+			{&pb.Record{Id: debit.Id}, "account_id", "account_id"},
+		}
+		for _, c := range refused {
+			_, err := journalsService.Update(context.TODO(), &pb.UpdateRecordRequest{Record: c.record, UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{c.path}}})
+			if status.Code(err) != codes.InvalidArgument || !equalTitles(violatedFields(err), []string{c.field}) {
+				t.Errorf("expected InvalidArgument on %s updating %v, got %v", c.field, c.record, err)
+			}
+		}
+
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: credit.Id}); err != nil {
+			t.Fatalf("unable to delete transfer record\n%v\n", err)
+		}
+		_, err = journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: debit.Id})
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("expected the other side deleted too, got %v", err)
+		}
+	})
+}
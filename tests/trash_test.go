@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fine-track/journals-app/db"
+	"github.com/fine-track/journals-app/pb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Tests deleted records go to the trash until restored or purged
+func TestTrash(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		journalsService := pb.NewRecordsServiceClient(conn)
+		categoriesService := pb.NewCategoriesServiceClient(conn)
+		food := createCategory(t, categoriesService, &pb.CreateCategoryRequest{Name: "Food"})
+		seedRecords(t, journalsService,
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Lunch", Amount: &pb.Money{Units: 1200, CurrencyCode: "EUR"}, Date: day(1), CategoryId: food.Id, Tags: []string{"work"}},
+			&pb.CreateRecordRequest{Type: pb.RecordType_EXPENSE, Title: "Dinner", Amount: &pb.Money{Units: 2500, CurrencyCode: "EUR"}, Date: day(2)},
+			&pb.CreateRecordRequest{Type: pb.RecordType_INCOME, Title: "Salary", Amount: &pb.Money{Units: 5000, CurrencyCode: "EUR"}, Date: day(3)},
+		)
+		records := allRecords(t, journalsService).Records
+		ids := map[string]*pb.Record{}
+		for _, r := range records {
+			ids[r.Title] = r
+		}
+		lunch := ids["Lunch"]
+		for _, title := range []string{"Lunch", "Dinner"} {
+			if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: ids[title].Id}); err != nil {
+				t.Fatalf("unable to delete record\n%v\n", err)
+			}
+		}
+
+		// the trash is hidden from the records
+		if list := allRecords(t, journalsService); !equalTitles(titles(list.Records), []string{"Salary"}) {
+			t.Errorf("expected the deleted records hidden, got %v", titles(list.Records))
+		}
+		if _, err := journalsService.GetRecord(context.TODO(), &pb.GetRecordRequest{Id: lunch.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound getting a deleted record, got %v", err)
+		}
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: lunch.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound deleting a deleted record, got %v", err)
+		}
+
+		// most recently deleted first, over pages
+		page, err := journalsService.ListTrash(context.TODO(), &pb.ListTrashRequest{PageSize: 1})
+		if err != nil {
+			t.Fatalf("unable to list trash\n%v\n", err)
+		}
+		next, err := journalsService.ListTrash(context.TODO(), &pb.ListTrashRequest{PageSize: 1, PageToken: page.NextPageToken})
+		if err != nil {
+			t.Fatalf("unable to list trash\n%v\n", err)
+		}
+		trashed := append(page.Records, next.Records...)
+		if !equalTitles(titles(trashed), []string{"Dinner", "Lunch"}) || next.NextPageToken != "" {
+			t.Errorf("expected the deleted records in the trash, got %v", titles(trashed))
+		}
+		if trashed[1].DeletedAt == nil || !equalTitles(trashed[1].Tags, []string{"work"}) || trashed[1].CategoryId != food.Id {
+			t.Errorf("expected the deleted record kept as it was, got %v", trashed[1])
+		}
+		other := pb.NewRecordsServiceClient(conn.As(t, "64d1b7e92b3de19c6a478937"))
+		if list, err := other.ListTrash(context.TODO(), &pb.ListTrashRequest{}); err != nil || len(list.Records) != 0 {
+			t.Errorf("expected the trash of another user empty, got %v %v", list, err)
+		}
+		if _, err := other.RestoreRecord(context.TODO(), &pb.RestoreRecordRequest{Id: lunch.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound restoring the record of another user, got %v", err)
+		}
+
+		// a restored record comes back without the category deleted since
+		if _, err := categoriesService.DeleteCategory(context.TODO(), &pb.DeleteCategoryRequest{Id: food.Id}); err != nil {
+			t.Fatalf("unable to delete category\n%v\n", err)
+		}
+		restored, err := journalsService.RestoreRecord(context.TODO(), &pb.RestoreRecordRequest{Id: lunch.Id})
+		if err != nil {
+			t.Fatalf("unable to restore record\n%v\n", err)
+		}
+		if restored.Record.Id != lunch.Id || restored.Record.CategoryId != "" || restored.Record.DeletedAt != nil || !equalTitles(restored.Record.Tags, []string{"work"}) {
+			t.Errorf("unexpected restored record %v", restored.Record)
+		}
+		if restored.Record.Version != lunch.Version+1 {
+			t.Errorf("expected the record without its category as a new version, got version %d", restored.Record.Version)
+		}
+		if list := allRecords(t, journalsService); !equalTitles(titles(list.Records), []string{"Lunch", "Salary"}) {
+			t.Errorf("expected the restored record listed, got %v", titles(list.Records))
+		}
+		if _, err := journalsService.RestoreRecord(context.TODO(), &pb.RestoreRecordRequest{Id: lunch.Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound restoring a record out of the trash, got %v", err)
+		}
+
+		if _, err := journalsService.PurgeRecord(context.TODO(), &pb.PurgeRecordRequest{Id: ids["Dinner"].Id}); err != nil {
+			t.Fatalf("unable to purge record\n%v\n", err)
+		}
+		if list, err := journalsService.ListTrash(context.TODO(), &pb.ListTrashRequest{}); err != nil || len(list.Records) != 0 {
+			t.Errorf("expected the trash empty, got %v %v", list, err)
+		}
+		if _, err := journalsService.RestoreRecord(context.TODO(), &pb.RestoreRecordRequest{Id: ids["Dinner"].Id}); status.Code(err) != codes.NotFound {
+			t.Errorf("expected NotFound restoring a purged record, got %v", err)
+		}
+	})
+}
+
+// Tests both sides of a transfer go to the trash and back together
+func TestTrashTransfers(t *testing.T) {
+	eachStore(t, func(t *testing.T, conn *testConn) {
+		accountsService := pb.NewAccountsServiceClient(conn)
+		journalsService := pb.NewRecordsServiceClient(conn)
+		checking := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Checking", Kind: pb.AccountKind_BANK, CurrencyCode: "EUR"})
+		savings := createAccount(t, accountsService, &pb.CreateAccountRequest{Name: "Savings", Kind: pb.AccountKind_SAVINGS, CurrencyCode: "EUR"})
+		transfer, err := journalsService.CreateTransfer(context.TODO(), &pb.CreateTransferRequest{
+			FromAccountId: checking.Id, ToAccountId: savings.Id, Amount: &pb.Money{Units: 300, CurrencyCode: "EUR"}, Date: day(2), Title: "Save",
+		})
+		if err != nil {
+			t.Fatalf("unable to create transfer\n%v\n", err)
+		}
+
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: transfer.Credit.Id}); err != nil {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		if list, err := journalsService.ListTrash(context.TODO(), &pb.ListTrashRequest{}); err != nil || len(list.Records) != 2 {
+			t.Errorf("expected both sides of the transfer in the trash, got %v %v", list, err)
+		}
+		if _, err := journalsService.RestoreRecord(context.TODO(), &pb.RestoreRecordRequest{Id: transfer.Debit.Id}); err != nil {
+			t.Fatalf("unable to restore record\n%v\n", err)
+		}
+		if list := allRecords(t, journalsService); len(list.Records) != 2 {
+			t.Errorf("expected both sides of the transfer restored, got %v", titles(list.Records))
+		}
+
+		// a restore needs the account of the record
+		if _, err := journalsService.Delete(context.TODO(), &pb.DeleteRecordRequest{Id: transfer.Debit.Id}); err != nil {
+			t.Fatalf("unable to delete record\n%v\n", err)
+		}
+		if _, err := accountsService.DeleteAccount(context.TODO(), &pb.DeleteAccountRequest{Id: savings.Id}); err != nil {
+			t.Fatalf("unable to delete account\n%v\n", err)
+		}
+		_, err = journalsService.RestoreRecord(context.TODO(), &pb.RestoreRecordRequest{Id: transfer.Debit.Id})
+		if status.Code(err) != codes.FailedPrecondition {
+			t.Errorf("expected FailedPrecondition restoring a record of a deleted account, got %v", err)
+		}
+		if list, err := journalsService.ListTrash(context.TODO(), &pb.ListTrashRequest{}); err != nil || len(list.Records) != 2 {
+			t.Errorf("expected the failed restore to keep the trash, got %v %v", list, err)
+		}
+	})
+}
+
+// Tests the trash is purged of the records deleted before the retention
+func TestPurgeTrash(t *testing.T) {
+	for _, s := range stores {
+		t.Run(s.name, func(t *testing.T) {
+			store := s.open(t)
+			ctx := context.TODO()
+			userId := primitive.NewObjectID()
+			r := db.Record{UserId: userId, Type: "EXPENSE", Title: "Lunch", Amount: db.Money{Units: 1200, Currency: "EUR"}, Date: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)}
+			if err := store.Create(ctx, &r); err != nil {
+				t.Fatalf("unable to create record\n%v\n", err)
+			}
+			if err := store.Delete(ctx, userId, r.ID, 0); err != nil {
+				t.Fatalf("unable to delete record\n%v\n", err)
+			}
+
+			if n, err := store.PurgeTrash(ctx, db.Now().Add(-time.Hour)); err != nil || n != 0 {
+				t.Errorf("expected no record purged within the retention, got %d %v", n, err)
+			}
+			if n, err := store.PurgeTrash(ctx, db.Now().Add(time.Hour)); err != nil || n != 1 {
+				t.Errorf("expected the deleted record purged, got %d %v", n, err)
+			}
+			if _, err := store.RestoreRecord(ctx, userId, r.ID); !errors.Is(err, db.ErrNotFound) {
+				t.Errorf("expected ErrNotFound restoring a purged record, got %v", err)
+			}
+		})
+	}
+}